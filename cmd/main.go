@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	_ "time/tzdata" // часовые пояса для статистики, в alpine образе их нет
 
 	"github.com/bigxxby/dream-test-task/internal/app"
 	"github.com/bigxxby/dream-test-task/internal/config"
@@ -181,11 +181,91 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves all the shortened links associated with the authenticated user.",
+                "description": "Retrieves all the shortened links associated with the authenticated user.\nEach link carries the destination metadata and the last health check result (health.status,\nhealth.latency_ms, health.checked_at, health.broken).\nThe list is paginated with an opaque cursor: pass pagination.next_cursor from the previous page\nas cursor while has_more is true, keeping the same sort and filters.\nstatus filters are exclusive, checked in order: expired (expires_at passed), scheduled (active_from\nnot reached), exhausted (max_clicks used up), active (everything else).\nq is a full-text search: it is split into words at any character other than a letter or digit, and\nevery word must match the start of a word in the destination URL, the short ID or alias or the page title.",
                 "tags": [
                     "Shortener"
                 ],
                 "summary": "Get all shortened links for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cursor from pagination.next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, 1-200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "clicks",
+                            "last_click"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "scheduled",
+                            "exhausted",
+                            "expired"
+                        ],
+                        "type": "string",
+                        "description": "Only links in this state",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339 or YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339 or YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Destination domain, subdomains included",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Links retrieved successfully",
@@ -193,6 +273,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/shortener.GetLinksResponse"
                         }
                     },
+                    "400": {
+                        "description": "Invalid cursor or filter",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -213,7 +299,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Creates a new shortened link from the provided URL.",
+                "description": "Creates a new shortened link from the provided URL.\nAn optional case-insensitive alias can be used instead of a random short ID.\nUTM parameters are stored on the link and appended to the destination on redirect;\nutm_template applies a saved set of them, explicitly passed parameters take precedence.\nmax_clicks (or one_time) disables the link after that many redirects.\ntarget_rules route visitors by OS, device, browser or language, e.g. iOS to the App Store.\nvariants split the traffic between several weighted destinations for A/B tests.\nWithout expires_at the server default TTL applies; never_expires and long expirations depend on the user role.",
                 "tags": [
                     "Shortener"
                 ],
@@ -237,10 +323,93 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid URL or missing parameters",
+                        "description": "Invalid URL, alias or missing parameters",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "UTM template not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Alias already taken",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/broken": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves links of the authenticated user whose destination failed the periodic health check\n(no response, 404, 410 or 5xx) several times in a row. The health field holds the last check result.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shortener"
+                ],
+                "summary": "Get links with broken destinations",
+                "responses": {
+                    "200": {
+                        "description": "Links retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.GetLinksResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/shortener.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/shortener/shared": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves links of other users that the authenticated user has read-only access to.",
+                "tags": [
+                    "Sharing"
+                ],
+                "summary": "Get links shared with the user",
+                "responses": {
+                    "200": {
+                        "description": "Links retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.GetLinksResponse"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -264,7 +433,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves the original URL statistics based on the provided shortened link ID.",
+                "description": "Retrieves the original URL statistics based on the provided shortened link ID.\nAvailable to the link owner and to users the link is shared with.",
                 "tags": [
                     "Shortener"
                 ],
@@ -283,6 +452,12 @@ const docTemplate = `{
                         "description": "Link stats retrieved successfully",
                         "schema": {
                             "$ref": "#/definitions/shortener.GetLinkResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Current link version"
+                            }
                         }
                     },
                     "400": {
@@ -291,6 +466,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/shortener.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "No access to the link",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Link not found",
                         "schema": {
@@ -306,18 +493,18 @@ const docTemplate = `{
                 }
             }
         },
-        "/shortener/{shortID}": {
+        "/shortener/stats/{shortID}/timeseries": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Redirects the user to the original URL from a shortened link ID.",
+                "description": "Returns click counts per hour, day or week and top referrers, browsers, OS, devices, countries,\ntarget rules (geo:\u003ccountry\u003e for geo_targets, default when nothing matched), A/B variants\nand sources (qr for QR code scans, link for everything else).\nLinks with variants also get per-variant clicks, unique visitors and share.\nBuckets are aligned in the requested timezone, weeks start on Monday.\nAvailable to the link owner and to users the link is shared with.",
                 "tags": [
-                    "Shortener"
+                    "Stats"
                 ],
-                "summary": "Redirect to the original URL",
+                "summary": "Get click time series for a link",
                 "parameters": [
                     {
                         "type": "string",
@@ -325,17 +512,60 @@ const docTemplate = `{
                         "name": "shortID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period start, RFC3339 or YYYY-MM-DD (default depends on interval)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period end, RFC3339 or YYYY-MM-DD (default now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week"
+                        ],
+                        "type": "string",
+                        "default": "day",
+                        "description": "Bucket size",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "UTC",
+                        "description": "IANA timezone",
+                        "name": "tz",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "302": {
-                        "description": "Redirected to the original URL",
+                    "200": {
+                        "description": "Time series retrieved successfully",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/shortener.SuccessResponse"
                         }
                     },
                     "400": {
-                        "description": "ShortID is empty or invalid",
+                        "description": "Invalid period, interval or timezone",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "No access to the link",
                         "schema": {
                             "$ref": "#/definitions/shortener.ErrorResponse"
                         }
@@ -353,42 +583,148 @@ const docTemplate = `{
                         }
                     }
                 }
+            }
+        },
+        "/shortener/utm-templates": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves UTM templates of the authenticated user, sorted by name.",
+                "tags": [
+                    "UTM"
+                ],
+                "summary": "Get UTM templates",
+                "responses": {
+                    "200": {
+                        "description": "Templates retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes a shortened link by its shortID",
+                "description": "Saves a named set of UTM parameters that can be applied when creating links via utm_template.",
                 "tags": [
-                    "Shortener"
+                    "UTM"
                 ],
-                "summary": "Delete a shortened link",
+                "summary": "Create a UTM template",
+                "parameters": [
+                    {
+                        "description": "Template name and UTM parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/shortener.CreateUTMTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Template created",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid name or parameters",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Template with this name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/utm-templates/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces all UTM parameters of the template. Links created from it earlier keep their parameters.",
+                "tags": [
+                    "UTM"
+                ],
+                "summary": "Replace parameters of a UTM template",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Shortened Link ID",
-                        "name": "shortID",
+                        "description": "Template name",
+                        "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New UTM parameters",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/shortener.UTMFields"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Link deleted successfully",
+                        "description": "Template updated",
                         "schema": {
                             "$ref": "#/definitions/shortener.SuccessResponse"
                         }
                     },
                     "400": {
-                        "description": "ShortID is empty or invalid",
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/shortener.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Shortened link not found",
+                        "description": "Template not found",
                         "schema": {
                             "$ref": "#/definitions/shortener.ErrorResponse"
                         }
@@ -400,12 +736,965 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "auth.ErrorResponse": {
-            "type": "object",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes the template. Links created from it keep their parameters.",
+                "tags": [
+                    "UTM"
+                ],
+                "summary": "Delete a UTM template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Template deleted",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/{shortID}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a shortened link by its shortID",
+                "tags": [
+                    "Shortener"
+                ],
+                "summary": "Delete a shortened link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ShortID is empty or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Link belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Shortened link not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the destination URL, alias or expiration of a link. Only the link owner can update it.\nThe current link version (ETag from the stats endpoint) must be sent in the If-Match header\nor in the version field, otherwise concurrent edits are rejected with 412.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shortener"
+                ],
+                "summary": "Update a shortened link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link version (ETag)",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/shortener.UpdateShortLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link updated",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.GetLinkResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New link version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL, alias or expiration",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Link belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Alias already taken",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Link was modified concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Link version is required",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/{shortID}/extend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Pushes the expiration of a link forward by duration, counting from the current expiration\nor from now if the link has already expired. The result must fit the maximum TTL of the user role.\nOnly the link owner can extend it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shortener"
+                ],
+                "summary": "Extend a shortened link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Extension, Go duration such as 168h",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ExtendLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link extended",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.GetLinkResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New link version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid duration, link never expires or maximum TTL exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Link belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Link was modified concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/{shortID}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves all changes of the link (destination, alias, expiration), newest first.\nAvailable to the link owner and to users the link is shared with.",
+                "tags": [
+                    "History"
+                ],
+                "summary": "Get link revision history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "History retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "No access to the link",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/{shortID}/history/{rev}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restores every editable field the link had after the given revision: destination, alias,\nexpiration and activation window, redirect code, UTM, password, click limit, targeting rules,\nvariants and tags. Click counters are kept. The rollback itself is recorded as a new revision.\nOnly the link owner can restore.",
+                "tags": [
+                    "History"
+                ],
+                "summary": "Roll back a link to a previous revision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision number",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link restored",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.GetLinkResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New link version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid revision number, or its expiration or activation window is no longer valid",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Link belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link or revision not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Short ID of the revision is taken or the revision has no full snapshot",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Link was modified concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/{shortID}/metadata": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Fetches the destination page again and stores its title, description, favicon URL and og:image on the link.\nMetadata is also fetched in the background after the link is created or its URL changes.\nDestinations in private networks are never requested. Only the link owner can refresh it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shortener"
+                ],
+                "summary": "Refresh destination metadata of a link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link metadata refreshed",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.GetLinkResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Link belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Link URL changed while fetching",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Destination page could not be fetched",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Metadata fetching is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/{shortID}/qr": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renders a QR code of the public short URL as PNG or SVG. The encoded URL carries the qr=1 marker,\nso scans are counted as clicks with source \"qr\" in the stats; the marker is not passed on to the destination.\nAvailable to the link owner and to users the link is shared with.",
+                "produces": [
+                    "image/png",
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "Shortener"
+                ],
+                "summary": "QR code for a shortened link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "png",
+                            "svg"
+                        ],
+                        "type": "string",
+                        "default": "png",
+                        "description": "Image format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 256,
+                        "description": "Width and height in pixels, 64-2048",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 4,
+                        "description": "Quiet zone in modules, 0-16",
+                        "name": "margin",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "L",
+                            "M",
+                            "Q",
+                            "H"
+                        ],
+                        "type": "string",
+                        "default": "M",
+                        "description": "Error correction level",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "000000",
+                        "description": "Foreground color, RRGGBB or RRGGBBAA",
+                        "name": "fg",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "ffffff",
+                        "description": "Background color, RRGGBB or RRGGBBAA",
+                        "name": "bg",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "QR code image",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format, size, margin, level or color",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "No access to the link",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/{shortID}/shares": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves users that have read-only access to the link. Only the link owner can list them.",
+                "tags": [
+                    "Sharing"
+                ],
+                "summary": "List users the link is shared with",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Shares retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Link belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Grants another user read-only access to the link statistics. Only the link owner can share.",
+                "tags": [
+                    "Sharing"
+                ],
+                "summary": "Share link stats with another user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User to share the link with",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ShareLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Link shared",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.SuccessResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Link belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Link already shared with this user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shortener/{shortID}/shares/{username}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes read-only access of the given user to the link. Only the link owner can revoke.",
+                "tags": [
+                    "Sharing"
+                ],
+                "summary": "Revoke shared access to a link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username to revoke access from",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Access revoked",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.SuccessResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Link belongs to another user",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Link or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{shortID}": {
+            "get": {
+                "description": "Public endpoint. Redirects the visitor to the original URL from a shortened link ID.\nThe status code is chosen per link (301, 302, 307 or 308, 302 by default).\nLinks with forward_query also pass the query string on; parameters already present in the original URL win.\nPassword-protected links show an HTML password form until the access cookie is set.\nLinks with max_clicks return 410 (or redirect to fallback_url) once the limit is used up.\nBefore active_from the link answers according to pending_mode: 404, a coming soon page or a redirect to pending_url.\ntarget_rules are checked first, in order: the first rule matching the visitor OS, device class, browser\nand language wins. Then geo_targets send visitors to the URL for their country (resolved by IP).\nEveryone else goes to the original URL, or to one of the weighted variants if the link has them.\nWith sticky_variants the chosen variant is kept in a per-link cookie, which the preview page also sees.\nA shortID ending with \"+\" shows a preview page with the destination, its title, the creator\nand the creation date instead of redirecting. Links with interstitial always show it;\nits Continue button adds continue=1, which is not passed on to the destination.",
+                "tags": [
+                    "Shortener"
+                ],
+                "summary": "Redirect to the original URL",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID, with a trailing + for the preview page",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "HTML preview page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirected to the original URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "ShortID is empty or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Password form for a protected link",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found or not active yet (HTML page for pending_mode coming_soon)",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Click limit reached, or an HTML page for an expired link",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Public endpoint used by the password form. On success sets a short-lived signed cookie\nscoped to the link and redirects back to it (303). Attempts are rate-limited per link and IP.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Shortener"
+                ],
+                "summary": "Unlock a password-protected link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shortened Link ID",
+                        "name": "shortID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "Redirected back to the short link",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid password, the form is shown again",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Link not found",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Link expired page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many attempts",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/shortener.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.ErrorResponse": {
+            "type": "object",
             "properties": {
                 "error": {
                     "type": "string"
@@ -424,61 +1713,312 @@ const docTemplate = `{
                 "password": {
                     "type": "string"
                 },
-                "username": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.RegisterRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.RegisterResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "auth.WhoamiResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.GeoTargets": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "models.LinkHealth": {
+            "type": "object",
+            "properties": {
+                "broken": {
+                    "description": "адрес недоступен Failures раз подряд",
+                    "type": "boolean"
+                },
+                "checked_at": {
+                    "description": "nil - адрес ещё не проверялся",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "сетевая ошибка, если ответа не было",
+                    "type": "string"
+                },
+                "failures": {
+                    "description": "неудачных проверок подряд",
+                    "type": "integer"
+                },
+                "latency_ms": {
+                    "description": "время ответа",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "HTTP-код ответа, 0 - ответа не было",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Pagination": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "description": "передать в cursor для следующей страницы",
+                    "type": "string"
+                },
+                "order": {
+                    "description": "asc или desc",
+                    "type": "string"
+                },
+                "sort": {
                     "type": "string"
                 }
             }
         },
-        "auth.LoginResponse": {
+        "models.ShortLink": {
             "type": "object",
             "properties": {
-                "message": {
+                "active_from": {
+                    "description": "до этого момента ссылка не ведёт на адрес назначения",
                     "type": "string"
                 },
-                "success": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "fallback_url": {
+                    "description": "куда вести после исчерпания MaxClicks вместо 410",
+                    "type": "string"
+                },
+                "favicon_url": {
+                    "type": "string"
+                },
+                "forward_query": {
+                    "description": "добавлять параметры запроса к адресу назначения",
                     "type": "boolean"
                 },
-                "token": {
+                "geo_targets": {
+                    "description": "страна посетителя -\u003e адрес назначения",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeoTargets"
+                        }
+                    ]
+                },
+                "health": {
+                    "description": "последняя проверка адреса назначения",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.LinkHealth"
+                        }
+                    ]
+                },
+                "id": {
                     "type": "string"
-                }
-            }
-        },
-        "auth.RegisterRequest": {
-            "type": "object",
-            "properties": {
-                "password": {
+                },
+                "interstitial": {
+                    "description": "всегда показывать предпросмотр вместо редиректа",
+                    "type": "boolean"
+                },
+                "is_alias": {
+                    "type": "boolean"
+                },
+                "last_click": {
                     "type": "string"
                 },
-                "username": {
+                "long_url": {
+                    "type": "string"
+                },
+                "max_clicks": {
+                    "description": "0 - без ограничения, 1 - одноразовая ссылка",
+                    "type": "integer"
+                },
+                "metadata_fetched_at": {
+                    "type": "string"
+                },
+                "og_image": {
+                    "type": "string"
+                },
+                "password_protected": {
+                    "type": "boolean"
+                },
+                "pending_mode": {
+                    "description": "поведение до ActiveFrom: not_found (по умолчанию), coming_soon, redirect",
+                    "type": "string"
+                },
+                "pending_url": {
+                    "description": "для pending_mode = redirect",
+                    "type": "string"
+                },
+                "redirect_code": {
+                    "description": "301, 302, 307 или 308",
+                    "type": "integer"
+                },
+                "short_id": {
+                    "type": "string"
+                },
+                "sticky_variants": {
+                    "description": "закреплять вариант за посетителем cookie",
+                    "type": "boolean"
+                },
+                "tags": {
+                    "description": "метки для группировки и фильтрации списка",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "target_rules": {
+                    "description": "правила по устройству и языку, проверяются до GeoTargets",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TargetRule"
+                    }
+                },
+                "title": {
+                    "description": "Метаданные страницы назначения, загружаются в фоне после создания ссылки и смены адреса",
+                    "type": "string"
+                },
+                "used_clicks": {
+                    "description": "переходы, засчитанные в MaxClicks; растёт синхронно, в отличие от Clicks",
+                    "type": "integer"
+                },
+                "user_id": {
                     "type": "string"
+                },
+                "utm": {
+                    "description": "метки кампании, добавляются при редиректе",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UTMParams"
+                        }
+                    ]
+                },
+                "variants": {
+                    "description": "A/B-тест: взвешенные адреса вместо LongLink",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Variant"
+                    }
+                },
+                "version": {
+                    "description": "для оптимистичной блокировки",
+                    "type": "integer"
                 }
             }
         },
-        "auth.RegisterResponse": {
+        "models.TargetRule": {
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string"
+                "browser": {
+                    "description": "Chrome, Safari, Firefox, Edge...",
+                    "type": "string",
+                    "example": "Safari"
                 },
-                "success": {
-                    "type": "boolean"
+                "device": {
+                    "description": "desktop, mobile, tablet или bot",
+                    "type": "string",
+                    "example": "mobile"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "language": {
+                    "description": "\"en\" подходит и для en-US",
+                    "type": "string",
+                    "example": "en"
+                },
+                "name": {
+                    "description": "попадает в статистику кликов, по умолчанию rule-N",
+                    "type": "string",
+                    "example": "ios"
+                },
+                "os": {
+                    "description": "iOS, Android, Windows, macOS, Linux, ChromeOS...",
+                    "type": "string",
+                    "example": "iOS"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://apps.apple.com/app/id123"
                 }
             }
         },
-        "auth.WhoamiResponse": {
+        "models.UTMParams": {
             "type": "object",
             "properties": {
-                "message": {
+                "utm_campaign": {
                     "type": "string"
                 },
-                "success": {
-                    "type": "boolean"
+                "utm_content": {
+                    "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.User"
+                "utm_medium": {
+                    "type": "string"
+                },
+                "utm_source": {
+                    "type": "string"
+                },
+                "utm_term": {
+                    "type": "string"
                 }
             }
         },
@@ -488,19 +2028,165 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "role": {
+                    "type": "string"
+                },
                 "username": {
                     "type": "string"
                 }
             }
         },
+        "models.Variant": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "попадает в статистику кликов, по умолчанию A, B, C...",
+                    "type": "string",
+                    "example": "A"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/landing-a"
+                },
+                "weight": {
+                    "description": "доля трафика относительно суммы весов, по умолчанию 1",
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
         "shortener.CreateShortLinkRequest": {
             "type": "object",
             "required": [
                 "url"
             ],
             "properties": {
+                "active_from": {
+                    "description": "ссылка начнёт работать с этого момента",
+                    "type": "string"
+                },
+                "alias": {
+                    "description": "3-16 символов: a-z, 0-9, \"-\", \"_\"",
+                    "type": "string",
+                    "example": "spring-sale"
+                },
+                "expires_at": {
+                    "description": "по умолчанию LINK_DEFAULT_TTL",
+                    "type": "string"
+                },
+                "fallback_url": {
+                    "description": "куда вести после исчерпания лимита вместо 410",
+                    "type": "string"
+                },
+                "forward_query": {
+                    "description": "добавлять параметры запроса к адресу назначения",
+                    "type": "boolean"
+                },
+                "geo_targets": {
+                    "description": "код страны ISO 3166-1 -\u003e адрес, остальным - url",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "interstitial": {
+                    "description": "показывать страницу предпросмотра вместо редиректа",
+                    "type": "boolean"
+                },
+                "max_clicks": {
+                    "description": "лимит переходов, 0 - без ограничения",
+                    "type": "integer",
+                    "example": 100
+                },
+                "never_expires": {
+                    "description": "бессрочная ссылка, если роль это разрешает",
+                    "type": "boolean"
+                },
+                "one_time": {
+                    "description": "одноразовая ссылка, то же что max_clicks = 1",
+                    "type": "boolean"
+                },
+                "password": {
+                    "description": "пароль на переход, 4-72 символа",
+                    "type": "string"
+                },
+                "pending_mode": {
+                    "description": "поведение до active_from",
+                    "type": "string",
+                    "enum": [
+                        "not_found",
+                        "coming_soon",
+                        "redirect"
+                    ]
+                },
+                "pending_url": {
+                    "description": "для pending_mode = redirect",
+                    "type": "string"
+                },
+                "redirect_code": {
+                    "description": "по умолчанию 302",
+                    "type": "integer",
+                    "enum": [
+                        301,
+                        302,
+                        307,
+                        308
+                    ]
+                },
+                "sticky_variants": {
+                    "description": "закреплять вариант за посетителем cookie",
+                    "type": "boolean"
+                },
+                "tags": {
+                    "description": "метки для фильтрации списка ссылок",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "promo",
+                        "spring"
+                    ]
+                },
+                "target_rules": {
+                    "description": "правила по устройству и языку, проверяются до geo_targets",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TargetRule"
+                    }
+                },
                 "url": {
                     "type": "string"
+                },
+                "utm_campaign": {
+                    "type": "string",
+                    "example": "spring_sale"
+                },
+                "utm_content": {
+                    "type": "string"
+                },
+                "utm_medium": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "utm_source": {
+                    "type": "string",
+                    "example": "newsletter"
+                },
+                "utm_template": {
+                    "description": "имя шаблона меток, явные метки важнее",
+                    "type": "string",
+                    "example": "newsletter"
+                },
+                "utm_term": {
+                    "type": "string"
+                },
+                "variants": {
+                    "description": "A/B-тест: взвешенные адреса вместо url",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Variant"
+                    }
                 }
             }
         },
@@ -518,6 +2204,36 @@ const docTemplate = `{
                 }
             }
         },
+        "shortener.CreateUTMTemplateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "newsletter"
+                },
+                "utm_campaign": {
+                    "type": "string",
+                    "example": "spring_sale"
+                },
+                "utm_content": {
+                    "type": "string"
+                },
+                "utm_medium": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "utm_source": {
+                    "type": "string",
+                    "example": "newsletter"
+                },
+                "utm_term": {
+                    "type": "string"
+                }
+            }
+        },
         "shortener.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -532,6 +2248,16 @@ const docTemplate = `{
                 }
             }
         },
+        "shortener.ExtendLinkRequest": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "на сколько продлить, по умолчанию LINK_DEFAULT_TTL",
+                    "type": "string",
+                    "example": "720h"
+                }
+            }
+        },
         "shortener.GetLinkResponse": {
             "type": "object",
             "properties": {
@@ -552,17 +2278,31 @@ const docTemplate = `{
                 "links": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.ShortLink"
                     }
                 },
                 "message": {
                     "type": "string"
                 },
+                "pagination": {
+                    "$ref": "#/definitions/models.Pagination"
+                },
                 "success": {
                     "type": "boolean"
                 }
             }
         },
+        "shortener.ShareLinkRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "shortener.SuccessResponse": {
             "type": "object",
             "properties": {
@@ -573,6 +2313,143 @@ const docTemplate = `{
                     "type": "boolean"
                 }
             }
+        },
+        "shortener.UTMFields": {
+            "type": "object",
+            "properties": {
+                "utm_campaign": {
+                    "type": "string",
+                    "example": "spring_sale"
+                },
+                "utm_content": {
+                    "type": "string"
+                },
+                "utm_medium": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "utm_source": {
+                    "type": "string",
+                    "example": "newsletter"
+                },
+                "utm_term": {
+                    "type": "string"
+                }
+            }
+        },
+        "shortener.UpdateShortLinkRequest": {
+            "type": "object",
+            "properties": {
+                "activate_now": {
+                    "description": "снять окно активации",
+                    "type": "boolean"
+                },
+                "active_from": {
+                    "type": "string"
+                },
+                "alias": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "fallback_url": {
+                    "description": "пустая строка - 410 после исчерпания лимита",
+                    "type": "string"
+                },
+                "forward_query": {
+                    "type": "boolean"
+                },
+                "geo_targets": {
+                    "description": "заменяет правила целиком, {} удаляет их",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "interstitial": {
+                    "type": "boolean"
+                },
+                "max_clicks": {
+                    "description": "0 снимает лимит",
+                    "type": "integer"
+                },
+                "never_expires": {
+                    "description": "снять срок действия",
+                    "type": "boolean"
+                },
+                "password": {
+                    "description": "пустая строка снимает пароль",
+                    "type": "string"
+                },
+                "pending_mode": {
+                    "type": "string",
+                    "enum": [
+                        "not_found",
+                        "coming_soon",
+                        "redirect"
+                    ]
+                },
+                "pending_url": {
+                    "type": "string"
+                },
+                "redirect_code": {
+                    "type": "integer",
+                    "enum": [
+                        301,
+                        302,
+                        307,
+                        308
+                    ]
+                },
+                "sticky_variants": {
+                    "type": "boolean"
+                },
+                "tags": {
+                    "description": "заменяет метки целиком, [] удаляет их",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "target_rules": {
+                    "description": "заменяет правила целиком, [] удаляет их",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TargetRule"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                },
+                "utm_campaign": {
+                    "type": "string"
+                },
+                "utm_content": {
+                    "type": "string"
+                },
+                "utm_medium": {
+                    "type": "string"
+                },
+                "utm_source": {
+                    "description": "пустая строка удаляет метку",
+                    "type": "string"
+                },
+                "utm_term": {
+                    "type": "string"
+                },
+                "variants": {
+                    "description": "заменяет варианты целиком, [] удаляет их",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Variant"
+                    }
+                },
+                "version": {
+                    "description": "альтернатива заголовку If-Match",
+                    "type": "integer"
+                }
+            }
         }
     },
     "securityDefinitions": {
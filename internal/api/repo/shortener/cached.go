@@ -0,0 +1,206 @@
+package shortener
+
+import (
+	"bytes"
+	"encoding/gob"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/cache"
+	"github.com/bigxxby/dream-test-task/internal/metrics"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+)
+
+// Ключи кэша. Найденная ссылка хранится под своим точным идентификатором,
+// отсутствие - под идентификатором в нижнем регистре, так как занятость
+// проверяется без учёта регистра.
+const (
+	linkKeyPrefix    = "link:"
+	missingKeyPrefix = "missing:"
+)
+
+// CachedShortenerRepo кэширует разрешение коротких идентификаторов для редиректа
+// и сбрасывает кэш при изменении и удалении ссылок. Остальные методы идут в базу.
+// Чтение, пересёкшееся с изменением, может вернуть в кэш старую запись - её срок ограничен TTL.
+type CachedShortenerRepo struct {
+	IShortenerRepo
+	Cache       cache.Cache
+	TTL         time.Duration
+	NegativeTTL time.Duration
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+}
+
+// NewCachedShortenerRepo оборачивает репозиторий кэшем
+func NewCachedShortenerRepo(repo IShortenerRepo, c cache.Cache, ttl, negativeTTL time.Duration) *CachedShortenerRepo {
+	return &CachedShortenerRepo{IShortenerRepo: repo, Cache: c, TTL: ttl, NegativeTTL: negativeTTL}
+}
+
+func (cr *CachedShortenerRepo) ResolveShortLink(shortID string) (*models.ShortLink, error) {
+	if link, found, ok := cr.lookup(shortID); ok {
+		cr.hits.Add(1)
+		if !found {
+			return nil, nil
+		}
+		return link, nil
+	}
+	cr.misses.Add(1)
+
+	link, err := cr.IShortenerRepo.ResolveShortLink(shortID)
+	if err != nil {
+		return nil, err
+	}
+
+	if link == nil {
+		// Кэшируем отсутствие, только если нет ссылки ни в каком регистре,
+		// иначе запись помешала бы найти её по другому написанию
+		taken, err := cr.IShortenerRepo.IsShortIDTaken(shortID)
+		if err == nil && !taken {
+			cr.set(missingKey(shortID), nil, cr.NegativeTTL)
+		}
+		return nil, nil
+	}
+	// Алиас, запрошенный в другом регистре, не кэшируем: сбросить такой ключ при изменении нельзя
+	if link.ShortId == shortID {
+		cr.set(linkKey(shortID), link, cr.TTL)
+	}
+	return link, nil
+}
+
+func (cr *CachedShortenerRepo) CreateShortLink(link *models.ShortLink) error {
+	if err := cr.IShortenerRepo.CreateShortLink(link); err != nil {
+		return err
+	}
+	cr.invalidate(missingKey(link.ShortId))
+	return nil
+}
+
+func (cr *CachedShortenerRepo) UpdateShortLink(link *models.ShortLink) error {
+	if err := cr.IShortenerRepo.UpdateShortLink(link); err != nil {
+		return err
+	}
+	cr.invalidate(linkKey(link.ShortId))
+	return nil
+}
+
+func (cr *CachedShortenerRepo) UpdateShortLinkVersioned(link *models.ShortLink, expectedVersion int, revision *models.LinkRevision) (bool, error) {
+	updated, err := cr.IShortenerRepo.UpdateShortLinkVersioned(link, expectedVersion, revision)
+	if err != nil || !updated {
+		return updated, err
+	}
+	keys := []string{linkKey(link.ShortId), missingKey(link.ShortId)}
+	// ревизия необязательна, как и в ShortenerRepo
+	if revision != nil && revision.OldShortId != "" && revision.OldShortId != link.ShortId {
+		keys = append(keys, linkKey(revision.OldShortId))
+	}
+	cr.invalidate(keys...)
+	return true, nil
+}
+
+func (cr *CachedShortenerRepo) UpdateLinkMetadata(link *models.ShortLink) (bool, error) {
+	updated, err := cr.IShortenerRepo.UpdateLinkMetadata(link)
+	if err != nil || !updated {
+		return updated, err
+	}
+	cr.invalidate(linkKey(link.ShortId))
+	return true, nil
+}
+
+func (cr *CachedShortenerRepo) DeleteLink(shortID string, userId *uuid.UUID) error {
+	if err := cr.IShortenerRepo.DeleteLink(shortID, userId); err != nil {
+		return err
+	}
+	cr.invalidate(linkKey(shortID))
+	return nil
+}
+
+// lookup возвращает ok = false, если в кэше ничего нет или кэш недоступен
+func (cr *CachedShortenerRepo) lookup(shortID string) (link *models.ShortLink, found bool, ok bool) {
+	data, hit, err := cr.Cache.Get(linkKey(shortID))
+	if err != nil {
+		cr.errors.Add(1)
+		return nil, false, false
+	}
+	if hit {
+		link = &models.ShortLink{}
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(link); err != nil {
+			log.Println("failed to decode cached link:", err)
+			return nil, false, false
+		}
+		return link, true, true
+	}
+
+	_, hit, err = cr.Cache.Get(missingKey(shortID))
+	if err != nil {
+		cr.errors.Add(1)
+		return nil, false, false
+	}
+	return nil, false, hit
+}
+
+func (cr *CachedShortenerRepo) set(key string, link *models.ShortLink, ttl time.Duration) {
+	var data []byte
+	if link != nil {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(link); err != nil {
+			log.Println("failed to encode link for cache:", err)
+			return
+		}
+		data = buf.Bytes()
+	}
+	if err := cr.Cache.Set(key, data, ttl); err != nil {
+		cr.errors.Add(1)
+	}
+}
+
+// invalidate сбрасывает ключи. Если кэш недоступен, устаревшая запись живёт до истечения TTL.
+func (cr *CachedShortenerRepo) invalidate(keys ...string) {
+	if err := cr.Cache.Delete(keys...); err != nil {
+		cr.errors.Add(1)
+		log.Println("failed to invalidate cached links:", err)
+	}
+}
+
+// CacheStats - счётчики обращений к кэшу с момента запуска
+type CacheStats struct {
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	Errors   uint64  `json:"errors"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+func (cr *CachedShortenerRepo) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:   cr.hits.Load(),
+		Misses: cr.misses.Load(),
+		Errors: cr.errors.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// Metrics реализует metrics.Source
+func (cr *CachedShortenerRepo) Metrics() []metrics.Metric {
+	stats := cr.Stats()
+	return []metrics.Metric{
+		{Name: "link_cache_hits_total", Help: "Short id resolutions served from the cache.", Type: metrics.Counter, Value: float64(stats.Hits)},
+		{Name: "link_cache_misses_total", Help: "Short id resolutions that went to the database.", Type: metrics.Counter, Value: float64(stats.Misses)},
+		{Name: "link_cache_errors_total", Help: "Failed cache operations.", Type: metrics.Counter, Value: float64(stats.Errors)},
+		{Name: "link_cache_hit_ratio", Help: "Share of resolutions served from the cache.", Type: metrics.Gauge, Value: stats.HitRatio},
+	}
+}
+
+func linkKey(shortID string) string {
+	return linkKeyPrefix + shortID
+}
+
+func missingKey(shortID string) string {
+	return missingKeyPrefix + strings.ToLower(shortID)
+}
@@ -0,0 +1,159 @@
+package shortener_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/api/repo/shortener"
+	"github.com/bigxxby/dream-test-task/internal/cache"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryRepo хранит ссылки в map и считает обращения к "базе"
+type memoryRepo struct {
+	shortener.IShortenerRepo
+	links   map[string]*models.ShortLink
+	queries int
+}
+
+func (r *memoryRepo) ResolveShortLink(shortID string) (*models.ShortLink, error) {
+	r.queries++
+	link, ok := r.links[shortID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *link
+	return &copied, nil
+}
+
+func (r *memoryRepo) IsShortIDTaken(shortID string) (bool, error) {
+	for id := range r.links {
+		if strings.EqualFold(id, shortID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryRepo) CreateShortLink(link *models.ShortLink) error {
+	r.links[link.ShortId] = link
+	return nil
+}
+
+func (r *memoryRepo) UpdateShortLinkVersioned(link *models.ShortLink, expectedVersion int, revision *models.LinkRevision) (bool, error) {
+	if revision != nil {
+		delete(r.links, revision.OldShortId)
+	}
+	r.links[link.ShortId] = link
+	return true, nil
+}
+
+func (r *memoryRepo) DeleteLink(shortID string, userId *uuid.UUID) error {
+	delete(r.links, shortID)
+	return nil
+}
+
+func newCachedRepo() (*shortener.CachedShortenerRepo, *memoryRepo) {
+	id := uuid.New()
+	repo := &memoryRepo{links: map[string]*models.ShortLink{
+		"abc123": {ID: &id, ShortId: "abc123", LongLink: "https://example.com", Version: 1},
+	}}
+	return shortener.NewCachedShortenerRepo(repo, cache.NewLRU(100), time.Minute, time.Minute), repo
+}
+
+func TestCachedRepoServesHotLinksFromCache(t *testing.T) {
+	cached, repo := newCachedRepo()
+
+	for i := 0; i < 3; i++ {
+		link, err := cached.ResolveShortLink("abc123")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com", link.LongLink)
+	}
+	assert.Equal(t, 1, repo.queries)
+
+	stats := cached.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+}
+
+func TestCachedRepoNegativeCaching(t *testing.T) {
+	cached, repo := newCachedRepo()
+
+	link, _ := cached.ResolveShortLink("nope")
+	assert.Nil(t, link)
+	link, _ = cached.ResolveShortLink("nope")
+	assert.Nil(t, link)
+	assert.Equal(t, 1, repo.queries)
+
+	// создание ссылки сбрасывает запись об отсутствии
+	assert.NoError(t, cached.CreateShortLink(&models.ShortLink{ShortId: "nope", LongLink: "https://new.example.com"}))
+	link, _ = cached.ResolveShortLink("nope")
+	assert.NotNil(t, link)
+
+	// другой регистр существующего идентификатора не кэшируется как отсутствие
+	cached.ResolveShortLink("ABC123")
+	cached.ResolveShortLink("ABC123")
+	assert.Equal(t, 4, repo.queries)
+}
+
+func TestCachedRepoInvalidatesOnUpdateAndDelete(t *testing.T) {
+	cached, _ := newCachedRepo()
+	link, _ := cached.ResolveShortLink("abc123")
+
+	before := *link
+	link.ShortId = "renamed"
+	link.LongLink = "https://changed.example.com"
+	_, err := cached.UpdateShortLinkVersioned(link, 1, models.NewLinkRevision(&before, link, nil))
+	assert.NoError(t, err)
+
+	old, _ := cached.ResolveShortLink("abc123")
+	assert.Nil(t, old)
+	renamed, _ := cached.ResolveShortLink("renamed")
+	assert.Equal(t, "https://changed.example.com", renamed.LongLink)
+
+	assert.NoError(t, cached.DeleteLink("renamed", nil))
+	deleted, _ := cached.ResolveShortLink("renamed")
+	assert.Nil(t, deleted)
+}
+
+func TestCachedRepoUpdateWithoutRevision(t *testing.T) {
+	cached, _ := newCachedRepo()
+	link, _ := cached.ResolveShortLink("abc123")
+
+	link.LongLink = "https://changed.example.com"
+	updated, err := cached.UpdateShortLinkVersioned(link, 1, nil)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+
+	cachedLink, _ := cached.ResolveShortLink("abc123")
+	assert.Equal(t, "https://changed.example.com", cachedLink.LongLink)
+}
+
+// brokenCache имитирует недоступное хранилище
+type brokenCache struct{}
+
+func (brokenCache) Get(key string) ([]byte, bool, error) {
+	return nil, false, cache.ErrUnavailable
+}
+
+func (brokenCache) Set(key string, value []byte, ttl time.Duration) error {
+	return cache.ErrUnavailable
+}
+
+func (brokenCache) Delete(keys ...string) error {
+	return cache.ErrUnavailable
+}
+
+func TestCachedRepoFallsBackToDatabase(t *testing.T) {
+	_, repo := newCachedRepo()
+	cached := shortener.NewCachedShortenerRepo(repo, brokenCache{}, time.Minute, time.Minute)
+
+	link, err := cached.ResolveShortLink("abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", link.LongLink)
+	assert.NoError(t, cached.DeleteLink("abc123", nil))
+	assert.NotZero(t, cached.Stats().Errors)
+}
@@ -1,6 +1,13 @@
 package shortener
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/bigxxby/dream-test-task/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -9,12 +16,45 @@ import (
 type IShortenerRepo interface {
 	CreateShortLink(link *models.ShortLink) error
 	UpdateShortLink(link *models.ShortLink) error
+	UpdateShortLinkVersioned(link *models.ShortLink, expectedVersion int, revision *models.LinkRevision) (bool, error) // false, если версия устарела
 	GetShortLinkByShortID(shortID string) (*models.ShortLink, error)
-	GetLinkStat(shortID string) (int, error) // Возвращает количество кликов для короткой ссылки
-	DeleteLink(shortID string) error         // Удаляет короткую ссылку
-	GetLinks(userId *uuid.UUID) ([]models.ShortLink, error)
+	ResolveShortLink(shortID string) (*models.ShortLink, error)                     // То же для публичного редиректа; результат может быть закэширован
+	IsShortIDTaken(shortID string) (bool, error)                                    // Проверяет занятость идентификатора без учёта регистра
+	NextSequence() (int64, error)                                                   // Следующее значение последовательности для генерации идентификаторов
+	GetLinkStat(shortID string) (int, error)                                        // Возвращает количество кликов для короткой ссылки
+	UpdateLinkMetadata(link *models.ShortLink) (bool, error)                        // Сохраняет метаданные страницы; false, если адрес ссылки уже сменился
+	DeleteLink(shortID string, userId *uuid.UUID) error                             // Удаляет короткую ссылку владельца
+	GetLinks(userId *uuid.UUID, query models.LinkQuery) ([]models.ShortLink, error) // Страница ссылок пользователя с фильтрами и курсором
+	GetSharedLinks(userId *uuid.UUID) ([]models.ShortLink, error)                   // Ссылки, которыми поделились с пользователем
+	GetBrokenLinks(userId *uuid.UUID) ([]models.ShortLink, error)                   // Ссылки пользователя с нерабочим адресом назначения
+
+	GetLinksToCheck(checkedBefore, now time.Time, limit int) ([]models.ShortLink, error) // Действующие ссылки, которые давно не проверялись
+	UpdateLinkHealth(link *models.ShortLink) error                                       // Сохраняет результат проверки адреса назначения
+
+	CreateLinkShare(share *models.LinkShare) error
+	GetLinkShare(linkId, userId *uuid.UUID) (*models.LinkShare, error)
+	GetLinkShares(linkId *uuid.UUID) ([]models.LinkShare, error) // Вместе с именами пользователей
+	DeleteLinkShare(linkId, userId *uuid.UUID) error
+
+	GetLinkRevisions(linkId *uuid.UUID) ([]models.LinkRevision, error)
+	GetLinkRevision(linkId *uuid.UUID, revision int) (*models.LinkRevision, error)
+
+	SaveClickBatch(events []models.ClickEvent) error // Увеличивает счётчики ссылок и сохраняет события одной транзакцией
+	ConsumeClick(linkId *uuid.UUID) (bool, error)    // Засчитывает переход в лимит max_clicks; false, если лимит исчерпан
+	GetClickTimeSeries(linkId *uuid.UUID, from, to time.Time, interval, timezone string) ([]models.ClickBucket, error)
+	GetClickBreakdown(linkId *uuid.UUID, from, to time.Time, column string, limit int) ([]models.ClickBreakdownItem, error)
+	GetVariantClicks(linkId *uuid.UUID, from, to time.Time) ([]models.VariantClicks, error)
+
+	CreateUTMTemplate(template *models.UTMTemplate) error
+	UpdateUTMTemplate(template *models.UTMTemplate) error
+	GetUTMTemplate(userId *uuid.UUID, name string) (*models.UTMTemplate, error)
+	GetUTMTemplates(userId *uuid.UUID) ([]models.UTMTemplate, error)
+	DeleteUTMTemplate(id *uuid.UUID) error
 }
 
+// ErrShortIDTaken - идентификатор заняли между проверкой IsShortIDTaken и записью
+var ErrShortIDTaken = errors.New("short id is already taken")
+
 type ShortenerRepo struct {
 	Db *gorm.DB
 }
@@ -24,14 +64,93 @@ func NewShortenerRepo(db *gorm.DB) IShortenerRepo {
 	return &ShortenerRepo{Db: db}
 }
 
-func (sr *ShortenerRepo) GetLinks(userId *uuid.UUID) ([]models.ShortLink, error) {
+// GetLinks возвращает страницу ссылок пользователя: до query.Limit+1 ссылок после курсора,
+// по которым сервис определяет, есть ли следующая страница
+func (sr *ShortenerRepo) GetLinks(userId *uuid.UUID, query models.LinkQuery) ([]models.ShortLink, error) {
+	db := sr.Db.Where("user_id = ?", userId)
+
+	// Условия повторяют ShortLink.Status: состояния не пересекаются и вместе покрывают все ссылки
+	switch query.Status {
+	case models.LinkStatusExpired:
+		db = db.Where("expires_at <= ?", query.Now)
+	case models.LinkStatusScheduled:
+		db = db.Where("expires_at IS NULL OR expires_at > ?", query.Now).
+			Where("active_from > ?", query.Now)
+	case models.LinkStatusExhausted:
+		db = db.Where("expires_at IS NULL OR expires_at > ?", query.Now).
+			Where("active_from IS NULL OR active_from <= ?", query.Now).
+			Where("max_clicks > 0 AND used_clicks >= max_clicks")
+	case models.LinkStatusActive:
+		db = db.Where("expires_at IS NULL OR expires_at > ?", query.Now).
+			Where("active_from IS NULL OR active_from <= ?", query.Now).
+			Where("max_clicks = 0 OR used_clicks < max_clicks")
+	}
+	if query.Tag != "" {
+		tag, err := json.Marshal([]string{query.Tag})
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("tags @> CAST(? AS jsonb)", string(tag))
+	}
+	if query.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		db = db.Where("created_at < ?", *query.CreatedTo)
+	}
+	if query.Domain != "" {
+		// Хост адреса назначения без схемы, userinfo и порта; поддомены тоже подходят
+		db = db.Where("lower(substring(long_link from ?)) = ? OR lower(substring(long_link from ?)) LIKE ?",
+			hostPattern, query.Domain, hostPattern, "%."+query.Domain)
+	}
+	if len(query.Search) > 0 {
+		// Слова состоят только из букв и цифр, поэтому их можно подставить в tsquery как есть
+		terms := make([]string, len(query.Search))
+		for i, word := range query.Search {
+			terms[i] = word + ":*"
+		}
+		db = db.Where(models.LinkSearchVector+" @@ to_tsquery('simple', ?)", strings.Join(terms, " & "))
+	}
+
+	column := linkSortColumn(query.Sort)
+	direction, compare := "ASC", ">"
+	if query.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if query.After != nil {
+		var value any = query.After.Clicks
+		if query.Sort != models.LinkSortClicks {
+			value = *query.After.Time
+		}
+		db = db.Where(column+" "+compare+" ? OR ("+column+" = ? AND id "+compare+" ?)", value, value, query.After.ID)
+	}
+
 	var links []models.ShortLink
-	err := sr.Db.Where("user_id = ?", userId).Find(&links).Error
+	err := db.Order(column + " " + direction).
+		Order("id " + direction).
+		Limit(query.Limit + 1).
+		Find(&links).Error
 	if err != nil {
 		return nil, err
 	}
 	return links, nil
 }
+
+// hostPattern выделяет хост из адреса вида scheme://user@host:port/path
+const hostPattern = `^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/?#]*@)?([^/:?#]+)`
+
+// linkSortColumn - выражение для сортировки; ссылки без переходов считаются кликнутыми
+// в models.NeverClicked, чтобы курсор мог на них указывать
+func linkSortColumn(sort string) string {
+	switch sort {
+	case models.LinkSortClicks:
+		return "clicks"
+	case models.LinkSortLastClick:
+		return "COALESCE(last_click, '1970-01-01 00:00:00+00')"
+	default:
+		return "created_at"
+	}
+}
 func (sr *ShortenerRepo) UpdateShortLink(link *models.ShortLink) error {
 	result := sr.Db.Save(link)
 	if result.Error != nil {
@@ -40,18 +159,132 @@ func (sr *ShortenerRepo) UpdateShortLink(link *models.ShortLink) error {
 	return result.Error
 }
 
-func (sr *ShortenerRepo) CreateShortLink(link *models.ShortLink) error {
-	result := sr.Db.Create(link)
+// UpdateShortLinkVersioned сохраняет изменяемые поля ссылки, только если её версия
+// в базе всё ещё равна expectedVersion. При успехе версия увеличивается,
+// а revision (если передана) записывается в историю в той же транзакции.
+// Метаданные страницы и результат проверки адреса не перезаписываются: их обновляют
+// UpdateLinkMetadata и UpdateLinkHealth.
+func (sr *ShortenerRepo) UpdateShortLinkVersioned(link *models.ShortLink, expectedVersion int, revision *models.LinkRevision) (bool, error) {
+	link.Version = expectedVersion + 1
+	updated := false
+	err := sr.Db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(link).
+			Where("version = ?", expectedVersion).
+			Select("*").
+			Omit(slices.Concat([]string{"id", "user_id", "clicks", "last_click", "used_clicks", "created_at"}, models.LinkMetadataColumns, models.LinkHealthColumns)...).
+			Updates(link)
+		if result.Error != nil {
+			return shortIDError(result.Error)
+		}
+		if result.RowsAffected != 1 {
+			return nil
+		}
+		updated = true
+
+		if revision == nil {
+			return nil
+		}
+		revision.Revision = link.Version
+		return tx.Create(revision).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+// GetBrokenLinks возвращает ссылки пользователя, адрес назначения которых перестал отвечать
+func (sr *ShortenerRepo) GetBrokenLinks(userId *uuid.UUID) ([]models.ShortLink, error) {
+	var links []models.ShortLink
+	err := sr.Db.Where("user_id = ? AND health_broken = ?", userId, true).
+		Order("health_checked_at DESC").
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GetLinksToCheck выбирает действующие ссылки (не истёкшие и уже запущенные), которые не проверялись
+// с checkedBefore. Непроверенные идут первыми, затем самые давние.
+func (sr *ShortenerRepo) GetLinksToCheck(checkedBefore, now time.Time, limit int) ([]models.ShortLink, error) {
+	var links []models.ShortLink
+	err := sr.Db.
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("active_from IS NULL OR active_from <= ?", now).
+		Where("health_checked_at IS NULL OR health_checked_at < ?", checkedBefore).
+		Order("health_checked_at ASC NULLS FIRST").
+		Limit(limit).
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// UpdateLinkHealth записывает только результат проверки, не меняя версию ссылки
+func (sr *ShortenerRepo) UpdateLinkHealth(link *models.ShortLink) error {
+	return sr.Db.Model(&models.ShortLink{}).
+		Where("id = ?", link.ID).
+		UpdateColumns(map[string]interface{}{
+			"health_status":     link.Health.Status,
+			"health_latency_ms": link.Health.LatencyMs,
+			"health_error":      link.Health.Error,
+			"health_checked_at": link.Health.CheckedAt,
+			"health_failures":   link.Health.Failures,
+			"health_broken":     link.Health.Broken,
+		}).Error
+}
+
+// UpdateLinkMetadata записывает только метаданные страницы назначения, не меняя версию ссылки.
+// Если адрес ссылки изменился, пока страница загружалась, метаданные устарели и не сохраняются.
+func (sr *ShortenerRepo) UpdateLinkMetadata(link *models.ShortLink) (bool, error) {
+	result := sr.Db.Model(&models.ShortLink{}).
+		Where("id = ? AND long_link = ?", link.ID, link.LongLink).
+		UpdateColumns(map[string]interface{}{
+			"title":               link.Title,
+			"description":         link.Description,
+			"favicon_url":         link.FaviconURL,
+			"og_image":            link.OGImage,
+			"metadata_fetched_at": link.MetadataFetchedAt,
+		})
 	if result.Error != nil {
-		return result.Error
+		return false, result.Error
 	}
-	return result.Error
+	return result.RowsAffected == 1, nil
+}
+
+// CreateShortLink создаёт ссылку и первую ревизию её истории.
+func (sr *ShortenerRepo) CreateShortLink(link *models.ShortLink) error {
+	return sr.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(link).Error; err != nil {
+			return shortIDError(err)
+		}
+		return tx.Create(models.NewLinkRevision(nil, link, link.UserID)).Error
+	})
+}
+
+// shortIDError заменяет нарушение уникального индекса short_id на ErrShortIDTaken
+func shortIDError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrShortIDTaken
+	}
+	return err
+}
+
+// ResolveShortLink ищет ссылку для редиректа. Без кэша совпадает с GetShortLinkByShortID.
+func (sr *ShortenerRepo) ResolveShortLink(shortID string) (*models.ShortLink, error) {
+	return sr.GetShortLinkByShortID(shortID)
 }
 
 // GetShortLinkByShortID находит короткую ссылку по короткому идентификатору.
+// Сгенерированные идентификаторы ищутся с учётом регистра, алиасы - без.
 func (sr *ShortenerRepo) GetShortLinkByShortID(shortID string) (*models.ShortLink, error) {
 	var link models.ShortLink
 	err := sr.Db.Where("short_id = ?", shortID).First(&link).Error
+	if err == gorm.ErrRecordNotFound {
+		err = sr.Db.Where("is_alias = ? AND short_id = ?", true, models.NormalizeAlias(shortID)).First(&link).Error
+	}
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil // Если запись не найдена, возвращаем nil
@@ -61,6 +294,26 @@ func (sr *ShortenerRepo) GetShortLinkByShortID(shortID string) (*models.ShortLin
 	return &link, nil
 }
 
+// IsShortIDTaken проверяет, занят ли идентификатор любой ссылкой без учёта регистра.
+func (sr *ShortenerRepo) IsShortIDTaken(shortID string) (bool, error) {
+	var count int64
+	err := sr.Db.Model(&models.ShortLink{}).Where("LOWER(short_id) = LOWER(?)", shortID).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// NextSequence возвращает следующее значение последовательности short_link_id_seq.
+func (sr *ShortenerRepo) NextSequence() (int64, error) {
+	var n int64
+	err := sr.Db.Raw("SELECT nextval('short_link_id_seq')").Scan(&n).Error
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
 // GetLinkStat возвращает количество кликов по короткой ссылке.
 func (sr *ShortenerRepo) GetLinkStat(shortID string) (int, error) {
 	var link models.ShortLink
@@ -75,16 +328,266 @@ func (sr *ShortenerRepo) GetLinkStat(shortID string) (int, error) {
 }
 
 // DeleteLink удаляет короткую ссылку по её короткому идентификатору.
-func (sr *ShortenerRepo) DeleteLink(shortID string) error {
+// Удаляется только ссылка, принадлежащая пользователю userId.
+func (sr *ShortenerRepo) DeleteLink(shortID string, userId *uuid.UUID) error {
 	var link models.ShortLink
-	// Проверяем, существует ли такая ссылка
-	err := sr.Db.Where("short_id = ?", shortID).First(&link).Error
+	// Проверяем, существует ли такая ссылка у этого пользователя
+	err := sr.Db.Where("short_id = ? AND user_id = ?", shortID, userId).First(&link).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil // Если запись не найдена, ничего не делаем
 		}
 		return err // Возвращаем ошибку для других случаев
 	}
-	// Удаляем ссылку
-	return sr.Db.Delete(&link).Error
+	// Удаляем ссылку вместе с доступами к ней и историей
+	return sr.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.LinkShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.LinkRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("link_id = ?", link.ID).Delete(&models.ClickEvent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&link).Error
+	})
+}
+
+// GetSharedLinks возвращает ссылки других пользователей, к которым у userId есть доступ.
+func (sr *ShortenerRepo) GetSharedLinks(userId *uuid.UUID) ([]models.ShortLink, error) {
+	var links []models.ShortLink
+	err := sr.Db.
+		Joins("JOIN link_shares ON link_shares.link_id = short_links.id").
+		Where("link_shares.user_id = ?", userId).
+		Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (sr *ShortenerRepo) CreateLinkShare(share *models.LinkShare) error {
+	return sr.Db.Create(share).Error
+}
+
+// GetLinkShare возвращает доступ пользователя к ссылке или nil, если доступа нет.
+func (sr *ShortenerRepo) GetLinkShare(linkId, userId *uuid.UUID) (*models.LinkShare, error) {
+	var share models.LinkShare
+	err := sr.Db.Where("link_id = ? AND user_id = ?", linkId, userId).First(&share).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &share, nil
+}
+
+// GetLinkShares возвращает доступы к ссылке вместе с именами пользователей одним запросом
+func (sr *ShortenerRepo) GetLinkShares(linkId *uuid.UUID) ([]models.LinkShare, error) {
+	var shares []models.LinkShare
+	err := sr.Db.
+		Select("link_shares.*, users.username").
+		Joins("JOIN users ON users.id = link_shares.user_id").
+		Where("link_shares.link_id = ?", linkId).
+		Order("link_shares.created_at").
+		Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+func (sr *ShortenerRepo) DeleteLinkShare(linkId, userId *uuid.UUID) error {
+	return sr.Db.Where("link_id = ? AND user_id = ?", linkId, userId).Delete(&models.LinkShare{}).Error
+}
+
+// GetLinkRevisions возвращает историю изменений ссылки, от новых к старым.
+func (sr *ShortenerRepo) GetLinkRevisions(linkId *uuid.UUID) ([]models.LinkRevision, error) {
+	var revisions []models.LinkRevision
+	err := sr.Db.Where("link_id = ?", linkId).Order("revision DESC").Find(&revisions).Error
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// GetLinkRevision возвращает ревизию ссылки или nil, если её нет.
+func (sr *ShortenerRepo) GetLinkRevision(linkId *uuid.UUID, revision int) (*models.LinkRevision, error) {
+	var rev models.LinkRevision
+	err := sr.Db.Where("link_id = ? AND revision = ?", linkId, revision).First(&rev).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// clickEventsInsertBatch - сколько строк вставлять одним INSERT
+const clickEventsInsertBatch = 500
+
+// ConsumeClick атомарно засчитывает переход по ссылке с лимитом.
+// Проверка и увеличение счётчика - один UPDATE, поэтому параллельные
+// редиректы не могут превысить max_clicks.
+func (sr *ShortenerRepo) ConsumeClick(linkId *uuid.UUID) (bool, error) {
+	result := sr.Db.Model(&models.ShortLink{}).
+		Where("id = ? AND max_clicks > 0 AND used_clicks < max_clicks", linkId).
+		UpdateColumn("used_clicks", gorm.Expr("used_clicks + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// clickBatchAttempts - сколько раз записывать пачку, если транзакцию прервала взаимная блокировка
+// или конфликт сериализации с другой репликой
+const clickBatchAttempts = 3
+
+// SaveClickBatch атомарно прибавляет клики пачки к счётчикам ссылок
+// (clicks = clicks + n, без перезаписи остальных полей) и вставляет события.
+// Ссылки обновляются в порядке ID: реплики, записывающие пересекающиеся пачки, блокируют строки
+// в одном порядке и не ждут друг друга по кругу.
+func (sr *ShortenerRepo) SaveClickBatch(events []models.ClickEvent) error {
+	type linkClicks struct {
+		count int
+		last  time.Time
+	}
+	byLink := make(map[uuid.UUID]*linkClicks)
+	for _, event := range events {
+		agg, ok := byLink[*event.LinkID]
+		if !ok {
+			agg = &linkClicks{}
+			byLink[*event.LinkID] = agg
+		}
+		agg.count++
+		if event.CreatedAt.After(agg.last) {
+			agg.last = event.CreatedAt
+		}
+	}
+	linkIds := make([]uuid.UUID, 0, len(byLink))
+	for linkId := range byLink {
+		linkIds = append(linkIds, linkId)
+	}
+	slices.SortFunc(linkIds, func(a, b uuid.UUID) int {
+		return bytes.Compare(a[:], b[:])
+	})
+
+	var err error
+	for attempt := 0; attempt < clickBatchAttempts; attempt++ {
+		err = sr.Db.Transaction(func(tx *gorm.DB) error {
+			for _, linkId := range linkIds {
+				agg := byLink[linkId]
+				err := tx.Model(&models.ShortLink{}).
+					Where("id = ?", linkId).
+					UpdateColumns(map[string]interface{}{
+						"clicks":     gorm.Expr("clicks + ?", agg.count),
+						"last_click": gorm.Expr("CASE WHEN last_click IS NULL OR last_click < ? THEN ? ELSE last_click END", agg.last, agg.last),
+					}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return tx.CreateInBatches(events, clickEventsInsertBatch).Error
+		})
+		if !isTransactionConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isTransactionConflict сообщает, что транзакцию откатила база из-за взаимной блокировки (40P01)
+// или конфликта сериализации (40001): такую транзакцию можно просто повторить
+func isTransactionConflict(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	code := pgErr.SQLState()
+	return code == "40P01" || code == "40001"
+}
+
+// GetClickTimeSeries считает клики по интервалам в часовом поясе timezone.
+// Время интервалов возвращается как локальное время пояса, помеченное UTC,
+// и только для непустых интервалов.
+func (sr *ShortenerRepo) GetClickTimeSeries(linkId *uuid.UUID, from, to time.Time, interval, timezone string) ([]models.ClickBucket, error) {
+	var buckets []models.ClickBucket
+	err := sr.Db.Model(&models.ClickEvent{}).
+		Select(`date_trunc(?, created_at AT TIME ZONE ?) AS "time", COUNT(*) AS clicks, COUNT(DISTINCT ip_hash) AS visitors`, interval, timezone).
+		Where("link_id = ? AND created_at >= ? AND created_at < ?", linkId, from, to).
+		Group("time").
+		Order(`"time"`).
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// GetClickBreakdown возвращает самые частые значения колонки column за период.
+// column должен быть из models.ClickBreakdownFields.
+func (sr *ShortenerRepo) GetClickBreakdown(linkId *uuid.UUID, from, to time.Time, column string, limit int) ([]models.ClickBreakdownItem, error) {
+	var items []models.ClickBreakdownItem
+	err := sr.Db.Model(&models.ClickEvent{}).
+		Select(column+" AS value, COUNT(*) AS clicks").
+		Where("link_id = ? AND created_at >= ? AND created_at < ?", linkId, from, to).
+		Group(column).
+		Order("clicks DESC").
+		Limit(limit).
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetVariantClicks считает клики и уникальных посетителей по вариантам A/B-теста за период
+func (sr *ShortenerRepo) GetVariantClicks(linkId *uuid.UUID, from, to time.Time) ([]models.VariantClicks, error) {
+	var items []models.VariantClicks
+	err := sr.Db.Model(&models.ClickEvent{}).
+		Select("variant AS name, COUNT(*) AS clicks, COUNT(DISTINCT ip_hash) AS visitors").
+		Where("link_id = ? AND created_at >= ? AND created_at < ? AND variant <> ''", linkId, from, to).
+		Group("variant").
+		Scan(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (sr *ShortenerRepo) CreateUTMTemplate(template *models.UTMTemplate) error {
+	return sr.Db.Create(template).Error
+}
+
+func (sr *ShortenerRepo) UpdateUTMTemplate(template *models.UTMTemplate) error {
+	return sr.Db.Save(template).Error
+}
+
+// GetUTMTemplate возвращает шаблон пользователя по имени или nil, если его нет.
+func (sr *ShortenerRepo) GetUTMTemplate(userId *uuid.UUID, name string) (*models.UTMTemplate, error) {
+	var template models.UTMTemplate
+	err := sr.Db.Where("user_id = ? AND name = ?", userId, name).First(&template).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (sr *ShortenerRepo) GetUTMTemplates(userId *uuid.UUID) ([]models.UTMTemplate, error) {
+	var templates []models.UTMTemplate
+	err := sr.Db.Where("user_id = ?", userId).Order("name").Find(&templates).Error
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (sr *ShortenerRepo) DeleteUTMTemplate(id *uuid.UUID) error {
+	return sr.Db.Where("id = ?", id).Delete(&models.UTMTemplate{}).Error
 }
@@ -0,0 +1,72 @@
+package shortener_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	shortenerRepo "github.com/bigxxby/dream-test-task/internal/api/repo/shortener"
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// linkRepo отдаёт ссылки из памяти; остальные методы репозитория не нужны
+type linkRepo struct {
+	shortenerRepo.IShortenerRepo
+	links map[string]*models.ShortLink
+}
+
+func (r *linkRepo) ResolveShortLink(shortID string) (*models.ShortLink, error) {
+	return r.links[shortID], nil
+}
+
+// clickLog запоминает записанные клики
+type clickLog struct {
+	events []models.ClickEvent
+}
+
+func (c *clickLog) Record(event models.ClickEvent) bool {
+	c.events = append(c.events, event)
+	return true
+}
+
+func TestRedirectBeforeActivation(t *testing.T) {
+	launch := time.Now().Add(time.Hour)
+	repo := &linkRepo{links: map[string]*models.ShortLink{
+		"hidden": {ShortId: "hidden", LongLink: "https://example.com", ActiveFrom: &launch},
+		"soon":   {ShortId: "soon", LongLink: "https://example.com", ActiveFrom: &launch, PendingMode: models.PendingComingSoon},
+		"teaser": {ShortId: "teaser", LongLink: "https://example.com", ActiveFrom: &launch, PendingMode: models.PendingRedirect, PendingURL: "https://example.com/teaser"},
+	}}
+	clicks := &clickLog{}
+	service := &shortener.ShortenerService{ShortenerRepo: repo, ClickRecorder: clicks}
+
+	// по умолчанию ссылка до запуска неотличима от несуществующей
+	_, status, err := service.Redirect("hidden", shortener.VisitInfo{})
+	assert.Equal(t, 404, status)
+	assert.EqualError(t, err, "link not found")
+
+	_, status, err = service.Redirect("soon", shortener.VisitInfo{})
+	assert.Equal(t, 404, status)
+	var notActive *shortener.NotActiveError
+	if assert.True(t, errors.As(err, &notActive)) {
+		assert.True(t, notActive.ActiveFrom.Equal(launch))
+	}
+
+	destination, status, err := service.Redirect("teaser", shortener.VisitInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, 302, status)
+	assert.Equal(t, "https://example.com/teaser", destination.URL)
+
+	// переходы до запуска не считаются кликами
+	assert.Empty(t, clicks.events)
+
+	// после запуска ссылка ведёт на адрес назначения
+	started := time.Now().Add(-time.Minute)
+	repo.links["soon"].ActiveFrom = &started
+	destination, status, err = service.Redirect("soon", shortener.VisitInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, 302, status)
+	assert.Equal(t, "https://example.com", destination.URL)
+	assert.Len(t, clicks.events, 1)
+}
@@ -0,0 +1,93 @@
+package shortener_test
+
+import (
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/bigxxby/dream-test-task/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateShortLinkAliasRace(t *testing.T) {
+	users := newMemoryUsers("alice", "bob")
+	repo := newMemoryRepo(users)
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      users,
+		Expiry:        shortener.NewExpiryPolicy(0, nil),
+	}
+	params := shortener.CreateLinkParams{Url: "https://example.com", Alias: "Spring-Sale"}
+
+	_, status, err := service.CreateShortLink(params, users.users["alice"].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	stored, _ := repo.GetShortLinkByShortID("spring-sale")
+	require.NotNil(t, stored)
+
+	_, status, err = service.CreateShortLink(params, users.users["bob"].ID)
+	assert.Equal(t, 409, status)
+	assert.EqualError(t, err, "alias is already taken")
+
+	// второй запрос прошёл проверку до того, как первый записал ссылку:
+	// запись отклоняет уникальный индекс, и это тоже 409, а не 500
+	repo.staleChecks = true
+	_, status, err = service.CreateShortLink(params, users.users["bob"].ID)
+	assert.Equal(t, 409, status)
+	assert.EqualError(t, err, "alias is already taken")
+}
+
+// sequence - последовательность в памяти вместо short_link_id_seq
+type sequence struct {
+	n int64
+}
+
+func (s *sequence) NextSequence() (int64, error) {
+	s.n++
+	return s.n, nil
+}
+
+func TestCreateShortLinkSequenceStrategy(t *testing.T) {
+	users := newMemoryUsers("alice")
+	repo := newMemoryRepo(users)
+	gen, err := utils.NewIDGenerator(utils.IDStrategySequence, 4, "", &sequence{})
+	require.NoError(t, err)
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      users,
+		IDGenerator:   gen,
+		Expiry:        shortener.NewExpiryPolicy(0, nil),
+	}
+
+	// соседние значения счётчика не должны считаться занятыми из-за сравнения без учёта регистра
+	for i := 0; i < 2000; i++ {
+		_, status, err := service.CreateShortLink(shortener.CreateLinkParams{Url: "https://example.com"}, users.users["alice"].ID)
+		require.NoError(t, err, "link %d", i)
+		require.Equal(t, 200, status)
+	}
+	assert.Len(t, repo.links, 2000)
+}
+
+func TestCreateShortLinkGeneratedIDRace(t *testing.T) {
+	users := newMemoryUsers("alice")
+	existing := &models.ShortLink{ShortId: "1000", LongLink: "https://example.org"}
+	repo := newMemoryRepo(users, existing)
+	gen, err := utils.NewIDGenerator(utils.IDStrategySequence, 4, "", &sequence{n: -1})
+	require.NoError(t, err)
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      users,
+		IDGenerator:   gen,
+		Expiry:        shortener.NewExpiryPolicy(0, nil),
+	}
+
+	// первый кандидат занят, но проверка этого не видит: запись отклоняется, и берётся следующий
+	repo.staleChecks = true
+	_, status, err := service.CreateShortLink(shortener.CreateLinkParams{Url: "https://example.com"}, users.users["alice"].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	created, _ := repo.GetShortLinkByShortID("1001")
+	require.NotNil(t, created)
+	assert.Equal(t, "https://example.com", created.LongLink)
+}
@@ -0,0 +1,139 @@
+package shortener
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrLinkExpired - срок действия ссылки истёк
+var ErrLinkExpired = errors.New("link expired")
+
+// NotActiveError - окно активации ссылки ещё не началось.
+// FallbackURL заполнен для pending_mode = redirect.
+type NotActiveError struct {
+	ActiveFrom  time.Time
+	FallbackURL string
+}
+
+func (e *NotActiveError) Error() string {
+	return "link is not active yet"
+}
+
+// ExpiryPolicy - серверные правила срока жизни ссылок
+type ExpiryPolicy struct {
+	DefaultTTL time.Duration            // срок ссылки без явного expires_at, 0 - бессрочно
+	MaxTTL     map[string]time.Duration // максимальный срок по ролям, 0 или нет роли - без ограничения
+}
+
+func NewExpiryPolicy(defaultTTL time.Duration, maxTTL map[string]time.Duration) *ExpiryPolicy {
+	return &ExpiryPolicy{DefaultTTL: defaultTTL, MaxTTL: maxTTL}
+}
+
+// Resolve вычисляет срок новой ссылки: явный expiresAt, бессрочный или по умолчанию.
+// Срок по умолчанию урезается до максимума роли, явный - отклоняется, если превышает его.
+func (p *ExpiryPolicy) Resolve(role string, expiresAt *time.Time, neverExpires bool, now time.Time) (*time.Time, error) {
+	if neverExpires && expiresAt != nil {
+		return nil, errors.New("expires_at cannot be combined with never_expires")
+	}
+	if neverExpires || expiresAt != nil {
+		return expiresAt, p.Check(role, expiresAt, now)
+	}
+
+	maxTTL := p.MaxTTL[role]
+	ttl := p.DefaultTTL
+	if maxTTL > 0 && (ttl <= 0 || ttl > maxTTL) {
+		ttl = maxTTL
+	}
+	if ttl <= 0 {
+		return nil, nil
+	}
+	expiration := now.Add(ttl)
+	return &expiration, nil
+}
+
+// Check проверяет срок ссылки по правилам роли. nil означает бессрочную ссылку.
+func (p *ExpiryPolicy) Check(role string, expiresAt *time.Time, now time.Time) error {
+	maxTTL := p.MaxTTL[role]
+	if expiresAt == nil {
+		if maxTTL > 0 {
+			return fmt.Errorf("links must expire within %s", maxTTL)
+		}
+		return nil
+	}
+	if !expiresAt.After(now) {
+		return errors.New("expiration time must be in the future")
+	}
+	if maxTTL > 0 && expiresAt.After(now.Add(maxTTL)) {
+		return fmt.Errorf("expiration time must be within %s", maxTTL)
+	}
+	return nil
+}
+
+// userRole возвращает роль пользователя; неизвестный пользователь считается обычным
+func (s *ShortenerService) userRole(userId *uuid.UUID) (string, error) {
+	user, err := s.UserRepo.GetUserById(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.RoleUser, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if user == nil || user.Role == "" {
+		return models.RoleUser, nil
+	}
+	return user.Role, nil
+}
+
+// ExtendLink продлевает ссылку на duration от текущего срока (или от текущего момента,
+// если ссылка уже истекла). duration == 0 означает срок по умолчанию.
+func (s *ShortenerService) ExtendLink(shortID string, userId *uuid.UUID, duration time.Duration) (*models.ShortLink, int, error) {
+	if duration < 0 {
+		return nil, 400, errors.New("duration must be positive")
+	}
+	if duration == 0 {
+		duration = s.Expiry.DefaultTTL
+	}
+	if duration <= 0 {
+		return nil, 400, errors.New("duration is required")
+	}
+
+	link, status, err := s.getOwnedLink(shortID, userId)
+	if err != nil {
+		return nil, status, err
+	}
+	if link.ExpiresAt == nil {
+		return nil, 400, errors.New("link never expires")
+	}
+
+	role, err := s.userRole(userId)
+	if err != nil {
+		return nil, 500, err
+	}
+
+	now := time.Now()
+	before := *link
+	base := *link.ExpiresAt
+	if base.Before(now) {
+		base = now
+	}
+	expiration := base.Add(duration)
+	if err := s.Expiry.Check(role, &expiration, now); err != nil {
+		return nil, 400, err
+	}
+	link.ExpiresAt = &expiration
+
+	revision := models.NewLinkRevision(&before, link, userId)
+	updated, err := s.ShortenerRepo.UpdateShortLinkVersioned(link, before.Version, revision)
+	if err != nil {
+		return nil, 500, err
+	}
+	if !updated {
+		return nil, 412, errors.New("link was modified by someone else, reload it and try again")
+	}
+	return link, 200, nil
+}
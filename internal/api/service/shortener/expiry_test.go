@@ -0,0 +1,86 @@
+package shortener_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiryPolicyResolve(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	policy := shortener.NewExpiryPolicy(30*24*time.Hour, map[string]time.Duration{
+		"user":  7 * 24 * time.Hour,
+		"admin": 0,
+	})
+
+	// срок по умолчанию урезается до максимума роли
+	expiresAt, err := policy.Resolve("user", nil, false, now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(7*24*time.Hour), *expiresAt)
+
+	expiresAt, err = policy.Resolve("admin", nil, false, now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(30*24*time.Hour), *expiresAt)
+
+	// бессрочные ссылки - только для ролей без ограничения
+	_, err = policy.Resolve("user", nil, true, now)
+	assert.Error(t, err)
+	expiresAt, err = policy.Resolve("admin", nil, true, now)
+	assert.NoError(t, err)
+	assert.Nil(t, expiresAt)
+
+	// явный срок не урезается, а отклоняется
+	tooLate := now.Add(8 * 24 * time.Hour)
+	_, err = policy.Resolve("user", &tooLate, false, now)
+	assert.Error(t, err)
+
+	past := now.Add(-time.Minute)
+	_, err = policy.Resolve("admin", &past, false, now)
+	assert.Error(t, err)
+
+	_, err = policy.Resolve("admin", &tooLate, true, now)
+	assert.Error(t, err)
+}
+
+func TestExpiryPolicyWithoutDefault(t *testing.T) {
+	now := time.Now()
+	policy := shortener.NewExpiryPolicy(0, map[string]time.Duration{"user": time.Hour})
+
+	// без срока по умолчанию ссылка бессрочна, если роль не ограничена
+	expiresAt, err := policy.Resolve("guest", nil, false, now)
+	assert.NoError(t, err)
+	assert.Nil(t, expiresAt)
+
+	expiresAt, err = policy.Resolve("user", nil, false, now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), *expiresAt)
+}
+
+func TestCreateShortLinkWithoutUserRow(t *testing.T) {
+	users := newMemoryUsers()
+	service := &shortener.ShortenerService{
+		ShortenerRepo: newMemoryRepo(users),
+		UserRepo:      users,
+		IDGenerator:   utils.NewRandomIDGenerator(6),
+		Expiry:        shortener.NewExpiryPolicy(0, map[string]time.Duration{"user": time.Hour}),
+	}
+	userId := uuid.New()
+
+	// пользователя нет в базе: действуют ограничения обычного пользователя, а не 500
+	link, status, err := service.CreateShortLink(shortener.CreateLinkParams{Url: "https://example.com"}, &userId)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	require.NotNil(t, link.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *link.ExpiresAt, time.Minute)
+
+	// другие ошибки базы по-прежнему 500
+	service.UserRepo = &memoryUsers{err: errDatabase}
+	_, status, err = service.CreateShortLink(shortener.CreateLinkParams{Url: "https://example.com"}, &userId)
+	assert.ErrorIs(t, err, errDatabase)
+	assert.Equal(t, 500, status)
+}
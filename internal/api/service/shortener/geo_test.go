@@ -0,0 +1,49 @@
+package shortener_test
+
+import (
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// countries - база GeoIP в памяти
+type countries map[string]string
+
+func (c countries) Country(ip string) string {
+	return c[ip]
+}
+
+func TestRedirectGeoTargets(t *testing.T) {
+	repo := &linkRepo{links: map[string]*models.ShortLink{
+		"promo": {
+			ShortId:    "promo",
+			LongLink:   "https://example.com",
+			GeoTargets: models.GeoTargets{"DE": "https://example.de"},
+		},
+	}}
+	clicks := &clickLog{}
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		ClickRecorder: clicks,
+		GeoIP:         countries{"192.0.2.1": "DE", "192.0.2.2": "US"},
+	}
+
+	destination, _, err := service.Redirect("promo", shortener.VisitInfo{IP: "192.0.2.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.de", destination.URL)
+
+	// остальные страны и неизвестные адреса идут на адрес по умолчанию
+	destination, _, _ = service.Redirect("promo", shortener.VisitInfo{IP: "192.0.2.2"})
+	assert.Equal(t, "https://example.com", destination.URL)
+	destination, _, _ = service.Redirect("promo", shortener.VisitInfo{IP: "198.51.100.7"})
+	assert.Equal(t, "https://example.com", destination.URL)
+
+	// страна попадает в клик для статистики
+	if assert.Len(t, clicks.events, 3) {
+		assert.Equal(t, "DE", clicks.events[0].Country)
+		assert.Equal(t, "US", clicks.events[1].Country)
+		assert.Equal(t, "", clicks.events[2].Country)
+	}
+}
@@ -0,0 +1,182 @@
+package shortener_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkHistoryAndRestore(t *testing.T) {
+	users := newMemoryUsers("alice", "bob")
+	alice := users.users["alice"]
+	repo := newMemoryRepo(users)
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      users,
+		Expiry:        shortener.NewExpiryPolicy(0, nil),
+	}
+
+	_, _, err := service.CreateShortLink(shortener.CreateLinkParams{
+		Url:          "https://example.com/v1",
+		Alias:        "promo",
+		RedirectCode: 301,
+		MaxClicks:    10,
+		Tags:         models.Tags{"spring"},
+		UTM:          models.UTMParams{Source: "newsletter"},
+	}, alice.ID)
+	require.NoError(t, err)
+
+	// меняем всё, что можно поменять, и переименовываем ссылку
+	url := "https://example.com/v2"
+	alias := "sale"
+	code := 307
+	password := "hunter22"
+	maxClicks := 0
+	tags := models.Tags{"summer"}
+	variants := models.Variants{{Name: "A", URL: "https://example.com/a", Weight: 1}, {Name: "B", URL: "https://example.com/b", Weight: 1}}
+	source := ""
+	link, _, err := service.UpdateLink("promo", alice.ID, shortener.UpdateLinkParams{
+		Url:          &url,
+		Alias:        &alias,
+		RedirectCode: &code,
+		Password:     &password,
+		MaxClicks:    &maxClicks,
+		Tags:         &tags,
+		Variants:     &variants,
+		UTM:          shortener.UTMPatch{Source: &source},
+		Version:      1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sale", link.ShortId)
+	assert.True(t, link.PasswordProtected)
+
+	// по старому идентификатору ссылки больше нет
+	_, status, _ := service.RestoreLinkRevision("promo", alice.ID, 1)
+	assert.Equal(t, 404, status)
+
+	restored, status, err := service.RestoreLinkRevision("sale", alice.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "promo", restored.ShortId)
+	assert.Equal(t, "https://example.com/v1", restored.LongLink)
+	assert.Equal(t, 301, restored.RedirectCode)
+	assert.Equal(t, 10, restored.MaxClicks)
+	assert.Equal(t, models.Tags{"spring"}, restored.Tags)
+	assert.Equal(t, "newsletter", restored.UTM.Source)
+	assert.Empty(t, restored.Variants)
+	assert.False(t, restored.PasswordProtected)
+	assert.Empty(t, restored.PasswordHash)
+	assert.Equal(t, 3, restored.Version)
+
+	// история от новых ревизий к старым, откат - отдельная ревизия
+	history, status, err := service.GetLinkHistory("promo", alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	if assert.Len(t, history, 3) {
+		assert.Equal(t, []int{3, 2, 1}, []int{history[0].Revision, history[1].Revision, history[2].Revision})
+		assert.Equal(t, 1, *history[0].RestoredFrom)
+		assert.Equal(t, "sale", history[0].OldShortId)
+		assert.Equal(t, "promo", history[0].NewShortId)
+		assert.Nil(t, history[1].RestoredFrom)
+	}
+
+	// откатывать может только владелец
+	_, status, _ = service.RestoreLinkRevision("promo", users.users["bob"].ID, 2)
+	assert.Equal(t, 403, status)
+	_, status, _ = service.RestoreLinkRevision("promo", alice.ID, 7)
+	assert.Equal(t, 404, status)
+}
+
+func TestRestoreLinkRevisionConflicts(t *testing.T) {
+	users := newMemoryUsers("alice")
+	alice := users.users["alice"]
+	repo := newMemoryRepo(users)
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      users,
+		Expiry:        shortener.NewExpiryPolicy(0, nil),
+	}
+
+	_, _, err := service.CreateShortLink(shortener.CreateLinkParams{Url: "https://example.com", Alias: "promo"}, alice.ID)
+	require.NoError(t, err)
+	alias := "sale"
+	_, _, err = service.UpdateLink("promo", alice.ID, shortener.UpdateLinkParams{Alias: &alias, Version: 1})
+	require.NoError(t, err)
+
+	// прежний идентификатор занят другой ссылкой
+	_, _, err = service.CreateShortLink(shortener.CreateLinkParams{Url: "https://example.org", Alias: "promo"}, alice.ID)
+	require.NoError(t, err)
+	_, status, err := service.RestoreLinkRevision("sale", alice.ID, 1)
+	assert.Equal(t, 409, status)
+	assert.EqualError(t, err, "short id of this revision is taken by another link")
+
+	// ссылку изменили между чтением и записью
+	_, status, _ = service.RestoreLinkRevision("sale", alice.ID, 2)
+	require.Equal(t, 200, status)
+	service.ShortenerRepo = concurrentRepo{repo}
+	_, status, _ = service.RestoreLinkRevision("sale", alice.ID, 2)
+	assert.Equal(t, 412, status)
+
+	// ревизии, записанные до снимков состояния, не откатываются
+	service.ShortenerRepo = repo
+	stored, _ := repo.GetShortLinkByShortID("sale")
+	for i := range repo.revisions {
+		if *repo.revisions[i].LinkID == *stored.ID && repo.revisions[i].Revision == 1 {
+			repo.revisions[i].State = nil
+		}
+	}
+	_, status, err = service.RestoreLinkRevision("sale", alice.ID, 1)
+	assert.Equal(t, 409, status)
+	assert.ErrorIs(t, err, models.ErrRevisionIncomplete)
+}
+
+func TestRestoreLinkRevisionChecksExpiry(t *testing.T) {
+	users := newMemoryUsers("alice")
+	alice := users.users["alice"]
+	repo := newMemoryRepo(users)
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      users,
+		Expiry:        shortener.NewExpiryPolicy(0, nil),
+	}
+
+	soon := time.Now().Add(time.Hour)
+	_, _, err := service.CreateShortLink(shortener.CreateLinkParams{Url: "https://example.com", Alias: "promo", ExpiresAt: &soon}, alice.ID)
+	require.NoError(t, err)
+	later := time.Now().Add(48 * time.Hour)
+	_, _, err = service.UpdateLink("promo", alice.ID, shortener.UpdateLinkParams{ExpiresAt: &later, Version: 1})
+	require.NoError(t, err)
+
+	// срок первой ревизии с тех пор прошёл
+	past := time.Now().Add(-time.Minute)
+	repo.revisions[0].State.ExpiresAt = &past
+	_, status, err := service.RestoreLinkRevision("promo", alice.ID, 1)
+	assert.Equal(t, 400, status)
+	assert.EqualError(t, err, "revision cannot be restored: expiration time must be in the future")
+
+	// бессрочная ревизия после ограничения срока для роли
+	_, _, err = service.CreateShortLink(shortener.CreateLinkParams{Url: "https://example.com", Alias: "forever"}, alice.ID)
+	require.NoError(t, err)
+	_, _, err = service.UpdateLink("forever", alice.ID, shortener.UpdateLinkParams{ExpiresAt: &soon, Version: 1})
+	require.NoError(t, err)
+	service.Expiry = shortener.NewExpiryPolicy(0, map[string]time.Duration{models.RoleUser: 24 * time.Hour})
+	_, status, err = service.RestoreLinkRevision("forever", alice.ID, 1)
+	assert.Equal(t, 400, status)
+	assert.Error(t, err)
+
+	// ревизия с тем же сроком откатывается
+	url := "https://example.org"
+	_, _, err = service.UpdateLink("forever", alice.ID, shortener.UpdateLinkParams{Url: &url, Version: 2})
+	require.NoError(t, err)
+	link, status, err := service.RestoreLinkRevision("forever", alice.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "https://example.com", link.LongLink)
+
+	stored, _ := repo.GetShortLinkByShortID("promo")
+	assert.True(t, later.Equal(*stored.ExpiresAt))
+}
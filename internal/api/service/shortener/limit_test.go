@@ -0,0 +1,110 @@
+package shortener_test
+
+import (
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limitRepo засчитывает переходы в лимит, как ConsumeClick репозитория
+type limitRepo struct {
+	linkRepo
+	err error
+}
+
+func (r *limitRepo) ConsumeClick(linkId *uuid.UUID) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	for _, link := range r.links {
+		if *link.ID == *linkId {
+			if link.ClicksExhausted() {
+				return false, nil
+			}
+			link.UsedClicks++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newLimitRepo(links ...*models.ShortLink) *limitRepo {
+	repo := &limitRepo{linkRepo: linkRepo{links: map[string]*models.ShortLink{}}}
+	for _, link := range links {
+		id := uuid.New()
+		link.ID = &id
+		repo.links[link.ShortId] = link
+	}
+	return repo
+}
+
+func TestRedirectClickLimit(t *testing.T) {
+	repo := newLimitRepo(&models.ShortLink{ShortId: "limited", LongLink: "https://example.com", MaxClicks: 2})
+	clicks := &clickLog{}
+	service := &shortener.ShortenerService{ShortenerRepo: repo, ClickRecorder: clicks}
+
+	for i := 0; i < 2; i++ {
+		destination, status, err := service.Redirect("limited", shortener.VisitInfo{})
+		require.NoError(t, err)
+		assert.Equal(t, 302, status)
+		assert.Equal(t, "https://example.com", destination.URL)
+	}
+
+	// после исчерпания лимита - 410, клик не записывается
+	_, status, err := service.Redirect("limited", shortener.VisitInfo{})
+	assert.Equal(t, 410, status)
+	assert.ErrorIs(t, err, shortener.ErrLinkExhausted)
+	assert.Len(t, clicks.events, 2)
+	assert.Equal(t, 2, repo.links["limited"].UsedClicks)
+
+	_, status, err = service.Preview("limited", shortener.VisitInfo{})
+	assert.Equal(t, 410, status)
+	assert.ErrorIs(t, err, shortener.ErrLinkExhausted)
+}
+
+func TestRedirectOneTimeWithFallback(t *testing.T) {
+	users := newMemoryUsers("alice")
+	repo := newLimitRepo(&models.ShortLink{
+		ShortId:      "once",
+		UserID:       users.users["alice"].ID,
+		LongLink:     "https://example.com/secret",
+		RedirectCode: 301,
+		MaxClicks:    1,
+		FallbackURL:  "https://example.com/expired",
+	})
+	clicks := &clickLog{}
+	service := &shortener.ShortenerService{ShortenerRepo: repo, UserRepo: users, ClickRecorder: clicks}
+
+	destination, status, err := service.Redirect("once", shortener.VisitInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, 301, status)
+	assert.Equal(t, "https://example.com/secret", destination.URL)
+
+	// запасной адрес временный, даже если у ссылки постоянный редирект
+	destination, status, err = service.Redirect("once", shortener.VisitInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, 302, status)
+	assert.Equal(t, "https://example.com/expired", destination.URL)
+	assert.Len(t, clicks.events, 1)
+
+	// предпросмотр исчерпанной ссылки с запасным адресом доступен
+	_, status, err = service.Preview("once", shortener.VisitInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+}
+
+func TestRedirectClickLimitError(t *testing.T) {
+	repo := newLimitRepo(&models.ShortLink{ShortId: "limited", LongLink: "https://example.com", MaxClicks: 5})
+	repo.err = errDatabase
+	clicks := &clickLog{}
+	service := &shortener.ShortenerService{ShortenerRepo: repo, ClickRecorder: clicks}
+
+	_, status, err := service.Redirect("limited", shortener.VisitInfo{})
+	assert.Equal(t, 500, status)
+	assert.ErrorIs(t, err, errDatabase)
+	assert.Empty(t, clicks.events)
+}
@@ -0,0 +1,167 @@
+package shortener
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+)
+
+// ListLinksParams - параметры списка ссылок в том виде, в котором они пришли от клиента
+type ListLinksParams struct {
+	Cursor string // pagination.next_cursor предыдущей страницы
+	Limit  string
+	Sort   string // created_at, clicks или last_click
+	Order  string // asc или desc
+	Status string // active, scheduled, exhausted или expired
+	Tag    string
+	From   string // RFC3339 или YYYY-MM-DD (UTC), включительно
+	To     string // не включительно
+	Domain string // домен адреса назначения
+	Search string // слова; знаки препинания разделяют слова, как в адресе
+}
+
+const (
+	maxSearchWords      = 5
+	maxSearchWordLength = 100
+)
+
+// domainRegex - имя хоста из латинских букв, цифр и дефисов (IDN - в punycode)
+var domainRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// GetLinks возвращает страницу ссылок пользователя с учётом фильтров, сортировки и курсора
+func (s *ShortenerService) GetLinks(userId *uuid.UUID, params ListLinksParams) (*models.LinkPage, int, error) {
+	query, err := parseListLinksParams(params)
+	if err != nil {
+		return nil, 400, err
+	}
+	query.Now = time.Now()
+
+	links, err := s.ShortenerRepo.GetLinks(userId, query)
+	if err != nil {
+		return nil, 500, err
+	}
+
+	order := "desc"
+	if !query.Desc {
+		order = "asc"
+	}
+	page := &models.LinkPage{
+		Links: links,
+		Pagination: models.Pagination{
+			Limit: query.Limit,
+			Sort:  query.Sort,
+			Order: order,
+		},
+	}
+	// Репозиторий возвращает на одну ссылку больше: так видно, есть ли следующая страница
+	if len(links) > query.Limit {
+		page.Links = links[:query.Limit]
+		page.Pagination.HasMore = true
+		page.Pagination.NextCursor = models.NewLinkCursor(&page.Links[query.Limit-1], query.Sort).Encode()
+	}
+	if page.Links == nil {
+		page.Links = []models.ShortLink{}
+	}
+	return page, 200, nil
+}
+
+func parseListLinksParams(params ListLinksParams) (models.LinkQuery, error) {
+	query := models.LinkQuery{
+		Sort:  models.LinkSortCreatedAt,
+		Desc:  true,
+		Limit: models.DefaultLinkPageSize,
+	}
+
+	switch params.Sort {
+	case "":
+	case models.LinkSortCreatedAt, models.LinkSortClicks, models.LinkSortLastClick:
+		query.Sort = params.Sort
+	default:
+		return query, errors.New("sort must be one of created_at, clicks, last_click")
+	}
+	switch params.Order {
+	case "", "desc":
+	case "asc":
+		query.Desc = false
+	default:
+		return query, errors.New("order must be asc or desc")
+	}
+
+	if params.Limit != "" {
+		limit, err := strconv.Atoi(params.Limit)
+		if err != nil || limit < 1 || limit > models.MaxLinkPageSize {
+			return query, fmt.Errorf("limit must be between 1 and %d", models.MaxLinkPageSize)
+		}
+		query.Limit = limit
+	}
+	if params.Cursor != "" {
+		cursor, err := models.DecodeLinkCursor(params.Cursor, query.Sort)
+		if err != nil {
+			return query, err
+		}
+		query.After = cursor
+	}
+
+	switch params.Status {
+	case "", models.LinkStatusActive, models.LinkStatusScheduled, models.LinkStatusExhausted, models.LinkStatusExpired:
+		query.Status = params.Status
+	default:
+		return query, errors.New("status must be active, scheduled, exhausted or expired")
+	}
+
+	if params.Tag != "" {
+		tag, err := models.NormalizeTag(params.Tag)
+		if err != nil {
+			return query, err
+		}
+		query.Tag = tag
+	}
+
+	if params.From != "" {
+		from, err := parseStatsTime(params.From, time.UTC)
+		if err != nil {
+			return query, errors.New("invalid from: " + err.Error())
+		}
+		query.CreatedFrom = &from
+	}
+	if params.To != "" {
+		to, err := parseStatsTime(params.To, time.UTC)
+		if err != nil {
+			return query, errors.New("invalid to: " + err.Error())
+		}
+		query.CreatedTo = &to
+	}
+	if query.CreatedFrom != nil && query.CreatedTo != nil && !query.CreatedFrom.Before(*query.CreatedTo) {
+		return query, errors.New("from must be before to")
+	}
+
+	if params.Domain != "" {
+		domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(params.Domain)), ".")
+		if len(domain) > 253 || !domainRegex.MatchString(domain) {
+			return query, errors.New("invalid domain")
+		}
+		query.Domain = domain
+	}
+
+	// Слова разбиваются так же, как models.LinkSearchVector разбивает адрес и заголовок
+	words := strings.FieldsFunc(strings.ToLower(params.Search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchWords {
+		return query, fmt.Errorf("search query can contain at most %d words", maxSearchWords)
+	}
+	for _, word := range words {
+		if len([]rune(word)) > maxSearchWordLength {
+			return query, fmt.Errorf("search words must be at most %d characters", maxSearchWordLength)
+		}
+	}
+	query.Search = words
+	return query, nil
+}
@@ -0,0 +1,128 @@
+package shortener_test
+
+import (
+	"testing"
+	"time"
+
+	shortenerRepo "github.com/bigxxby/dream-test-task/internal/api/repo/shortener"
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listRepo отдаёт заданные ссылки и запоминает запрос
+type listRepo struct {
+	shortenerRepo.IShortenerRepo
+	links []models.ShortLink
+	query models.LinkQuery
+}
+
+func (r *listRepo) GetLinks(userId *uuid.UUID, query models.LinkQuery) ([]models.ShortLink, error) {
+	r.query = query
+	if len(r.links) > query.Limit+1 {
+		return r.links[:query.Limit+1], nil
+	}
+	return r.links, nil
+}
+
+func TestGetLinksPagination(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var links []models.ShortLink
+	for i := range 3 {
+		id := uuid.New()
+		links = append(links, models.ShortLink{ID: &id, ShortId: string(rune('a' + i)), CreatedAt: created.Add(-time.Duration(i) * time.Hour)})
+	}
+	repo := &listRepo{links: links}
+	service := &shortener.ShortenerService{ShortenerRepo: repo}
+	userId := uuid.New()
+
+	page, status, err := service.GetLinks(&userId, shortener.ListLinksParams{Limit: "2"})
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Len(t, page.Links, 2)
+	assert.Equal(t, models.Pagination{Limit: 2, Sort: "created_at", Order: "desc", NextCursor: page.Pagination.NextCursor, HasMore: true}, page.Pagination)
+	assert.Equal(t, models.LinkSortCreatedAt, repo.query.Sort)
+	assert.True(t, repo.query.Desc)
+
+	// следующая страница начинается после последней ссылки предыдущей
+	page, _, err = service.GetLinks(&userId, shortener.ListLinksParams{Limit: "2", Cursor: page.Pagination.NextCursor})
+	require.NoError(t, err)
+	require.NotNil(t, repo.query.After)
+	assert.Equal(t, *links[1].ID, repo.query.After.ID)
+	assert.True(t, links[1].CreatedAt.Equal(*repo.query.After.Time))
+
+	// последняя страница
+	repo.links = links[2:]
+	page, _, err = service.GetLinks(&userId, shortener.ListLinksParams{})
+	require.NoError(t, err)
+	assert.False(t, page.Pagination.HasMore)
+	assert.Empty(t, page.Pagination.NextCursor)
+	assert.Equal(t, models.DefaultLinkPageSize, repo.query.Limit)
+
+	repo.links = nil
+	page, _, err = service.GetLinks(&userId, shortener.ListLinksParams{})
+	require.NoError(t, err)
+	assert.NotNil(t, page.Links)
+}
+
+func TestGetLinksFilters(t *testing.T) {
+	repo := &listRepo{}
+	service := &shortener.ShortenerService{ShortenerRepo: repo}
+	userId := uuid.New()
+
+	_, status, err := service.GetLinks(&userId, shortener.ListLinksParams{
+		Sort:   "last_click",
+		Order:  "asc",
+		Status: "expired",
+		Tag:    " Promo",
+		From:   "2024-05-01",
+		To:     "2024-06-01T00:00:00+03:00",
+		Domain: "Example.COM.",
+		Search: "  Spring   sale/2024 ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, models.LinkSortLastClick, repo.query.Sort)
+	assert.False(t, repo.query.Desc)
+	assert.Equal(t, models.LinkStatusExpired, repo.query.Status)
+	assert.Equal(t, "promo", repo.query.Tag)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), *repo.query.CreatedFrom)
+	assert.True(t, time.Date(2024, 5, 31, 21, 0, 0, 0, time.UTC).Equal(*repo.query.CreatedTo))
+	assert.Equal(t, "example.com", repo.query.Domain)
+	assert.Equal(t, []string{"spring", "sale", "2024"}, repo.query.Search)
+
+	for _, value := range []string{models.LinkStatusActive, models.LinkStatusScheduled, models.LinkStatusExhausted, models.LinkStatusExpired} {
+		_, status, err = service.GetLinks(&userId, shortener.ListLinksParams{Status: value})
+		require.NoError(t, err)
+		assert.Equal(t, 200, status)
+		assert.Equal(t, value, repo.query.Status)
+	}
+
+	// курсор от другой сортировки не подходит
+	id := uuid.New()
+	cursor := models.NewLinkCursor(&models.ShortLink{ID: &id}, models.LinkSortClicks).Encode()
+
+	for _, params := range []shortener.ListLinksParams{
+		{Sort: "title"},
+		{Order: "up"},
+		{Limit: "0"},
+		{Limit: "201"},
+		{Limit: "ten"},
+		{Cursor: "%%%"},
+		{Cursor: cursor},
+		{Status: "deleted"},
+		{Tag: "two words"},
+		{From: "yesterday"},
+		{From: "2024-06-01", To: "2024-05-01"},
+		{Domain: "http://example.com"},
+		{Domain: "exa%mple.com"},
+		{Search: "a b c d e f"},
+		{Search: "example.com/a/b/c/d"},
+	} {
+		_, status, err := service.GetLinks(&userId, params)
+		assert.Error(t, err, "%+v", params)
+		assert.Equal(t, 400, status, "%+v", params)
+	}
+}
@@ -0,0 +1,200 @@
+package shortener_test
+
+import (
+	"errors"
+	"strings"
+
+	shortenerRepo "github.com/bigxxby/dream-test-task/internal/api/repo/shortener"
+	"github.com/bigxxby/dream-test-task/internal/api/repo/user"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// memoryRepo хранит ссылки и доступы в памяти; методы, которые тестам не нужны, не реализованы
+type memoryRepo struct {
+	shortenerRepo.IShortenerRepo
+	links     map[uuid.UUID]*models.ShortLink
+	revisions []models.LinkRevision
+	shares    []models.LinkShare
+	users     *memoryUsers // для имён в GetLinkShares, как JOIN в базе
+
+	// staleChecks - IsShortIDTaken не видит занятых идентификаторов, как при гонке
+	// двух запросов между проверкой и записью
+	staleChecks bool
+}
+
+func newMemoryRepo(users *memoryUsers, links ...*models.ShortLink) *memoryRepo {
+	r := &memoryRepo{links: make(map[uuid.UUID]*models.ShortLink), users: users}
+	for _, link := range links {
+		if link.ID == nil {
+			id := uuid.New()
+			link.ID = &id
+		}
+		if link.Version == 0 {
+			link.Version = 1
+		}
+		r.links[*link.ID] = link
+	}
+	return r
+}
+
+func (r *memoryRepo) GetShortLinkByShortID(shortID string) (*models.ShortLink, error) {
+	for _, link := range r.links {
+		if link.ShortId == shortID {
+			copied := *link
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// IsShortIDTaken сравнивает идентификаторы без учёта регистра, как репозиторий
+func (r *memoryRepo) IsShortIDTaken(shortID string) (bool, error) {
+	if r.staleChecks {
+		return false, nil
+	}
+	return r.shortIDTakenByOther(shortID, nil), nil
+}
+
+// shortIDTakenByOther повторяет уникальный индекс по lower(short_id)
+func (r *memoryRepo) shortIDTakenByOther(shortID string, linkId *uuid.UUID) bool {
+	for id, link := range r.links {
+		if (linkId == nil || id != *linkId) && strings.EqualFold(link.ShortId, shortID) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateShortLink проверяет уникальность short_id, как уникальный индекс в базе
+func (r *memoryRepo) CreateShortLink(link *models.ShortLink) error {
+	if r.shortIDTakenByOther(link.ShortId, nil) {
+		return shortenerRepo.ErrShortIDTaken
+	}
+	id := uuid.New()
+	link.ID = &id
+	link.Version = 1
+	copied := *link
+	r.links[id] = &copied
+	r.revisions = append(r.revisions, *models.NewLinkRevision(nil, link, link.UserID))
+	return nil
+}
+
+// GetLinkRevisions возвращает ревизии от новых к старым, как репозиторий
+func (r *memoryRepo) GetLinkRevisions(linkId *uuid.UUID) ([]models.LinkRevision, error) {
+	var revisions []models.LinkRevision
+	for i := len(r.revisions) - 1; i >= 0; i-- {
+		if *r.revisions[i].LinkID == *linkId {
+			revisions = append(revisions, r.revisions[i])
+		}
+	}
+	return revisions, nil
+}
+
+func (r *memoryRepo) GetLinkRevision(linkId *uuid.UUID, revision int) (*models.LinkRevision, error) {
+	for _, rev := range r.revisions {
+		if *rev.LinkID == *linkId && rev.Revision == revision {
+			return &rev, nil
+		}
+	}
+	return nil, nil
+}
+
+// UpdateShortLinkVersioned сохраняет ссылку, только если версия в "базе" равна expectedVersion
+func (r *memoryRepo) UpdateShortLinkVersioned(link *models.ShortLink, expectedVersion int, revision *models.LinkRevision) (bool, error) {
+	stored, ok := r.links[*link.ID]
+	if !ok || stored.Version != expectedVersion {
+		return false, nil
+	}
+	if r.shortIDTakenByOther(link.ShortId, link.ID) {
+		return false, shortenerRepo.ErrShortIDTaken
+	}
+	link.Version = expectedVersion + 1
+	copied := *link
+	r.links[*link.ID] = &copied
+	if revision != nil {
+		revision.Revision = link.Version
+		r.revisions = append(r.revisions, *revision)
+	}
+	return true, nil
+}
+
+func (r *memoryRepo) CreateLinkShare(share *models.LinkShare) error {
+	id := uuid.New()
+	share.ID = &id
+	r.shares = append(r.shares, *share)
+	return nil
+}
+
+func (r *memoryRepo) GetLinkShare(linkId, userId *uuid.UUID) (*models.LinkShare, error) {
+	for _, share := range r.shares {
+		if *share.LinkID == *linkId && *share.UserID == *userId {
+			return &share, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryRepo) GetLinkShares(linkId *uuid.UUID) ([]models.LinkShare, error) {
+	var shares []models.LinkShare
+	for _, share := range r.shares {
+		if *share.LinkID == *linkId {
+			share.Username = r.users.names[*share.UserID]
+			shares = append(shares, share)
+		}
+	}
+	return shares, nil
+}
+
+func (r *memoryRepo) DeleteLinkShare(linkId, userId *uuid.UUID) error {
+	for i, share := range r.shares {
+		if *share.LinkID == *linkId && *share.UserID == *userId {
+			r.shares = append(r.shares[:i], r.shares[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// memoryUsers - пользователи в памяти; как и UserRepo, на отсутствующего отвечает gorm.ErrRecordNotFound
+type memoryUsers struct {
+	user.IUserRepo
+	users map[string]*models.User
+	names map[uuid.UUID]string
+	err   error // ошибка базы для всех запросов
+}
+
+func newMemoryUsers(usernames ...string) *memoryUsers {
+	u := &memoryUsers{users: make(map[string]*models.User), names: make(map[uuid.UUID]string)}
+	for _, username := range usernames {
+		id := uuid.New()
+		u.users[username] = &models.User{ID: &id, Username: username, Role: models.RoleUser}
+		u.names[id] = username
+	}
+	return u
+}
+
+func (u *memoryUsers) GetUserByName(username string) (*models.User, error) {
+	if u.err != nil {
+		return nil, u.err
+	}
+	user, ok := u.users[username]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (u *memoryUsers) GetUserById(userId *uuid.UUID) (*models.User, error) {
+	if u.err != nil {
+		return nil, u.err
+	}
+	if name, ok := u.names[*userId]; ok {
+		return u.users[name], nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// errDatabase - ошибка, которую фейки возвращают вместо недоступной базы
+var errDatabase = errors.New("connection refused")
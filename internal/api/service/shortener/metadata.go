@@ -0,0 +1,77 @@
+package shortener
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/metadata"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+)
+
+// metadataTimeout ограничивает загрузку страницы вместе с записью в базу
+const metadataTimeout = 15 * time.Second
+
+// RefreshLinkMetadata заново загружает заголовок, описание, иконку и og:image страницы назначения.
+// Только владелец ссылки может её обновить.
+func (s *ShortenerService) RefreshLinkMetadata(shortID string, userId *uuid.UUID) (*models.ShortLink, int, error) {
+	if s.Metadata == nil {
+		return nil, 503, errors.New("metadata fetching is disabled")
+	}
+
+	link, status, err := s.getOwnedLink(shortID, userId)
+	if err != nil {
+		return nil, status, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+	page, err := s.Metadata.Fetch(ctx, link.LongLink)
+	if err != nil {
+		return nil, 502, err
+	}
+
+	applyMetadata(link, page, time.Now())
+	updated, err := s.ShortenerRepo.UpdateLinkMetadata(link)
+	if err != nil {
+		return nil, 500, err
+	}
+	if !updated {
+		return nil, 409, errors.New("link destination changed while fetching metadata, try again")
+	}
+	return link, 200, nil
+}
+
+// fetchMetadataAsync ставит загрузку метаданных страницы в фоновую очередь, не задерживая ответ.
+// Ошибки только логируются, а при заполненной очереди загрузка пропускается:
+// ссылка работает и без метаданных.
+func (s *ShortenerService) fetchMetadataAsync(link *models.ShortLink) {
+	if s.Metadata == nil || s.MetadataQueue == nil {
+		return
+	}
+	target := &models.ShortLink{ID: link.ID, ShortId: link.ShortId, LongLink: link.LongLink}
+
+	s.MetadataQueue.Schedule(func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, metadataTimeout)
+		defer cancel()
+		page, err := s.Metadata.Fetch(ctx, target.LongLink)
+		if err != nil {
+			log.Printf("failed to fetch metadata for %s: %v", target.ShortId, err)
+			return
+		}
+		applyMetadata(target, page, time.Now())
+		if _, err := s.ShortenerRepo.UpdateLinkMetadata(target); err != nil {
+			log.Printf("failed to save metadata for %s: %v", target.ShortId, err)
+		}
+	})
+}
+
+func applyMetadata(link *models.ShortLink, page *metadata.Page, now time.Time) {
+	link.Title = page.Title
+	link.Description = page.Description
+	link.FaviconURL = page.FaviconURL
+	link.OGImage = page.Image
+	link.MetadataFetchedAt = &now
+}
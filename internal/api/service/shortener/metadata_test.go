@@ -0,0 +1,115 @@
+package shortener_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	shortenerRepo "github.com/bigxxby/dream-test-task/internal/api/repo/shortener"
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/metadata"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metadataRepo хранит ссылки в памяти и сообщает о сохранённых метаданных
+type metadataRepo struct {
+	shortenerRepo.IShortenerRepo
+	links map[string]*models.ShortLink
+	saved chan models.ShortLink
+}
+
+func (r *metadataRepo) GetShortLinkByShortID(shortID string) (*models.ShortLink, error) {
+	link, ok := r.links[shortID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *link
+	return &copied, nil
+}
+
+func (r *metadataRepo) UpdateShortLinkVersioned(link *models.ShortLink, expectedVersion int, revision *models.LinkRevision) (bool, error) {
+	link.Version = expectedVersion + 1
+	copied := *link
+	r.links[link.ShortId] = &copied
+	return true, nil
+}
+
+func (r *metadataRepo) UpdateLinkMetadata(link *models.ShortLink) (bool, error) {
+	stored := r.links[link.ShortId]
+	if stored.LongLink != link.LongLink {
+		return false, nil
+	}
+	stored.Title, stored.Description, stored.FaviconURL, stored.OGImage = link.Title, link.Description, link.FaviconURL, link.OGImage
+	stored.MetadataFetchedAt = link.MetadataFetchedAt
+	r.saved <- *stored
+	return true, nil
+}
+
+// pageFetcher отдаёт заранее заданные страницы
+type pageFetcher map[string]*metadata.Page
+
+func (f pageFetcher) Fetch(ctx context.Context, rawURL string) (*metadata.Page, error) {
+	page, ok := f[rawURL]
+	if !ok {
+		return nil, errors.New("destination responded with status 404")
+	}
+	return page, nil
+}
+
+func TestRefreshLinkMetadata(t *testing.T) {
+	owner, stranger := uuid.New(), uuid.New()
+	linkID := uuid.New()
+	repo := &metadataRepo{
+		links: map[string]*models.ShortLink{
+			"promo": {ID: &linkID, ShortId: "promo", LongLink: "https://example.com/sale", UserID: &owner, Version: 1},
+			"gone":  {ShortId: "gone", LongLink: "https://example.com/gone", UserID: &owner, Version: 1},
+		},
+		saved: make(chan models.ShortLink, 1),
+	}
+	fetcher := pageFetcher{
+		"https://example.com/sale": {Title: "Spring sale", Description: "50% off", FaviconURL: "https://example.com/favicon.ico", Image: "https://example.com/cover.png"},
+		"https://example.com/new":  {Title: "New page"},
+	}
+	queue, err := metadata.NewQueue(metadata.QueueOptions{Size: 10, Workers: 1})
+	require.NoError(t, err)
+	defer queue.Close(context.Background())
+	service := &shortener.ShortenerService{ShortenerRepo: repo, Metadata: fetcher, MetadataQueue: queue}
+
+	link, status, err := service.RefreshLinkMetadata("promo", &owner)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "Spring sale", link.Title)
+	assert.Equal(t, "50% off", link.Description)
+	assert.Equal(t, "https://example.com/favicon.ico", link.FaviconURL)
+	assert.Equal(t, "https://example.com/cover.png", link.OGImage)
+	assert.NotNil(t, link.MetadataFetchedAt)
+	assert.Equal(t, "Spring sale", (<-repo.saved).Title)
+
+	_, status, _ = service.RefreshLinkMetadata("promo", &stranger)
+	assert.Equal(t, 403, status)
+
+	_, status, err = service.RefreshLinkMetadata("gone", &owner)
+	assert.Equal(t, 502, status)
+	assert.Error(t, err)
+
+	// смена адреса загружает метаданные новой страницы в фоне
+	url := "https://example.com/new"
+	_, status, err = service.UpdateLink("promo", &owner, shortener.UpdateLinkParams{Url: &url, Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	select {
+	case saved := <-repo.saved:
+		assert.Equal(t, "New page", saved.Title)
+		assert.Empty(t, saved.OGImage)
+	case <-time.After(time.Second):
+		t.Fatal("metadata was not fetched after the url changed")
+	}
+
+	service.Metadata = nil
+	_, status, _ = service.RefreshLinkMetadata("promo", &owner)
+	assert.Equal(t, 503, status)
+}
@@ -0,0 +1,74 @@
+package shortener
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/bigxxby/dream-test-task/internal/utils"
+)
+
+// ErrPasswordRequired - ссылка защищена паролем, а доступ ещё не подтверждён
+var ErrPasswordRequired = errors.New("password required")
+
+// LinkAccess выдаёт и проверяет подписанные токены доступа к защищённым ссылкам
+// и ограничивает попытки ввода пароля по ссылке и IP.
+type LinkAccess struct {
+	Secret   []byte
+	TTL      time.Duration
+	Attempts *utils.RateLimiter
+}
+
+func NewLinkAccess(secret []byte, ttl time.Duration, attempts *utils.RateLimiter) *LinkAccess {
+	return &LinkAccess{Secret: secret, TTL: ttl, Attempts: attempts}
+}
+
+// Token возвращает токен вида "<unix-время истечения>.<подпись>".
+// Подпись включает хэш пароля, поэтому смена пароля отзывает выданные токены.
+func (a *LinkAccess) Token(link *models.ShortLink, now time.Time) string {
+	expires := strconv.FormatInt(now.Add(a.TTL).Unix(), 10)
+	return expires + "." + a.sign(link, expires)
+}
+
+func (a *LinkAccess) Verify(link *models.ShortLink, token string, now time.Time) bool {
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() >= expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(a.sign(link, expires)))
+}
+
+func (a *LinkAccess) sign(link *models.ShortLink, expires string) string {
+	mac := hmac.New(sha256.New, a.Secret)
+	mac.Write([]byte(link.ID.String() + "." + expires + "." + link.PasswordHash))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// UnlockLink проверяет пароль защищённой ссылки и возвращает токен доступа.
+// Для ссылки без пароля возвращает пустой токен.
+func (s *ShortenerService) UnlockLink(shortID string, password string, visit VisitInfo) (string, int, error) {
+	link, status, err := s.getRedirectableLink(shortID)
+	if err != nil {
+		return "", status, err
+	}
+	if link.PasswordHash == "" {
+		return "", 200, nil
+	}
+
+	if !s.LinkAccess.Attempts.Allow(link.ID.String() + "|" + utils.HashIP(visit.IP)) {
+		return "", 429, errors.New("too many password attempts, try again later")
+	}
+	if !link.ComparePassword(password) {
+		return "", 401, errors.New("invalid password")
+	}
+	return s.LinkAccess.Token(link, time.Now()), 200, nil
+}
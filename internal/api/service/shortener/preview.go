@@ -0,0 +1,94 @@
+package shortener
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"gorm.io/gorm"
+)
+
+// LinkPreview - данные страницы предпросмотра, которую посетитель видит вместо редиректа
+type LinkPreview struct {
+	ShortID string
+	// Destination - адрес, на который попадёт этот посетитель, с учётом правил, страны, варианта,
+	// меток UTM и параметров запроса. Пусто, если ссылка защищена паролем и посетитель его ещё
+	// не ввёл, или если вариант A/B-теста выберется только при переходе.
+	Destination  string
+	Alternatives []string // адреса вариантов A/B-теста, когда вариант посетителю ещё не назначен
+	Title        string   // заголовок страницы назначения, если посетитель попадёт на основной адрес
+	Creator      string   // имя пользователя, создавшего ссылку
+	CreatedAt    time.Time
+	Protected    bool
+	Interstitial bool // ссылка всегда показывает предпросмотр, для перехода нужна отметка continue
+}
+
+// Preview возвращает данные для страницы предпросмотра ссылки (короткий идентификатор с "+").
+// Клик не записывается и лимит переходов не расходуется. Адрес ссылки с паролем
+// показывается только посетителю с действующим токеном доступа.
+func (s *ShortenerService) Preview(shortID string, visit VisitInfo) (*LinkPreview, int, error) {
+	link, status, err := s.getRedirectableLink(shortID)
+	if err != nil {
+		return nil, status, err
+	}
+	if link.ClicksExhausted() && link.FallbackURL == "" {
+		return nil, 410, ErrLinkExhausted
+	}
+
+	unlocked := link.PasswordHash == "" || s.LinkAccess.Verify(link, visit.AccessToken, time.Now())
+	return s.newPreview(link, visit, unlocked)
+}
+
+// newPreview выбирает адрес так же, как Redirect: исчерпанная ссылка ведёт на FallbackURL,
+// иначе работают правила, страна и вариант посетителя
+func (s *ShortenerService) newPreview(link *models.ShortLink, visit VisitInfo, unlocked bool) (*LinkPreview, int, error) {
+	preview := &LinkPreview{
+		ShortID:      link.ShortId,
+		CreatedAt:    link.CreatedAt,
+		Protected:    link.PasswordHash != "",
+		Interstitial: link.Interstitial,
+	}
+	if unlocked {
+		s.previewDestination(preview, link, visit)
+	}
+
+	// У ссылки может не быть владельца, или его удалили: тогда автор просто не показывается
+	if link.UserID == nil {
+		return preview, 200, nil
+	}
+	creator, err := s.UserRepo.GetUserById(link.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return preview, 200, nil
+	}
+	if err != nil {
+		return nil, 500, err
+	}
+	if creator != nil {
+		preview.Creator = creator.Username
+	}
+	return preview, 200, nil
+}
+
+// previewDestination заполняет адрес назначения посетителя. Если сработал бы случайный
+// вариант A/B-теста, показываются все варианты: при переходе вариант выберется заново.
+func (s *ShortenerService) previewDestination(preview *LinkPreview, link *models.ShortLink, visit VisitInfo) {
+	if link.ClicksExhausted() && link.FallbackURL != "" {
+		preview.Destination = link.FallbackURL
+		return
+	}
+
+	target, _, variant := link.Target(s.newVisitor(link, visit))
+	assigned := link.StickyVariants && link.Variants.Find(visit.Variant) != nil
+	if variant != "" && !assigned {
+		preview.Alternatives = make([]string, 0, len(link.Variants))
+		for _, v := range link.Variants {
+			preview.Alternatives = append(preview.Alternatives, link.DestinationURLFor(v.URL, visit.Query))
+		}
+		return
+	}
+
+	preview.Destination = link.DestinationURLFor(target, visit.Query)
+	if target == link.LongLink {
+		preview.Title = link.Title
+	}
+}
@@ -0,0 +1,191 @@
+package shortener_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/api/repo/user"
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// userRepo отдаёт пользователей из памяти; отсутствующий пользователь - gorm.ErrRecordNotFound, как в базе
+type userRepo struct {
+	user.IUserRepo
+	users map[uuid.UUID]*models.User
+}
+
+func (r *userRepo) GetUserById(userId *uuid.UUID) (*models.User, error) {
+	user, ok := r.users[*userId]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func TestPreview(t *testing.T) {
+	creatorID := uuid.New()
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	linkID := uuid.New()
+	protected := &models.ShortLink{ID: &linkID, ShortId: "secret", LongLink: "https://example.com/private", UserID: &creatorID, CreatedAt: created}
+	assert.NoError(t, protected.SetPassword("hunter2"))
+
+	repo := &linkRepo{links: map[string]*models.ShortLink{
+		"promo":  {ShortId: "promo", LongLink: "https://example.com", Title: "Example", UserID: &creatorID, CreatedAt: created},
+		"safe":   {ShortId: "safe", LongLink: "https://example.com", UserID: &creatorID, CreatedAt: created, Interstitial: true},
+		"secret": protected,
+	}}
+	clicks := &clickLog{}
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      &userRepo{users: map[uuid.UUID]*models.User{creatorID: {Username: "alice"}}},
+		ClickRecorder: clicks,
+		LinkAccess:    shortener.NewLinkAccess([]byte("secret"), time.Hour, nil),
+	}
+
+	preview, status, err := service.Preview("promo", shortener.VisitInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "https://example.com", preview.Destination)
+	assert.Equal(t, "Example", preview.Title)
+	assert.Equal(t, "alice", preview.Creator)
+	assert.True(t, preview.CreatedAt.Equal(created))
+
+	// адрес ссылки с паролем скрыт до ввода пароля
+	preview, _, err = service.Preview("secret", shortener.VisitInfo{})
+	assert.NoError(t, err)
+	assert.True(t, preview.Protected)
+	assert.Empty(t, preview.Destination)
+
+	token := service.LinkAccess.Token(protected, time.Now())
+	preview, _, _ = service.Preview("secret", shortener.VisitInfo{AccessToken: token})
+	assert.Equal(t, "https://example.com/private", preview.Destination)
+
+	// ссылка с interstitial показывает предпросмотр до подтверждения перехода
+	destination, status, err := service.Redirect("safe", shortener.VisitInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	if assert.NotNil(t, destination.Preview) {
+		assert.True(t, destination.Preview.Interstitial)
+		assert.Equal(t, "alice", destination.Preview.Creator)
+	}
+
+	// отметка continue подтверждает переход и не передаётся в адрес назначения
+	repo.links["safe"].ForwardQuery = true
+	destination, status, _ = service.Redirect("safe", shortener.VisitInfo{Query: "ref=1&continue=1"})
+	assert.Equal(t, 302, status)
+	assert.Nil(t, destination.Preview)
+	assert.Equal(t, "https://example.com?ref=1", destination.URL)
+
+	// у обычной ссылки continue - параметр адреса назначения
+	repo.links["promo"].ForwardQuery = true
+	destination, _, _ = service.Redirect("promo", shortener.VisitInfo{Query: "continue=1"})
+	assert.Equal(t, "https://example.com?continue=1", destination.URL)
+
+	// предпросмотр не считается кликом
+	assert.Len(t, clicks.events, 2)
+}
+
+func TestPreviewWithoutCreator(t *testing.T) {
+	deletedID := uuid.New()
+	repo := &linkRepo{links: map[string]*models.ShortLink{
+		"orphan": {ShortId: "orphan", LongLink: "https://example.com", UserID: &deletedID},
+		"nobody": {ShortId: "nobody", LongLink: "https://example.com", Interstitial: true},
+	}}
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      &userRepo{users: map[uuid.UUID]*models.User{}},
+		ClickRecorder: &clickLog{},
+	}
+
+	// автор удалён: предпросмотр показывается без имени, а не падает с 500
+	preview, status, err := service.Preview("orphan", shortener.VisitInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Empty(t, preview.Creator)
+	assert.Equal(t, "https://example.com", preview.Destination)
+
+	// у ссылки нет владельца, и она всегда показывает предпросмотр
+	destination, status, err := service.Redirect("nobody", shortener.VisitInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	if assert.NotNil(t, destination.Preview) {
+		assert.Empty(t, destination.Preview.Creator)
+	}
+
+	// другие ошибки базы по-прежнему 500
+	service.UserRepo = &memoryUsers{err: errDatabase}
+	_, status, err = service.Preview("orphan", shortener.VisitInfo{})
+	assert.ErrorIs(t, err, errDatabase)
+	assert.Equal(t, 500, status)
+}
+
+func TestPreviewShowsVisitorDestination(t *testing.T) {
+	creatorID := uuid.New()
+	linkID := uuid.New()
+	variants := models.Variants{{Name: "A", URL: "https://example.com/a", Weight: 1}, {Name: "B", URL: "https://example.com/b", Weight: 1}}
+	repo := &linkRepo{links: map[string]*models.ShortLink{
+		"app": {
+			ShortId:  "app",
+			LongLink: "https://example.com",
+			Title:    "Example",
+			UserID:   &creatorID,
+			UTM:      models.UTMParams{Source: "flyer"},
+			TargetRules: models.TargetRules{
+				{Name: "ios", OS: "iOS", URL: "https://apps.apple.com/app/id1"},
+			},
+			GeoTargets: models.GeoTargets{"DE": "https://example.de"},
+		},
+		"ab":     {ShortId: "ab", LongLink: "https://example.com", UserID: &creatorID, Variants: variants},
+		"sticky": {ShortId: "sticky", LongLink: "https://example.com", UserID: &creatorID, Variants: variants, StickyVariants: true},
+		"once":   {ID: &linkID, ShortId: "once", LongLink: "https://example.com", UserID: &creatorID, MaxClicks: 1, UsedClicks: 1, FallbackURL: "https://example.com/over"},
+	}}
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      &userRepo{users: map[uuid.UUID]*models.User{creatorID: {Username: "alice"}}},
+		GeoIP:         countries{"192.0.2.1": "DE"},
+	}
+
+	tests := []struct {
+		name         string
+		shortID      string
+		visit        shortener.VisitInfo
+		destination  string
+		title        string
+		alternatives []string
+	}{
+		{"target rule", "app", shortener.VisitInfo{UserAgent: iPhoneUA}, "https://apps.apple.com/app/id1?utm_source=flyer", "", nil},
+		{"geo target", "app", shortener.VisitInfo{UserAgent: desktopUA, IP: "192.0.2.1"}, "https://example.de?utm_source=flyer", "", nil},
+		{"default", "app", shortener.VisitInfo{UserAgent: desktopUA}, "https://example.com?utm_source=flyer", "Example", nil},
+		{"random variant", "ab", shortener.VisitInfo{Variant: "B"}, "", "", []string{"https://example.com/a", "https://example.com/b"}},
+		{"assigned variant", "sticky", shortener.VisitInfo{Variant: "B"}, "https://example.com/b", "", nil},
+		{"exhausted with fallback", "once", shortener.VisitInfo{}, "https://example.com/over", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview, status, err := service.Preview(tt.shortID, tt.visit)
+			require.NoError(t, err)
+			assert.Equal(t, 200, status)
+			assert.Equal(t, tt.destination, preview.Destination)
+			assert.Equal(t, tt.title, preview.Title)
+			assert.Equal(t, tt.alternatives, preview.Alternatives)
+		})
+	}
+
+	// страница interstitial показывает тот же адрес, что и редирект после continue
+	repo.links["app"].Interstitial = true
+	repo.links["app"].ForwardQuery = true
+	service.ClickRecorder = &clickLog{}
+	visit := shortener.VisitInfo{UserAgent: iPhoneUA, Query: "ref=1"}
+	destination, _, err := service.Redirect("app", visit)
+	require.NoError(t, err)
+	require.NotNil(t, destination.Preview)
+	visit.Query = "ref=1&continue=1"
+	redirect, _, err := service.Redirect("app", visit)
+	require.NoError(t, err)
+	assert.Equal(t, redirect.URL, destination.Preview.Destination)
+}
@@ -0,0 +1,57 @@
+package shortener_test
+
+import (
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"
+	desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+func TestRedirectTargetRules(t *testing.T) {
+	repo := &linkRepo{links: map[string]*models.ShortLink{
+		"app": {
+			ShortId:  "app",
+			LongLink: "https://example.com",
+			TargetRules: models.TargetRules{
+				{Name: "ios", OS: "iOS", URL: "https://apps.apple.com/app/id1"},
+				{Name: "android", OS: "Android", URL: "https://play.google.com/store/apps/details?id=app"},
+			},
+			GeoTargets: models.GeoTargets{"DE": "https://example.de"},
+		},
+	}}
+	clicks := &clickLog{}
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		ClickRecorder: clicks,
+		GeoIP:         countries{"192.0.2.1": "DE"},
+	}
+
+	destination, _, err := service.Redirect("app", shortener.VisitInfo{UserAgent: iPhoneUA, IP: "192.0.2.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://apps.apple.com/app/id1", destination.URL)
+
+	destination, _, _ = service.Redirect("app", shortener.VisitInfo{UserAgent: androidUA})
+	assert.Equal(t, "https://play.google.com/store/apps/details?id=app", destination.URL)
+
+	// правила проверяются раньше стран, без совпадения работают GeoTargets и адрес по умолчанию
+	destination, _, _ = service.Redirect("app", shortener.VisitInfo{UserAgent: desktopUA, IP: "192.0.2.1"})
+	assert.Equal(t, "https://example.de", destination.URL)
+	destination, _, _ = service.Redirect("app", shortener.VisitInfo{UserAgent: desktopUA})
+	assert.Equal(t, "https://example.com", destination.URL)
+
+	// сработавшее правило попадает в клик для статистики
+	if assert.Len(t, clicks.events, 4) {
+		assert.Equal(t, "ios", clicks.events[0].Rule)
+		assert.Equal(t, "DE", clicks.events[0].Country)
+		assert.Equal(t, "android", clicks.events[1].Rule)
+		assert.Equal(t, "geo:DE", clicks.events[2].Rule)
+		assert.Equal(t, "", clicks.events[3].Rule)
+	}
+}
@@ -0,0 +1,77 @@
+package shortener_test
+
+import (
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareLink(t *testing.T) {
+	users := newMemoryUsers("owner", "alice", "bob")
+	owner, alice := users.users["owner"], users.users["alice"]
+	repo := newMemoryRepo(users, &models.ShortLink{ShortId: "promo", LongLink: "https://example.com", UserID: owner.ID})
+	service := &shortener.ShortenerService{ShortenerRepo: repo, UserRepo: users}
+
+	share, status, err := service.ShareLink("promo", owner.ID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "alice", share.Username)
+
+	_, status, _ = service.ShareLink("promo", owner.ID, "alice")
+	assert.Equal(t, 409, status)
+	_, status, _ = service.ShareLink("promo", owner.ID, "owner")
+	assert.Equal(t, 400, status)
+	_, status, _ = service.ShareLink("promo", owner.ID, "nobody")
+	assert.Equal(t, 404, status)
+
+	// делиться может только владелец, в том числе не тот, с кем уже поделились
+	_, status, _ = service.ShareLink("promo", alice.ID, "bob")
+	assert.Equal(t, 403, status)
+
+	// пользователь с доступом видит статистику, остальные - нет
+	_, status, err = service.GetLink("promo", alice.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	_, status, _ = service.GetLink("promo", users.users["bob"].ID)
+	assert.Equal(t, 403, status)
+
+	_, status, err = service.ShareLink("promo", owner.ID, "bob")
+	require.NoError(t, err)
+	shares, status, err := service.GetLinkShares("promo", owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	if assert.Len(t, shares, 2) {
+		assert.Equal(t, "alice", shares[0].Username)
+		assert.Equal(t, "bob", shares[1].Username)
+	}
+	_, status, _ = service.GetLinkShares("promo", alice.ID)
+	assert.Equal(t, 403, status)
+
+	status, err = service.UnshareLink("promo", owner.ID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	_, status, _ = service.GetLink("promo", alice.ID)
+	assert.Equal(t, 403, status)
+	status, _ = service.UnshareLink("promo", owner.ID, "nobody")
+	assert.Equal(t, 404, status)
+}
+
+func TestShareLinkUserLookupFails(t *testing.T) {
+	users := newMemoryUsers("owner", "alice")
+	owner := users.users["owner"]
+	repo := newMemoryRepo(users, &models.ShortLink{ShortId: "promo", LongLink: "https://example.com", UserID: owner.ID})
+	service := &shortener.ShortenerService{ShortenerRepo: repo, UserRepo: users}
+
+	// ошибка базы - это 500, а не "пользователь не найден"
+	users.err = errDatabase
+	_, status, err := service.ShareLink("promo", owner.ID, "alice")
+	assert.Equal(t, 500, status)
+	assert.ErrorIs(t, err, errDatabase)
+
+	status, err = service.UnshareLink("promo", owner.ID, "alice")
+	assert.Equal(t, 500, status)
+	assert.ErrorIs(t, err, errDatabase)
+}
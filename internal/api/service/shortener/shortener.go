@@ -1,109 +1,812 @@
 package shortener
 
 import (
+	"errors"
+	"math/rand/v2"
 	"time"
 
 	"github.com/bigxxby/dream-test-task/internal/api/repo/shortener"
+	"github.com/bigxxby/dream-test-task/internal/api/repo/user"
+	"github.com/bigxxby/dream-test-task/internal/clicks"
+	"github.com/bigxxby/dream-test-task/internal/geoip"
+	"github.com/bigxxby/dream-test-task/internal/metadata"
 	"github.com/bigxxby/dream-test-task/internal/models"
 	"github.com/bigxxby/dream-test-task/internal/utils"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// CreateLinkParams - параметры создания короткой ссылки
+type CreateLinkParams struct {
+	Url            string
+	Alias          string     // необязательный человекочитаемый идентификатор
+	ExpiresAt      *time.Time // nil - срок по умолчанию из ExpiryPolicy
+	NeverExpires   bool       // бессрочная ссылка, если роль это разрешает
+	ActiveFrom     *time.Time // nil - ссылка работает сразу
+	PendingMode    string     // поведение до ActiveFrom
+	PendingURL     string     // для PendingMode = redirect
+	RedirectCode   int        // 0 - код по умолчанию
+	ForwardQuery   bool
+	UTM            models.UTMParams
+	UTMTemplate    string // имя шаблона пользователя; явно переданные метки важнее
+	Password       string // необязательный пароль на переход
+	MaxClicks      int    // 0 - без ограничения
+	OneTime        bool   // то же, что MaxClicks = 1
+	FallbackURL    string // куда вести после исчерпания лимита
+	TargetRules    models.TargetRules
+	GeoTargets     models.GeoTargets
+	Variants       models.Variants
+	StickyVariants bool
+	Interstitial   bool // всегда показывать страницу предпросмотра
+	Tags           models.Tags
+}
+
+// UpdateLinkParams - изменяемые поля ссылки. nil означает, что поле не меняется.
+type UpdateLinkParams struct {
+	Url            *string
+	Alias          *string
+	ExpiresAt      *time.Time
+	NeverExpires   bool // снять срок действия, если роль это разрешает
+	ActiveFrom     *time.Time
+	ActivateNow    bool // снять окно активации
+	PendingMode    *string
+	PendingURL     *string // пустая строка удаляет адрес
+	RedirectCode   *int
+	ForwardQuery   *bool
+	UTM            UTMPatch
+	Password       *string             // пустая строка снимает пароль
+	MaxClicks      *int                // 0 снимает лимит
+	FallbackURL    *string             // пустая строка - 410 после исчерпания лимита
+	TargetRules    *models.TargetRules // заменяет правила целиком, пустой список удаляет их
+	GeoTargets     *models.GeoTargets  // пустой набор удаляет правила
+	Variants       *models.Variants    // заменяет варианты целиком, пустой список удаляет их
+	StickyVariants *bool
+	Interstitial   *bool
+	Tags           *models.Tags // заменяет метки целиком, пустой список удаляет их
+	Version        int          // версия, которую видел клиент (ETag / If-Match)
+}
+
+// VisitInfo - данные запроса посетителя, по которым записывается клик
+type VisitInfo struct {
+	IP             string
+	Referrer       string
+	UserAgent      string
+	AcceptLanguage string
+	Query          string // строка запроса без "?", для ForwardQuery
+	AccessToken    string // cookie доступа к ссылке с паролем
+	Variant        string // cookie с вариантом A/B-теста, назначенным ранее
+	Source         string // models.ClickSourceQR для перехода по QR-коду
+}
+
+// Destination - куда перенаправить посетителя
+type Destination struct {
+	URL     string
+	Variant string       // выбранный вариант A/B-теста, пусто если адрес выбран иначе
+	Sticky  bool         // вариант нужно закрепить за посетителем cookie
+	Preview *LinkPreview // вместо редиректа показать страницу предпросмотра
+}
+
 type IShortenerService interface {
-	CreateShortLink(link string, userId *uuid.UUID) (*models.ShortLink, int, error)
-	Redirect(shortID string) (string, int, error)
-	GetLinks(userId *uuid.UUID) ([]models.ShortLink, int, error)
-	GetLink(shortID string) (*models.ShortLink, int, error)
-	DeleteLink(shortID string) (int, error)
+	CreateShortLink(params CreateLinkParams, userId *uuid.UUID) (*models.ShortLink, int, error)
+	Redirect(shortID string, visit VisitInfo) (*Destination, int, error)
+	UnlockLink(shortID string, password string, visit VisitInfo) (string, int, error)
+	Preview(shortID string, visit VisitInfo) (*LinkPreview, int, error)
+	GetLinks(userId *uuid.UUID, params ListLinksParams) (*models.LinkPage, int, error)
+	GetBrokenLinks(userId *uuid.UUID) ([]models.ShortLink, int, error)
+	GetLink(shortID string, userId *uuid.UUID) (*models.ShortLink, int, error)
+	DeleteLink(shortID string, userId *uuid.UUID) (int, error)
+	UpdateLink(shortID string, userId *uuid.UUID, params UpdateLinkParams) (*models.ShortLink, int, error)
+	GetLinkHistory(shortID string, userId *uuid.UUID) ([]models.LinkRevision, int, error)
+	GetLinkTimeSeries(shortID string, userId *uuid.UUID, params TimeSeriesParams) (*models.ClickTimeSeries, int, error)
+	RestoreLinkRevision(shortID string, userId *uuid.UUID, revision int) (*models.ShortLink, int, error)
+	ExtendLink(shortID string, userId *uuid.UUID, duration time.Duration) (*models.ShortLink, int, error)
+	RefreshLinkMetadata(shortID string, userId *uuid.UUID) (*models.ShortLink, int, error)
+
+	GetSharedLinks(userId *uuid.UUID) ([]models.ShortLink, int, error)
+	ShareLink(shortID string, ownerId *uuid.UUID, username string) (*models.LinkShare, int, error)
+	GetLinkShares(shortID string, ownerId *uuid.UUID) ([]models.LinkShare, int, error)
+	UnshareLink(shortID string, ownerId *uuid.UUID, username string) (int, error)
+
+	GetUTMTemplates(userId *uuid.UUID) ([]models.UTMTemplate, int, error)
+	CreateUTMTemplate(userId *uuid.UUID, name string, utm models.UTMParams) (*models.UTMTemplate, int, error)
+	UpdateUTMTemplate(userId *uuid.UUID, name string, utm models.UTMParams) (*models.UTMTemplate, int, error)
+	DeleteUTMTemplate(userId *uuid.UUID, name string) (int, error)
 }
 
 type ShortenerService struct {
 	ShortenerRepo shortener.IShortenerRepo
+	UserRepo      user.IUserRepo
+	IDGenerator   utils.IDGenerator
+	ClickRecorder clicks.Recorder
+	LinkAccess    *LinkAccess
+	Expiry        *ExpiryPolicy
+	GeoIP         geoip.Locator      // nil - страна посетителя не определяется
+	Metadata      metadata.Fetcher   // nil - метаданные страниц назначения не загружаются
+	MetadataQueue metadata.Scheduler // nil - метаданные загружаются только по запросу владельца
 }
 
-func (s *ShortenerService) GetLinks(userId *uuid.UUID) ([]models.ShortLink, int, error) {
-	links, err := s.ShortenerRepo.GetLinks(userId)
+// maxShortIDAttempts - сколько кандидатов пробуем, прежде чем сдаться
+const maxShortIDAttempts = 10
+
+var errRedirectCode = errors.New("redirect code must be one of 301, 302, 307, 308")
+
+// ErrLinkExhausted - лимит переходов по ссылке исчерпан
+var ErrLinkExhausted = errors.New("link click limit reached")
+
+// GetLink возвращает ссылку владельцу или пользователю, с которым ею поделились
+func (s *ShortenerService) GetLink(shortID string, userId *uuid.UUID) (*models.ShortLink, int, error) {
+	link, status, err := s.getReadableLink(shortID, userId)
+	if err != nil {
+		return nil, status, err
+	}
+	return link, 200, nil
+}
+
+func NewShortenerService(shortenerRepo shortener.IShortenerRepo, userRepo user.IUserRepo, idGenerator utils.IDGenerator, clickRecorder clicks.Recorder, linkAccess *LinkAccess, expiry *ExpiryPolicy, geoLocator geoip.Locator, metadataFetcher metadata.Fetcher, metadataQueue metadata.Scheduler) IShortenerService {
+	return &ShortenerService{ShortenerRepo: shortenerRepo, UserRepo: userRepo, IDGenerator: idGenerator, ClickRecorder: clickRecorder, LinkAccess: linkAccess, Expiry: expiry, GeoIP: geoLocator, Metadata: metadataFetcher, MetadataQueue: metadataQueue}
+}
+func (s *ShortenerService) DeleteLink(shortID string, userId *uuid.UUID) (int, error) {
+	link, status, err := s.getOwnedLink(shortID, userId)
+	if err != nil {
+		return status, err
+	}
+
+	err = s.ShortenerRepo.DeleteLink(link.ShortId, userId)
+	if err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
+
+// UpdateLink меняет адрес назначения, алиас и срок действия ссылки.
+// Изменение применяется, только если версия ссылки совпадает с params.Version,
+// иначе возвращается 412, чтобы параллельные правки не затирали друг друга.
+func (s *ShortenerService) UpdateLink(shortID string, userId *uuid.UUID, params UpdateLinkParams) (*models.ShortLink, int, error) {
+	if params.Version <= 0 {
+		return nil, 428, errors.New("link version is required, pass it in If-Match header or version field")
+	}
+
+	link, status, err := s.getOwnedLink(shortID, userId)
+	if err != nil {
+		return nil, status, err
+	}
+	if link.Version != params.Version {
+		return nil, 412, errors.New("link was modified by someone else, reload it and try again")
+	}
+	before := *link
+
+	if params.Url != nil {
+		link.LongLink = *params.Url
+		err = link.ValidateLongLink()
+		if err != nil {
+			return nil, 400, err
+		}
+	}
+
+	if params.Alias != nil && models.NormalizeAlias(*params.Alias) != link.ShortId {
+		err = link.SetAlias(*params.Alias)
+		if err != nil {
+			return nil, 400, err
+		}
+
+		taken, err := s.ShortenerRepo.IsShortIDTaken(link.ShortId)
+		if err != nil {
+			return nil, 500, err
+		}
+		if taken {
+			return nil, 409, errors.New("alias is already taken")
+		}
+	}
+
+	if params.ExpiresAt != nil || params.NeverExpires {
+		if params.ExpiresAt != nil && params.NeverExpires {
+			return nil, 400, errors.New("expires_at cannot be combined with never_expires")
+		}
+		role, err := s.userRole(userId)
+		if err != nil {
+			return nil, 500, err
+		}
+		if err := s.Expiry.Check(role, params.ExpiresAt, time.Now()); err != nil {
+			return nil, 400, err
+		}
+		link.ExpiresAt = params.ExpiresAt
+	}
+
+	if params.ActiveFrom != nil && params.ActivateNow {
+		return nil, 400, errors.New("active_from cannot be combined with activate_now")
+	}
+	if params.ActiveFrom != nil || params.ActivateNow {
+		link.ActiveFrom = params.ActiveFrom
+	}
+	if params.PendingMode != nil {
+		link.PendingMode = *params.PendingMode
+	}
+	if params.PendingURL != nil {
+		link.PendingURL = *params.PendingURL
+	}
+	if err := link.ValidateActivation(); err != nil {
+		return nil, 400, err
+	}
+
+	if params.RedirectCode != nil {
+		if !models.IsValidRedirectCode(*params.RedirectCode) {
+			return nil, 400, errRedirectCode
+		}
+		link.RedirectCode = *params.RedirectCode
+	}
+	if params.ForwardQuery != nil {
+		link.ForwardQuery = *params.ForwardQuery
+	}
+
+	params.UTM.ApplyTo(&link.UTM)
+	if err := link.UTM.Normalize(); err != nil {
+		return nil, 400, err
+	}
+
+	if params.Password != nil {
+		if err := link.SetPassword(*params.Password); err != nil {
+			return nil, 400, err
+		}
+	}
+
+	if params.MaxClicks != nil {
+		link.MaxClicks = *params.MaxClicks
+	}
+	if params.FallbackURL != nil {
+		link.FallbackURL = *params.FallbackURL
+	}
+	if err := link.ValidateClickLimit(); err != nil {
+		return nil, 400, err
+	}
+
+	if params.TargetRules != nil {
+		link.TargetRules, err = params.TargetRules.Normalize()
+		if err != nil {
+			return nil, 400, err
+		}
+	}
+	if params.GeoTargets != nil {
+		link.GeoTargets, err = params.GeoTargets.Normalize()
+		if err != nil {
+			return nil, 400, err
+		}
+	}
+	if params.Variants != nil {
+		link.Variants, err = params.Variants.Normalize()
+		if err != nil {
+			return nil, 400, err
+		}
+	}
+	if params.StickyVariants != nil {
+		link.StickyVariants = *params.StickyVariants
+	}
+	if params.Interstitial != nil {
+		link.Interstitial = *params.Interstitial
+	}
+	if params.Tags != nil {
+		link.Tags, err = params.Tags.Normalize()
+		if err != nil {
+			return nil, 400, err
+		}
+	}
+
+	revision := models.NewLinkRevision(&before, link, userId)
+	updated, err := s.ShortenerRepo.UpdateShortLinkVersioned(link, params.Version, revision)
+	if errors.Is(err, shortener.ErrShortIDTaken) {
+		return nil, 409, errors.New("alias is already taken")
+	}
 	if err != nil {
 		return nil, 500, err
 	}
-	return links, 200, nil
+	if !updated {
+		return nil, 412, errors.New("link was modified by someone else, reload it and try again")
+	}
+	if link.LongLink != before.LongLink {
+		s.fetchMetadataAsync(link)
+	}
+	return link, 200, nil
+}
+
+// GetLinkHistory возвращает историю изменений ссылки владельцу или пользователю с доступом
+func (s *ShortenerService) GetLinkHistory(shortID string, userId *uuid.UUID) ([]models.LinkRevision, int, error) {
+	link, status, err := s.getReadableLink(shortID, userId)
+	if err != nil {
+		return nil, status, err
+	}
+
+	revisions, err := s.ShortenerRepo.GetLinkRevisions(link.ID)
+	if err != nil {
+		return nil, 500, err
+	}
+	return revisions, 200, nil
+}
+
+// RestoreLinkRevision откатывает ссылку к состоянию после указанной ревизии: адрес, алиас, сроки,
+// пароль, лимит переходов, правила и остальные изменяемые поля. Откат сам записывается
+// в историю как новая ревизия. Ревизии без снимка состояния не откатываются (409).
+func (s *ShortenerService) RestoreLinkRevision(shortID string, userId *uuid.UUID, revision int) (*models.ShortLink, int, error) {
+	link, status, err := s.getOwnedLink(shortID, userId)
+	if err != nil {
+		return nil, status, err
+	}
+
+	rev, err := s.ShortenerRepo.GetLinkRevision(link.ID, revision)
+	if err != nil {
+		return nil, 500, err
+	}
+	if rev == nil {
+		return nil, 404, errors.New("revision not found")
+	}
+
+	before := *link
+	if err := rev.ApplyTo(link); err != nil {
+		return nil, 409, err
+	}
+
+	// Сроки ревизии могли устареть или выйти за нынешние ограничения роли, проверяем их
+	// так же, как при изменении ссылки
+	if !equalTime(link.ExpiresAt, before.ExpiresAt) {
+		role, err := s.userRole(userId)
+		if err != nil {
+			return nil, 500, err
+		}
+		if err := s.Expiry.Check(role, link.ExpiresAt, time.Now()); err != nil {
+			return nil, 400, errors.New("revision cannot be restored: " + err.Error())
+		}
+	}
+	if err := link.ValidateActivation(); err != nil {
+		return nil, 400, errors.New("revision cannot be restored: " + err.Error())
+	}
+
+	if link.ShortId != before.ShortId {
+		taken, err := s.ShortenerRepo.IsShortIDTaken(link.ShortId)
+		if err != nil {
+			return nil, 500, err
+		}
+		if taken {
+			return nil, 409, errors.New("short id of this revision is taken by another link")
+		}
+	}
+
+	restored := models.NewLinkRevision(&before, link, userId)
+	restored.RestoredFrom = &revision
+	updated, err := s.ShortenerRepo.UpdateShortLinkVersioned(link, before.Version, restored)
+	if errors.Is(err, shortener.ErrShortIDTaken) {
+		return nil, 409, errors.New("short id of this revision is taken by another link")
+	}
+	if err != nil {
+		return nil, 500, err
+	}
+	if !updated {
+		return nil, 412, errors.New("link was modified by someone else, reload it and try again")
+	}
+	if link.LongLink != before.LongLink {
+		s.fetchMetadataAsync(link)
+	}
+	return link, 200, nil
+}
+
+// equalTime сравнивает необязательные моменты времени
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
-func (s *ShortenerService) GetLink(shortID string) (*models.ShortLink, int, error) {
+
+// getOwnedLink возвращает ссылку, только если она принадлежит пользователю
+func (s *ShortenerService) getOwnedLink(shortID string, userId *uuid.UUID) (*models.ShortLink, int, error) {
 	link, err := s.ShortenerRepo.GetShortLinkByShortID(shortID)
 	if err != nil {
-		return nil, 404, err
+		return nil, 500, err
+	}
+	if link == nil {
+		return nil, 404, errors.New("link not found")
+	}
+	if !link.IsOwnedBy(userId) {
+		return nil, 403, errors.New("access denied")
 	}
 	return link, 200, nil
 }
 
-func NewShortenerService(shortenerRepo shortener.IShortenerRepo) IShortenerService {
-	return &ShortenerService{ShortenerRepo: shortenerRepo}
+// getReadableLink возвращает ссылку владельцу или пользователю с доступом на чтение
+func (s *ShortenerService) getReadableLink(shortID string, userId *uuid.UUID) (*models.ShortLink, int, error) {
+	link, err := s.ShortenerRepo.GetShortLinkByShortID(shortID)
+	if err != nil {
+		return nil, 500, err
+	}
+	if link == nil {
+		return nil, 404, errors.New("link not found")
+	}
+	if link.IsOwnedBy(userId) {
+		return link, 200, nil
+	}
+
+	share, err := s.ShortenerRepo.GetLinkShare(link.ID, userId)
+	if err != nil {
+		return nil, 500, err
+	}
+	if share == nil {
+		return nil, 403, errors.New("access denied")
+	}
+	return link, 200, nil
+}
+
+// GetBrokenLinks возвращает ссылки пользователя, адрес назначения которых не отвечает при проверке
+func (s *ShortenerService) GetBrokenLinks(userId *uuid.UUID) ([]models.ShortLink, int, error) {
+	links, err := s.ShortenerRepo.GetBrokenLinks(userId)
+	if err != nil {
+		return nil, 500, err
+	}
+	return links, 200, nil
+}
+
+func (s *ShortenerService) GetSharedLinks(userId *uuid.UUID) ([]models.ShortLink, int, error) {
+	links, err := s.ShortenerRepo.GetSharedLinks(userId)
+	if err != nil {
+		return nil, 500, err
+	}
+	return links, 200, nil
+}
+
+// ShareLink даёт пользователю username доступ на чтение статистики ссылки
+func (s *ShortenerService) ShareLink(shortID string, ownerId *uuid.UUID, username string) (*models.LinkShare, int, error) {
+	link, status, err := s.getOwnedLink(shortID, ownerId)
+	if err != nil {
+		return nil, status, err
+	}
+
+	user, status, err := s.findUser(username)
+	if err != nil {
+		return nil, status, err
+	}
+	if link.IsOwnedBy(user.ID) {
+		return nil, 400, errors.New("cannot share link with its owner")
+	}
+
+	existing, err := s.ShortenerRepo.GetLinkShare(link.ID, user.ID)
+	if err != nil {
+		return nil, 500, err
+	}
+	if existing != nil {
+		return nil, 409, errors.New("link already shared with this user")
+	}
+
+	share := &models.LinkShare{
+		LinkID:   link.ID,
+		UserID:   user.ID,
+		Username: user.Username,
+	}
+	err = s.ShortenerRepo.CreateLinkShare(share)
+	if err != nil {
+		return nil, 500, err
+	}
+	return share, 200, nil
 }
-func (s *ShortenerService) DeleteLink(shortID string) (int, error) {
-	err := s.ShortenerRepo.DeleteLink(shortID)
+
+func (s *ShortenerService) GetLinkShares(shortID string, ownerId *uuid.UUID) ([]models.LinkShare, int, error) {
+	link, status, err := s.getOwnedLink(shortID, ownerId)
+	if err != nil {
+		return nil, status, err
+	}
+
+	shares, err := s.ShortenerRepo.GetLinkShares(link.ID)
+	if err != nil {
+		return nil, 500, err
+	}
+	return shares, 200, nil
+}
+
+func (s *ShortenerService) UnshareLink(shortID string, ownerId *uuid.UUID, username string) (int, error) {
+	link, status, err := s.getOwnedLink(shortID, ownerId)
+	if err != nil {
+		return status, err
+	}
+
+	user, status, err := s.findUser(username)
+	if err != nil {
+		return status, err
+	}
+
+	err = s.ShortenerRepo.DeleteLinkShare(link.ID, user.ID)
 	if err != nil {
 		return 500, err
 	}
 	return 200, nil
 }
 
+// findUser ищет пользователя, с которым делятся ссылкой: 404, если его нет, 500 при ошибке базы
+func (s *ShortenerService) findUser(username string) (*models.User, int, error) {
+	user, err := s.UserRepo.GetUserByName(username)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user == nil) {
+		return nil, 404, errors.New("user not found")
+	}
+	if err != nil {
+		return nil, 500, err
+	}
+	return user, 200, nil
+}
+
 // CreateShortLink implements IShortenerService.
-func (s *ShortenerService) CreateShortLink(link string, userId *uuid.UUID) (*models.ShortLink, int, error) {
-	// Генерация уникального короткого идентификатора
-	shortLink := utils.GenerateShortLink()
+func (s *ShortenerService) CreateShortLink(params CreateLinkParams, userId *uuid.UUID) (*models.ShortLink, int, error) {
+	shortLinkModel := &models.ShortLink{
+		LongLink:     params.Url,
+		UserID:       userId, // Привязываем userId
+		RedirectCode: params.RedirectCode,
+		ForwardQuery: params.ForwardQuery,
+	}
+
+	err := shortLinkModel.ValidateLongLink()
+	if err != nil {
+		return nil, 400, err
+	}
 
-	// Проверка, существует ли уже короткая ссылка с таким идентификатором
-	existingLink, err := s.ShortenerRepo.GetShortLinkByShortID(shortLink)
-	if err != nil && err.Error() != "record not found" {
+	role, err := s.userRole(userId)
+	if err != nil {
 		return nil, 500, err
 	}
-	if existingLink != nil {
-		// Если ссылка уже существует, генерируем новую
-		shortLink = utils.GenerateShortLink()
+	shortLinkModel.ExpiresAt, err = s.Expiry.Resolve(role, params.ExpiresAt, params.NeverExpires, time.Now())
+	if err != nil {
+		return nil, 400, err
 	}
 
-	expiration := time.Now().Add(30 * 24 * time.Hour)
+	shortLinkModel.ActiveFrom = params.ActiveFrom
+	shortLinkModel.PendingMode = params.PendingMode
+	shortLinkModel.PendingURL = params.PendingURL
+	err = shortLinkModel.ValidateActivation()
+	if err != nil {
+		return nil, 400, err
+	}
+	if params.RedirectCode != 0 && !models.IsValidRedirectCode(params.RedirectCode) {
+		return nil, 400, errRedirectCode
+	}
 
-	shortLinkModel := &models.ShortLink{
-		LongLink:  link,
-		ShortId:   shortLink,
-		UserID:    userId, // Привязываем userId
-		ExpiresAt: &expiration,
+	utm, status, err := s.resolveUTM(userId, params.UTMTemplate, params.UTM)
+	if err != nil {
+		return nil, status, err
 	}
+	shortLinkModel.UTM = utm
 
-	err = s.ShortenerRepo.CreateShortLink(shortLinkModel)
+	err = shortLinkModel.SetPassword(params.Password)
 	if err != nil {
-		return nil, 500, err
+		return nil, 400, err
+	}
+
+	shortLinkModel.MaxClicks = params.MaxClicks
+	if params.OneTime {
+		if params.MaxClicks > 1 {
+			return nil, 400, errors.New("one_time link cannot have max_clicks greater than 1")
+		}
+		shortLinkModel.MaxClicks = 1
+	}
+	shortLinkModel.FallbackURL = params.FallbackURL
+	err = shortLinkModel.ValidateClickLimit()
+	if err != nil {
+		return nil, 400, err
 	}
 
-	err = shortLinkModel.ValidateLongLink()
+	shortLinkModel.TargetRules, err = params.TargetRules.Normalize()
+	if err != nil {
+		return nil, 400, err
+	}
+	shortLinkModel.GeoTargets, err = params.GeoTargets.Normalize()
 	if err != nil {
 		return nil, 400, err
 	}
+	shortLinkModel.Variants, err = params.Variants.Normalize()
+	if err != nil {
+		return nil, 400, err
+	}
+	shortLinkModel.StickyVariants = params.StickyVariants
+	shortLinkModel.Interstitial = params.Interstitial
+	shortLinkModel.Tags, err = params.Tags.Normalize()
+	if err != nil {
+		return nil, 400, err
+	}
+
+	if params.Alias != "" {
+		err = shortLinkModel.SetAlias(params.Alias)
+		if err != nil {
+			return nil, 400, err
+		}
+
+		taken, err := s.ShortenerRepo.IsShortIDTaken(shortLinkModel.ShortId)
+		if err != nil {
+			return nil, 500, err
+		}
+		if taken {
+			return nil, 409, errors.New("alias is already taken")
+		}
+
+		// Между проверкой и записью алиас может занять параллельный запрос: его отсекает уникальный индекс
+		err = s.ShortenerRepo.CreateShortLink(shortLinkModel)
+		if errors.Is(err, shortener.ErrShortIDTaken) {
+			return nil, 409, errors.New("alias is already taken")
+		}
+		if err != nil {
+			return nil, 500, err
+		}
+	} else {
+		err = s.createWithGeneratedID(shortLinkModel)
+		if err != nil {
+			return nil, 500, err
+		}
+	}
+	s.fetchMetadataAsync(shortLinkModel)
 
 	// shortLink = "http://localhost:" + config.AppPort + "/" + "shortener/" + shortLink
 	shortLinkModel.ParseShortId()
 	return shortLinkModel, 200, nil
 }
 
-func (s *ShortenerService) Redirect(shortID string) (string, int, error) {
-	shortLink, err := s.ShortenerRepo.GetShortLinkByShortID(shortID)
+// createWithGeneratedID запрашивает у генератора кандидатов, пока не найдёт свободный идентификатор,
+// и сохраняет ссылку. Если кандидата занял параллельный запрос между проверкой и записью,
+// пробуется следующий: пользователь идентификатор не выбирал, и конфликт - не его ошибка.
+func (s *ShortenerService) createWithGeneratedID(link *models.ShortLink) error {
+	for attempt := 0; attempt < maxShortIDAttempts; attempt++ {
+		shortID, err := s.IDGenerator.NextID(attempt)
+		if err != nil {
+			return err
+		}
+		if models.IsReservedShortID(shortID) {
+			continue
+		}
+
+		// Проверка, существует ли уже короткая ссылка с таким идентификатором
+		taken, err := s.ShortenerRepo.IsShortIDTaken(shortID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			continue
+		}
+
+		link.ShortId = shortID
+		err = s.ShortenerRepo.CreateShortLink(link)
+		if errors.Is(err, shortener.ErrShortIDTaken) {
+			continue
+		}
+		return err
+	}
+	return errors.New("failed to generate unique short id")
+}
+
+// Redirect возвращает адрес назначения и код редиректа ссылки (301/302/307/308) и записывает клик.
+// Не требует авторизации, поэтому не проверяет владельца ссылки.
+// Для ссылки с лимитом переход сначала засчитывается в базе; после исчерпания
+// лимита возвращается 410 или редирект на FallbackURL без записи клика.
+// Адрес выбирается по правилам TargetRules (User-Agent и язык), затем по стране
+// посетителя из GeoTargets, затем среди вариантов A/B-теста по весам.
+func (s *ShortenerService) Redirect(shortID string, visit VisitInfo) (*Destination, int, error) {
+	shortLink, status, err := s.getRedirectableLink(shortID)
 	if err != nil {
-		return "", 500, err
+		var notActive *NotActiveError
+		if errors.As(err, &notActive) && notActive.FallbackURL != "" {
+			return &Destination{URL: notActive.FallbackURL}, 302, nil
+		}
+		return nil, status, err
 	}
-	if shortLink == nil {
-		return "", 404, nil
+
+	if shortLink.PasswordHash != "" && !s.LinkAccess.Verify(shortLink, visit.AccessToken, time.Now()) {
+		return nil, 401, ErrPasswordRequired
+	}
+
+	// Предпросмотр показывается до списания перехода: клик засчитывается после "continue".
+	// Отметка continue служебная только для таких ссылок, у остальных её не трогаем.
+	if shortLink.Interstitial {
+		var confirmed bool
+		visit.Query, confirmed = models.StripMarker(visit.Query, models.ContinueParam)
+		if !confirmed {
+			preview, status, err := s.newPreview(shortLink, visit, true)
+			if err != nil {
+				return nil, status, err
+			}
+			return &Destination{Preview: preview}, 200, nil
+		}
 	}
 
-	if shortLink.ExpiresAt != nil && shortLink.ExpiresAt.Before(time.Now()) {
-		return "", 404, nil
+	if shortLink.MaxClicks > 0 {
+		ok, err := s.ShortenerRepo.ConsumeClick(shortLink.ID)
+		if err != nil {
+			return nil, 500, err
+		}
+		if !ok {
+			if shortLink.FallbackURL != "" {
+				return &Destination{URL: shortLink.FallbackURL}, 302, nil
+			}
+			return nil, 410, ErrLinkExhausted
+		}
 	}
 
-	shortLink.UpdateClicks()
-	err = s.ShortenerRepo.UpdateShortLink(shortLink)
+	visitor := s.newVisitor(shortLink, visit)
+	target, rule, variant := shortLink.Target(visitor)
+
+	// Клик пишется в базу асинхронно; если буфер переполнен, редирект всё равно выполняется
+	s.ClickRecorder.Record(newClickEvent(shortLink, visit, visitor, rule, variant))
+
+	return &Destination{
+		URL:     shortLink.DestinationURLFor(target, visit.Query),
+		Variant: variant,
+		Sticky:  variant != "" && shortLink.StickyVariants,
+	}, shortLink.RedirectStatus(), nil
+}
+
+// getRedirectableLink находит ссылку для публичного перехода: существующую и не истёкшую.
+// Для истёкшей ссылки возвращает 410 и ErrLinkExpired, для ещё не активной - 404
+// и NotActiveError, если её pending_mode не скрывает ссылку.
+func (s *ShortenerService) getRedirectableLink(shortID string) (*models.ShortLink, int, error) {
+	if models.IsReservedShortID(shortID) {
+		return nil, 404, errors.New("link not found")
+	}
+
+	shortLink, err := s.ShortenerRepo.ResolveShortLink(shortID)
 	if err != nil {
-		return "", 500, err
+		return nil, 500, err
 	}
+	if shortLink == nil {
+		return nil, 404, errors.New("link not found")
+	}
+
+	now := time.Now()
+	if shortLink.ExpiresAt != nil && shortLink.ExpiresAt.Before(now) {
+		return nil, 410, ErrLinkExpired
+	}
+	if !shortLink.IsActiveAt(now) {
+		switch shortLink.PendingMode {
+		case models.PendingComingSoon:
+			return nil, 404, &NotActiveError{ActiveFrom: *shortLink.ActiveFrom}
+		case models.PendingRedirect:
+			return nil, 404, &NotActiveError{ActiveFrom: *shortLink.ActiveFrom, FallbackURL: shortLink.PendingURL}
+		default:
+			return nil, 404, errors.New("link not found")
+		}
+	}
+	return shortLink, 200, nil
+}
 
-	return shortLink.LongLink, 200, nil
+// newVisitor разбирает User-Agent и Accept-Language, определяет страну по IP
+// и назначает вариант A/B-теста
+func (s *ShortenerService) newVisitor(link *models.ShortLink, visit VisitInfo) models.Visitor {
+	ua := utils.ParseUserAgent(visit.UserAgent)
+	visitor := models.Visitor{
+		OS:       ua.OS,
+		Device:   ua.Device,
+		Browser:  ua.Browser,
+		Language: utils.PrimaryLanguage(visit.AcceptLanguage),
+		Variant:  assignVariant(link, visit.Variant),
+	}
+	if s.GeoIP != nil {
+		visitor.Country = s.GeoIP.Country(visit.IP)
+	}
+	return visitor
+}
+
+// assignVariant возвращает вариант, закреплённый за посетителем, если ссылка закрепляет
+// варианты и он ещё есть, иначе случайный с учётом весов
+func assignVariant(link *models.ShortLink, assigned string) string {
+	if len(link.Variants) == 0 {
+		return ""
+	}
+	if link.StickyVariants && link.Variants.Find(assigned) != nil {
+		return assigned
+	}
+	return link.Variants.Pick(rand.IntN(link.Variants.TotalWeight())).Name
+}
+
+// newClickEvent собирает событие клика из данных запроса
+func newClickEvent(link *models.ShortLink, visit VisitInfo, visitor models.Visitor, rule, variant string) models.ClickEvent {
+	return models.ClickEvent{
+		LinkID:    link.ID,
+		CreatedAt: time.Now(),
+		Referrer:  utils.ReferrerHost(visit.Referrer),
+		Browser:   visitor.Browser,
+		OS:        visitor.OS,
+		Device:    visitor.Device,
+		IPHash:    utils.HashIP(visit.IP),
+		Language:  visitor.Language,
+		Country:   visitor.Country,
+		Rule:      rule,
+		Variant:   variant,
+		Source:    visit.Source,
+	}
 }
@@ -0,0 +1,199 @@
+package shortener
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxTimeSeriesBuckets ограничивает размер ответа (например, ~41 день по часам)
+	maxTimeSeriesBuckets = 1000
+	// breakdownLimit - сколько самых частых значений возвращать в каждом разрезе
+	breakdownLimit = 10
+)
+
+// TimeSeriesParams - параметры запроса статистики в том виде, в котором они пришли от клиента
+type TimeSeriesParams struct {
+	From     string // RFC3339 или YYYY-MM-DD в часовом поясе Timezone
+	To       string
+	Interval string // hour, day или week
+	Timezone string // имя из базы IANA, например Europe/Moscow
+}
+
+// GetLinkTimeSeries возвращает количество кликов по интервалам и разрезы по источникам
+// владельцу ссылки или пользователю с доступом.
+func (s *ShortenerService) GetLinkTimeSeries(shortID string, userId *uuid.UUID, params TimeSeriesParams) (*models.ClickTimeSeries, int, error) {
+	link, status, err := s.getReadableLink(shortID, userId)
+	if err != nil {
+		return nil, status, err
+	}
+
+	interval := params.Interval
+	if interval == "" {
+		interval = models.IntervalDay
+	}
+	if interval != models.IntervalHour && interval != models.IntervalDay && interval != models.IntervalWeek {
+		return nil, 400, errors.New("interval must be one of hour, day, week")
+	}
+
+	timezone := params.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, 400, errors.New("unknown timezone " + timezone)
+	}
+
+	to := time.Now().In(loc)
+	if params.To != "" {
+		to, err = parseStatsTime(params.To, loc)
+		if err != nil {
+			return nil, 400, errors.New("invalid to: " + err.Error())
+		}
+	}
+	from := defaultStatsFrom(to, interval)
+	if params.From != "" {
+		from, err = parseStatsTime(params.From, loc)
+		if err != nil {
+			return nil, 400, errors.New("invalid from: " + err.Error())
+		}
+	}
+	if !from.Before(to) {
+		return nil, 400, errors.New("from must be before to")
+	}
+
+	// Выравниваем начало по границе интервала, чтобы первый интервал был полным
+	from = models.TruncateToInterval(from, interval)
+
+	var starts []time.Time
+	for b := from; b.Before(to); b = models.NextInterval(b, interval) {
+		starts = append(starts, b)
+		if len(starts) > maxTimeSeriesBuckets {
+			return nil, 400, errors.New("too many buckets, use a shorter period or a longer interval")
+		}
+	}
+
+	rows, err := s.ShortenerRepo.GetClickTimeSeries(link.ID, from, to, interval, loc.String())
+	if err != nil {
+		return nil, 500, err
+	}
+
+	// Репозиторий возвращает локальное время пояса, сопоставляем по нему
+	const wallClock = "2006-01-02 15:04"
+	byStart := make(map[string]models.ClickBucket, len(rows))
+	for _, row := range rows {
+		byStart[row.Time.Format(wallClock)] = row
+	}
+
+	result := &models.ClickTimeSeries{
+		From:       from,
+		To:         to,
+		Interval:   interval,
+		Timezone:   loc.String(),
+		Buckets:    make([]models.ClickBucket, 0, len(starts)),
+		Breakdowns: make(map[string][]models.ClickBreakdownItem, len(models.ClickBreakdownFields)),
+	}
+	for _, start := range starts {
+		// При переводе часов назад час повторяется, а база объединяет оба в одну строку:
+		// отдаём её первому из интервалов, чтобы не посчитать клики дважды
+		key := start.Format(wallClock)
+		row := byStart[key]
+		delete(byStart, key)
+		result.Total += row.Clicks
+		result.Buckets = append(result.Buckets, models.ClickBucket{
+			Time:     start,
+			Clicks:   row.Clicks,
+			Visitors: row.Visitors,
+		})
+	}
+
+	for name, column := range models.ClickBreakdownFields {
+		items, err := s.ShortenerRepo.GetClickBreakdown(link.ID, from, to, column, breakdownLimit)
+		if err != nil {
+			return nil, 500, err
+		}
+		// Клики без сработавшего правила ушли на адрес по умолчанию, без варианта - мимо A/B-теста
+		empty := "unknown"
+		switch name {
+		case "rule":
+			empty = "default"
+		case "variant":
+			empty = "none"
+		case "source":
+			empty = "link"
+		}
+		for i := range items {
+			if items[i].Value == "" {
+				items[i].Value = empty
+			}
+		}
+		result.Breakdowns[name] = items
+	}
+
+	if len(link.Variants) > 0 {
+		rows, err := s.ShortenerRepo.GetVariantClicks(link.ID, from, to)
+		if err != nil {
+			return nil, 500, err
+		}
+		result.Variants = variantStats(link.Variants, rows)
+	}
+
+	return result, 200, nil
+}
+
+// variantStats сводит клики по вариантам с текущими вариантами ссылки.
+// Клики по удалённым вариантам остаются только в разрезе variant.
+func variantStats(variants models.Variants, rows []models.VariantClicks) []models.VariantClicks {
+	byName := make(map[string]models.VariantClicks, len(rows))
+	for _, row := range rows {
+		byName[row.Name] = row
+	}
+
+	stats := make([]models.VariantClicks, 0, len(variants))
+	var total int64
+	for _, variant := range variants {
+		row := byName[variant.Name]
+		total += row.Clicks
+		stats = append(stats, models.VariantClicks{
+			Name:     variant.Name,
+			URL:      variant.URL,
+			Weight:   variant.Weight,
+			Clicks:   row.Clicks,
+			Visitors: row.Visitors,
+		})
+	}
+	if total > 0 {
+		for i := range stats {
+			stats[i].Share = float64(stats[i].Clicks) / float64(total)
+		}
+	}
+	return stats
+}
+
+// parseStatsTime разбирает RFC3339 или дату YYYY-MM-DD в часовом поясе loc
+func parseStatsTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, errors.New("expected RFC3339 or YYYY-MM-DD")
+	}
+	return t, nil
+}
+
+// defaultStatsFrom - период по умолчанию: сутки по часам, 30 дней по дням, 12 недель по неделям
+func defaultStatsFrom(to time.Time, interval string) time.Time {
+	switch interval {
+	case models.IntervalHour:
+		return to.Add(-24 * time.Hour)
+	case models.IntervalWeek:
+		return to.AddDate(0, 0, -7*12)
+	default:
+		return to.AddDate(0, 0, -30)
+	}
+}
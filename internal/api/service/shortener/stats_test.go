@@ -0,0 +1,120 @@
+package shortener_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsRepo отдаёт заранее посчитанные интервалы, как GetClickTimeSeries репозитория:
+// локальное время пояса, помеченное UTC, только непустые интервалы
+type statsRepo struct {
+	*memoryRepo
+	buckets []models.ClickBucket
+}
+
+func (r *statsRepo) GetClickTimeSeries(linkId *uuid.UUID, from, to time.Time, interval, timezone string) ([]models.ClickBucket, error) {
+	return r.buckets, nil
+}
+
+func (r *statsRepo) GetClickBreakdown(linkId *uuid.UUID, from, to time.Time, column string, limit int) ([]models.ClickBreakdownItem, error) {
+	return nil, nil
+}
+
+func wallClock(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestGetLinkTimeSeriesBuckets(t *testing.T) {
+	users := newMemoryUsers("alice")
+	alice := users.users["alice"]
+
+	tests := []struct {
+		name    string
+		params  shortener.TimeSeriesParams
+		buckets []models.ClickBucket
+		// ожидаемые начала интервалов в формате 2006-01-02 15:04 -07:00 и клики в них
+		want  []string
+		total int64
+	}{
+		{
+			name:    "empty days are filled with zeros",
+			params:  shortener.TimeSeriesParams{From: "2024-03-01", To: "2024-03-05", Interval: "day", Timezone: "Europe/Moscow"},
+			buckets: []models.ClickBucket{{Time: wallClock(2024, 3, 2, 0), Clicks: 3, Visitors: 2}, {Time: wallClock(2024, 3, 4, 0), Clicks: 1, Visitors: 1}},
+			want:    []string{"2024-03-01 00:00 +03:00 0", "2024-03-02 00:00 +03:00 3", "2024-03-03 00:00 +03:00 0", "2024-03-04 00:00 +03:00 1"},
+			total:   4,
+		},
+		{
+			name:    "weeks start on monday",
+			params:  shortener.TimeSeriesParams{From: "2024-03-06", To: "2024-03-20", Interval: "week", Timezone: "UTC"},
+			buckets: []models.ClickBucket{{Time: wallClock(2024, 3, 11, 0), Clicks: 5}},
+			want:    []string{"2024-03-04 00:00 +00:00 0", "2024-03-11 00:00 +00:00 5", "2024-03-18 00:00 +00:00 0"},
+			total:   5,
+		},
+		{
+			name:    "spring dst skips the missing hour",
+			params:  shortener.TimeSeriesParams{From: "2024-03-31T00:00:00+01:00", To: "2024-03-31T04:00:00+02:00", Interval: "hour", Timezone: "Europe/Berlin"},
+			buckets: []models.ClickBucket{{Time: wallClock(2024, 3, 31, 3), Clicks: 2}},
+			want:    []string{"2024-03-31 00:00 +01:00 0", "2024-03-31 01:00 +01:00 0", "2024-03-31 03:00 +02:00 2"},
+			total:   2,
+		},
+		{
+			// база объединяет оба часа 02:00 в одну строку, она не должна попасть в ряд дважды
+			name:    "autumn dst repeats the hour once",
+			params:  shortener.TimeSeriesParams{From: "2024-10-27T01:00:00+02:00", To: "2024-10-27T03:00:00+01:00", Interval: "hour", Timezone: "Europe/Berlin"},
+			buckets: []models.ClickBucket{{Time: wallClock(2024, 10, 27, 2), Clicks: 4}},
+			want:    []string{"2024-10-27 01:00 +02:00 0", "2024-10-27 02:00 +02:00 4", "2024-10-27 02:00 +01:00 0"},
+			total:   4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &statsRepo{
+				memoryRepo: newMemoryRepo(users, &models.ShortLink{ShortId: "promo", LongLink: "https://example.com", UserID: alice.ID}),
+				buckets:    tt.buckets,
+			}
+			service := &shortener.ShortenerService{ShortenerRepo: repo, UserRepo: users}
+
+			series, status, err := service.GetLinkTimeSeries("promo", alice.ID, tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, 200, status)
+			var got []string
+			for _, bucket := range series.Buckets {
+				got = append(got, bucket.Time.Format("2006-01-02 15:04 -07:00 ")+strconv.FormatInt(bucket.Clicks, 10))
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.total, series.Total)
+		})
+	}
+}
+
+func TestGetLinkTimeSeriesRejected(t *testing.T) {
+	users := newMemoryUsers("alice")
+	alice := users.users["alice"]
+	repo := &statsRepo{memoryRepo: newMemoryRepo(users, &models.ShortLink{ShortId: "promo", LongLink: "https://example.com", UserID: alice.ID})}
+	service := &shortener.ShortenerService{ShortenerRepo: repo, UserRepo: users}
+
+	tests := []struct {
+		name   string
+		params shortener.TimeSeriesParams
+	}{
+		{"unknown interval", shortener.TimeSeriesParams{Interval: "month"}},
+		{"unknown timezone", shortener.TimeSeriesParams{Timezone: "Mars/Olympus"}},
+		{"invalid from", shortener.TimeSeriesParams{From: "yesterday"}},
+		{"from after to", shortener.TimeSeriesParams{From: "2024-03-05", To: "2024-03-01"}},
+		{"too many buckets", shortener.TimeSeriesParams{From: "2020-01-01", To: "2024-01-01", Interval: "hour"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, status, err := service.GetLinkTimeSeries("promo", alice.ID, tt.params)
+			assert.Error(t, err)
+			assert.Equal(t, 400, status)
+		})
+	}
+}
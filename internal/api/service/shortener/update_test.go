@@ -0,0 +1,113 @@
+package shortener_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLink(t *testing.T) {
+	users := newMemoryUsers("alice", "bob")
+	alice := users.users["alice"]
+	repo := newMemoryRepo(users,
+		&models.ShortLink{ShortId: "promo", LongLink: "https://example.com", UserID: alice.ID},
+		&models.ShortLink{ShortId: "taken", LongLink: "https://example.com", UserID: alice.ID, IsAlias: true},
+	)
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      users,
+		Expiry:        shortener.NewExpiryPolicy(0, nil),
+	}
+
+	url := "https://example.org/new"
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	link, status, err := service.UpdateLink("promo", alice.ID, shortener.UpdateLinkParams{Url: &url, ExpiresAt: &expiresAt, Version: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, url, link.LongLink)
+	assert.Equal(t, expiresAt, *link.ExpiresAt)
+	assert.Equal(t, 2, link.Version)
+
+	stored, _ := repo.GetShortLinkByShortID("promo")
+	assert.Equal(t, url, stored.LongLink)
+
+	alias := "Spring"
+	link, _, err = service.UpdateLink("promo", alice.ID, shortener.UpdateLinkParams{Alias: &alias, Version: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "spring", link.ShortId)
+	assert.True(t, link.IsAlias)
+}
+
+func TestUpdateLinkRejected(t *testing.T) {
+	users := newMemoryUsers("alice", "bob")
+	alice := users.users["alice"]
+	repo := newMemoryRepo(users,
+		&models.ShortLink{ShortId: "promo", LongLink: "https://example.com", UserID: alice.ID, Version: 3},
+		&models.ShortLink{ShortId: "taken", LongLink: "https://example.com", UserID: alice.ID, IsAlias: true},
+	)
+	service := &shortener.ShortenerService{
+		ShortenerRepo: repo,
+		UserRepo:      users,
+		Expiry:        shortener.NewExpiryPolicy(0, nil),
+	}
+	url := "https://example.org"
+	invalid := "not a url"
+	alias := "taken"
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		shortID string
+		user    string
+		params  shortener.UpdateLinkParams
+		status  int
+	}{
+		{"no version", "promo", "alice", shortener.UpdateLinkParams{Url: &url}, 428},
+		{"stale version", "promo", "alice", shortener.UpdateLinkParams{Url: &url, Version: 2}, 412},
+		{"not owner", "promo", "bob", shortener.UpdateLinkParams{Url: &url, Version: 3}, 403},
+		{"unknown link", "missing", "alice", shortener.UpdateLinkParams{Url: &url, Version: 1}, 404},
+		{"invalid url", "promo", "alice", shortener.UpdateLinkParams{Url: &invalid, Version: 3}, 400},
+		{"expiry in the past", "promo", "alice", shortener.UpdateLinkParams{ExpiresAt: &past, Version: 3}, 400},
+		{"alias taken", "promo", "alice", shortener.UpdateLinkParams{Alias: &alias, Version: 3}, 409},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, status, err := service.UpdateLink(tt.shortID, users.users[tt.user].ID, tt.params)
+			assert.Error(t, err)
+			assert.Equal(t, tt.status, status)
+		})
+	}
+
+	stored, _ := repo.GetShortLinkByShortID("promo")
+	assert.Equal(t, "https://example.com", stored.LongLink)
+	assert.Equal(t, 3, stored.Version)
+}
+
+// concurrentRepo меняет ссылку между чтением и записью, как параллельная правка
+type concurrentRepo struct {
+	*memoryRepo
+}
+
+func (r concurrentRepo) UpdateShortLinkVersioned(link *models.ShortLink, expectedVersion int, revision *models.LinkRevision) (bool, error) {
+	r.links[*link.ID].Version++
+	return r.memoryRepo.UpdateShortLinkVersioned(link, expectedVersion, revision)
+}
+
+func TestUpdateLinkConcurrentEdit(t *testing.T) {
+	users := newMemoryUsers("alice")
+	alice := users.users["alice"]
+	repo := newMemoryRepo(users, &models.ShortLink{ShortId: "promo", LongLink: "https://example.com", UserID: alice.ID})
+	service := &shortener.ShortenerService{ShortenerRepo: concurrentRepo{repo}, UserRepo: users}
+
+	// версия совпала при чтении, но к записи ссылку уже изменили
+	url := "https://example.org"
+	_, status, err := service.UpdateLink("promo", alice.ID, shortener.UpdateLinkParams{Url: &url, Version: 1})
+	assert.Error(t, err)
+	assert.Equal(t, 412, status)
+	stored, _ := repo.GetShortLinkByShortID("promo")
+	assert.Equal(t, "https://example.com", stored.LongLink)
+}
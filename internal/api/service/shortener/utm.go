@@ -0,0 +1,116 @@
+package shortener
+
+import (
+	"errors"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+)
+
+// UTMPatch - изменяемые метки ссылки. nil - метка не меняется, пустая строка - удаляется.
+type UTMPatch struct {
+	Source   *string
+	Medium   *string
+	Campaign *string
+	Term     *string
+	Content  *string
+}
+
+// ApplyTo меняет метки utm согласно патчу
+func (p UTMPatch) ApplyTo(utm *models.UTMParams) {
+	set := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	set(&utm.Source, p.Source)
+	set(&utm.Medium, p.Medium)
+	set(&utm.Campaign, p.Campaign)
+	set(&utm.Term, p.Term)
+	set(&utm.Content, p.Content)
+}
+
+// resolveUTM объединяет метки шаблона пользователя с явно переданными: явные важнее
+func (s *ShortenerService) resolveUTM(userId *uuid.UUID, templateName string, utm models.UTMParams) (models.UTMParams, int, error) {
+	if templateName != "" {
+		template, err := s.ShortenerRepo.GetUTMTemplate(userId, templateName)
+		if err != nil {
+			return utm, 500, err
+		}
+		if template == nil {
+			return utm, 404, errors.New("utm template not found")
+		}
+		utm = template.UTM.Merge(utm)
+	}
+	if err := utm.Normalize(); err != nil {
+		return utm, 400, err
+	}
+	return utm, 200, nil
+}
+
+func (s *ShortenerService) GetUTMTemplates(userId *uuid.UUID) ([]models.UTMTemplate, int, error) {
+	templates, err := s.ShortenerRepo.GetUTMTemplates(userId)
+	if err != nil {
+		return nil, 500, err
+	}
+	return templates, 200, nil
+}
+
+// CreateUTMTemplate сохраняет набор меток под именем, уникальным для пользователя
+func (s *ShortenerService) CreateUTMTemplate(userId *uuid.UUID, name string, utm models.UTMParams) (*models.UTMTemplate, int, error) {
+	template := &models.UTMTemplate{UserID: userId, Name: name, UTM: utm}
+	if err := template.Validate(); err != nil {
+		return nil, 400, err
+	}
+
+	existing, err := s.ShortenerRepo.GetUTMTemplate(userId, template.Name)
+	if err != nil {
+		return nil, 500, err
+	}
+	if existing != nil {
+		return nil, 409, errors.New("utm template with this name already exists")
+	}
+
+	err = s.ShortenerRepo.CreateUTMTemplate(template)
+	if err != nil {
+		return nil, 500, err
+	}
+	return template, 200, nil
+}
+
+// UpdateUTMTemplate заменяет метки шаблона. Ссылки, созданные по нему раньше, не меняются.
+func (s *ShortenerService) UpdateUTMTemplate(userId *uuid.UUID, name string, utm models.UTMParams) (*models.UTMTemplate, int, error) {
+	template, err := s.ShortenerRepo.GetUTMTemplate(userId, name)
+	if err != nil {
+		return nil, 500, err
+	}
+	if template == nil {
+		return nil, 404, errors.New("utm template not found")
+	}
+
+	template.UTM = utm
+	if err := template.Validate(); err != nil {
+		return nil, 400, err
+	}
+	err = s.ShortenerRepo.UpdateUTMTemplate(template)
+	if err != nil {
+		return nil, 500, err
+	}
+	return template, 200, nil
+}
+
+func (s *ShortenerService) DeleteUTMTemplate(userId *uuid.UUID, name string) (int, error) {
+	template, err := s.ShortenerRepo.GetUTMTemplate(userId, name)
+	if err != nil {
+		return 500, err
+	}
+	if template == nil {
+		return 404, errors.New("utm template not found")
+	}
+
+	err = s.ShortenerRepo.DeleteUTMTemplate(template.ID)
+	if err != nil {
+		return 500, err
+	}
+	return 200, nil
+}
@@ -0,0 +1,52 @@
+package shortener_test
+
+import (
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectVariants(t *testing.T) {
+	repo := &linkRepo{links: map[string]*models.ShortLink{
+		"ab": {
+			ShortId:  "ab",
+			LongLink: "https://example.com",
+			Variants: models.Variants{
+				{Name: "A", URL: "https://example.com/a", Weight: 3},
+				{Name: "B", URL: "https://example.com/b", Weight: 1},
+			},
+		},
+	}}
+	clicks := &clickLog{}
+	service := &shortener.ShortenerService{ShortenerRepo: repo, ClickRecorder: clicks}
+
+	// трафик делится по весам, без sticky_variants cookie не учитывается
+	urls := map[string]string{"A": "https://example.com/a", "B": "https://example.com/b"}
+	const visits = 2000
+	counts := map[string]int{}
+	for i := 0; i < visits; i++ {
+		destination, _, err := service.Redirect("ab", shortener.VisitInfo{Variant: "B"})
+		assert.NoError(t, err)
+		assert.False(t, destination.Sticky)
+		assert.Equal(t, urls[destination.Variant], destination.URL)
+		// вариант попадает в клик для статистики
+		assert.Equal(t, destination.Variant, clicks.events[i].Variant)
+		counts[destination.Variant]++
+	}
+	assert.Equal(t, visits, counts["A"]+counts["B"])
+	assert.InDelta(t, 0.75, float64(counts["A"])/visits, 0.1)
+
+	// с sticky_variants посетитель остаётся на назначенном варианте
+	repo.links["ab"].StickyVariants = true
+	for i := 0; i < 20; i++ {
+		destination, _, _ := service.Redirect("ab", shortener.VisitInfo{Variant: "B"})
+		assert.Equal(t, "https://example.com/b", destination.URL)
+		assert.True(t, destination.Sticky)
+	}
+
+	// вариант из cookie, которого больше нет, назначается заново
+	destination, _, _ := service.Redirect("ab", shortener.VisitInfo{Variant: "C"})
+	assert.Contains(t, []string{"A", "B"}, destination.Variant)
+}
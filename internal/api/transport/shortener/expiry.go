@@ -0,0 +1,79 @@
+package shortener
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Структура запроса для продления ссылки
+type ExtendLinkRequest struct {
+	Duration string `json:"duration,omitempty" example:"720h"` // на сколько продлить, по умолчанию LINK_DEFAULT_TTL
+}
+
+// ExtendLink godoc
+//	@Summary		Extend a shortened link
+//	@Description	Pushes the expiration of a link forward by duration, counting from the current expiration
+//	@Description	or from now if the link has already expired. The result must fit the maximum TTL of the user role.
+//	@Description	Only the link owner can extend it.
+//	@Tags			Shortener
+//	@Accept			json
+//	@Produce		json
+//	@Param			shortID	path	string				true	"Shortened Link ID"
+//	@Param			request	body	ExtendLinkRequest	false	"Extension, Go duration such as 168h"
+//	@Security		BearerAuth
+//	@Success		200	{object}	GetLinkResponse	"Link extended"
+//	@Header			200	{string}	ETag			"New link version"
+//	@Failure		400	{object}	ErrorResponse	"Invalid duration, link never expires or maximum TTL exceeded"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Link belongs to another user"
+//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		412	{object}	ErrorResponse	"Link was modified concurrently"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/{shortID}/extend [post]
+func (sc *ShortenerController) ExtendLink(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	// Тело необязательно: без него ссылка продлевается на срок по умолчанию
+	var req ExtendLinkRequest
+	if ctx.Request.Body != nil && ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(400, gin.H{
+				"error":   err.Error(),
+				"message": "Bad request",
+				"success": false,
+			})
+			return
+		}
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		parsed, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			ctx.JSON(400, gin.H{
+				"error":   "Duration must look like 720h or 90m",
+				"message": "Bad request",
+				"success": false,
+			})
+			return
+		}
+		duration = parsed
+	}
+
+	link, status, err := sc.ShortenerService.ExtendLink(ctx.Param("shortID"), userIDUUID, duration)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.Header("ETag", link.ETag())
+	ctx.JSON(200, gin.H{
+		"link":    link,
+		"message": "Link extended",
+		"success": true,
+	})
+}
@@ -0,0 +1,35 @@
+package shortener
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// GetBrokenLinks godoc
+//	@Summary		Get links with broken destinations
+//	@Description	Retrieves links of the authenticated user whose destination failed the periodic health check
+//	@Description	(no response, 404, 410 or 5xx) several times in a row. The health field holds the last check result.
+//	@Tags			Shortener
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	GetLinksResponse	"Links retrieved successfully"
+//	@Failure		401	{object}	ErrorResponse		"Unauthorized"
+//	@Failure		500	{object}	ErrorResponse		"Internal server error"
+//	@Router			/shortener/broken [get]
+func (sc *ShortenerController) GetBrokenLinks(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	links, status, err := sc.ShortenerService.GetBrokenLinks(userIDUUID)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"links":   links,
+		"message": "Links found",
+		"success": true,
+	})
+}
@@ -0,0 +1,89 @@
+package shortener
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLinkHistory godoc
+//	@Summary		Get link revision history
+//	@Description	Retrieves all changes of the link (destination, alias, expiration), newest first.
+//	@Description	Available to the link owner and to users the link is shared with.
+//	@Tags			History
+//	@Param			shortID	path	string	true	"Shortened Link ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"History retrieved successfully"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"No access to the link"
+//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/{shortID}/history [get]
+func (sc *ShortenerController) GetLinkHistory(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	revisions, status, err := sc.ShortenerService.GetLinkHistory(ctx.Param("shortID"), userIDUUID)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"history": revisions,
+		"message": "History found",
+		"success": true,
+	})
+}
+
+// RestoreLinkRevision godoc
+//	@Summary		Roll back a link to a previous revision
+//	@Description	Restores every editable field the link had after the given revision: destination, alias,
+//	@Description	expiration and activation window, redirect code, UTM, password, click limit, targeting rules,
+//	@Description	variants and tags. Click counters are kept. The rollback itself is recorded as a new revision.
+//	@Description	Only the link owner can restore.
+//	@Tags			History
+//	@Param			shortID	path	string	true	"Shortened Link ID"
+//	@Param			rev		path	int		true	"Revision number"
+//	@Security		BearerAuth
+//	@Success		200	{object}	GetLinkResponse	"Link restored"
+//	@Header			200	{string}	ETag			"New link version"
+//	@Failure		400	{object}	ErrorResponse	"Invalid revision number, or its expiration or activation window is no longer valid"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Link belongs to another user"
+//	@Failure		404	{object}	ErrorResponse	"Link or revision not found"
+//	@Failure		409	{object}	ErrorResponse	"Short ID of the revision is taken or the revision has no full snapshot"
+//	@Failure		412	{object}	ErrorResponse	"Link was modified concurrently"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/{shortID}/history/{rev}/restore [post]
+func (sc *ShortenerController) RestoreLinkRevision(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	revision, err := strconv.Atoi(ctx.Param("rev"))
+	if err != nil || revision <= 0 {
+		ctx.JSON(400, gin.H{
+			"error":   "Revision must be a positive number",
+			"message": "Bad request",
+			"success": false,
+		})
+		return
+	}
+
+	link, status, err := sc.ShortenerService.RestoreLinkRevision(ctx.Param("shortID"), userIDUUID, revision)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.Header("ETag", link.ETag())
+	ctx.JSON(200, gin.H{
+		"link":    link,
+		"message": "Link restored",
+		"success": true,
+	})
+}
@@ -0,0 +1,42 @@
+package shortener
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshLinkMetadata godoc
+//	@Summary		Refresh destination metadata of a link
+//	@Description	Fetches the destination page again and stores its title, description, favicon URL and og:image on the link.
+//	@Description	Metadata is also fetched in the background after the link is created or its URL changes.
+//	@Description	Destinations in private networks are never requested. Only the link owner can refresh it.
+//	@Tags			Shortener
+//	@Produce		json
+//	@Param			shortID	path	string	true	"Shortened Link ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	GetLinkResponse	"Link metadata refreshed"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Link belongs to another user"
+//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		409	{object}	ErrorResponse	"Link URL changed while fetching"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Failure		502	{object}	ErrorResponse	"Destination page could not be fetched"
+//	@Failure		503	{object}	ErrorResponse	"Metadata fetching is disabled"
+//	@Router			/shortener/{shortID}/metadata [post]
+func (sc *ShortenerController) RefreshLinkMetadata(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	link, status, err := sc.ShortenerService.RefreshLinkMetadata(ctx.Param("shortID"), userIDUUID)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"link":    link,
+		"message": "Link metadata refreshed",
+		"success": true,
+	})
+}
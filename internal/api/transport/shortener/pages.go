@@ -0,0 +1,191 @@
+package shortener
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// HTML-страницы, которые видит посетитель короткой ссылки вместо редиректа
+
+var passwordPage = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+<style>
+body{font-family:system-ui,sans-serif;display:flex;justify-content:center;padding-top:15vh;margin:0;background:#f6f7f9}
+form{background:#fff;padding:2rem;border-radius:8px;box-shadow:0 1px 4px rgba(0,0,0,.1);width:18rem}
+input,button{box-sizing:border-box;width:100%;padding:.6rem;margin-top:.8rem;font-size:1rem}
+.error{color:#b00020}
+</style>
+</head>
+<body>
+<form method="post">
+<h1>Password required</h1>
+<p>This link is protected. Enter the password to continue.</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<input type="password" name="password" autocomplete="current-password" required autofocus>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+var expiredPage = template.Must(template.New("expired").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Link expired</title>
+<style>
+body{font-family:system-ui,sans-serif;display:flex;justify-content:center;padding-top:15vh;margin:0;background:#f6f7f9}
+main{background:#fff;padding:2rem;border-radius:8px;box-shadow:0 1px 4px rgba(0,0,0,.1);width:22rem}
+</style>
+</head>
+<body>
+<main>
+<h1>Link expired</h1>
+<p>This short link has expired and no longer leads anywhere.</p>
+<p>If you need it, ask the person who shared it for a new link.</p>
+</main>
+</body>
+</html>
+`))
+
+var comingSoonPage = template.Must(template.New("coming_soon").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Coming soon</title>
+<style>
+body{font-family:system-ui,sans-serif;display:flex;justify-content:center;padding-top:15vh;margin:0;background:#f6f7f9}
+main{background:#fff;padding:2rem;border-radius:8px;box-shadow:0 1px 4px rgba(0,0,0,.1);width:22rem}
+</style>
+</head>
+<body>
+<main>
+<h1>Coming soon</h1>
+<p>This link is not active yet. It will open on <time datetime="{{.ISO}}">{{.Human}}</time>.</p>
+</main>
+</body>
+</html>
+`))
+
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Link preview</title>
+<style>
+body{font-family:system-ui,sans-serif;display:flex;justify-content:center;padding-top:15vh;margin:0;background:#f6f7f9}
+main{background:#fff;padding:2rem;border-radius:8px;box-shadow:0 1px 4px rgba(0,0,0,.1);width:28rem;max-width:90vw}
+.url{word-break:break-all;background:#f6f7f9;padding:.6rem;border-radius:4px}
+.meta{color:#555;font-size:.9rem}
+a.button{display:block;text-align:center;margin-top:1.2rem;padding:.6rem;font-size:1rem;background:#1a73e8;color:#fff;border-radius:4px;text-decoration:none}
+</style>
+</head>
+<body>
+<main>
+<h1>Where this link goes</h1>
+{{if .Destination}}
+{{if .Title}}<p><strong>{{.Title}}</strong></p>{{end}}
+<p class="url">{{.Destination}}</p>
+<p>Domain: <strong>{{.Host}}</strong></p>
+{{else if .Alternatives}}
+<p>This link sends visitors to one of these pages, chosen when you continue:</p>
+{{range .Alternatives}}<p class="url">{{.}}</p>
+{{end}}
+{{else}}
+<p>This link is password protected. You will be asked for the password to see where it goes.</p>
+{{end}}
+<p class="meta">Created{{if .Creator}} by {{.Creator}}{{end}} on <time datetime="{{.CreatedISO}}">{{.CreatedHuman}}</time></p>
+<a class="button" href="{{.ContinueURL}}" rel="nofollow">Continue</a>
+</main>
+</body>
+</html>
+`))
+
+type previewPageData struct {
+	Destination  string
+	Alternatives []string
+	Host         string
+	Title        string
+	Creator      string
+	CreatedISO   string
+	CreatedHuman string
+	ContinueURL  string
+}
+
+// renderPreview показывает страницу предпросмотра. Кнопка Continue ведёт на ту же
+// короткую ссылку с той же строкой запроса, а для ссылок с interstitial - с отметкой continue.
+func renderPreview(ctx *gin.Context, preview *shortener.LinkPreview) {
+	query, _ := models.StripMarker(ctx.Request.URL.RawQuery, models.ContinueParam)
+	if preview.Interstitial {
+		if query != "" {
+			query += "&"
+		}
+		query += models.ContinueParam + "=1"
+	}
+	continueURL := "/" + preview.ShortID
+	if query != "" {
+		continueURL += "?" + query
+	}
+
+	created := preview.CreatedAt.UTC()
+	data := previewPageData{
+		Destination:  preview.Destination,
+		Alternatives: preview.Alternatives,
+		Title:        preview.Title,
+		Creator:      preview.Creator,
+		CreatedISO:   created.Format(time.RFC3339),
+		CreatedHuman: created.Format("2 January 2006"),
+		ContinueURL:  continueURL,
+	}
+	if destination, err := url.Parse(preview.Destination); err == nil {
+		data.Host = destination.Hostname()
+	}
+	renderPage(ctx, 200, previewPage, data)
+}
+
+type comingSoonPageData struct {
+	ISO   string
+	Human string
+}
+
+func newComingSoonPageData(activeFrom time.Time) comingSoonPageData {
+	activeFrom = activeFrom.UTC()
+	return comingSoonPageData{
+		ISO:   activeFrom.Format(time.RFC3339),
+		Human: activeFrom.Format("2 January 2006, 15:04 MST"),
+	}
+}
+
+type passwordPageData struct {
+	Error string
+}
+
+// renderPage отдаёт HTML-страницу с указанным статусом
+func renderPage(ctx *gin.Context, status int, page *template.Template, data any) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		log.Println("failed to render page:", err)
+		ctx.String(500, "Internal server error")
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,67 @@
+package shortener
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// linkAccessCookie - префикс cookie с токеном доступа к ссылке с паролем; у каждой ссылки свой cookie,
+// см. linkCookieName
+const linkAccessCookie = "link_access"
+
+// linkCookieName - имя cookie ссылки. Cookie ставятся на путь "/", а не на путь ссылки:
+// браузер не отправил бы cookie пути /abc на страницу предпросмотра /abc+. Поэтому ссылка
+// различается по имени cookie. Идентификаторы уникальны без учёта регистра, поэтому имя в нижнем
+// регистре не путает ссылки, а алиас, открытый в другом регистре, получает тот же cookie.
+func linkCookieName(prefix, shortID string) string {
+	return prefix + "_" + strings.ToLower(strings.TrimSuffix(shortID, "+"))
+}
+
+// UnlockLink godoc
+//	@Summary		Unlock a password-protected link
+//	@Description	Public endpoint used by the password form. On success sets a short-lived signed cookie
+//	@Description	scoped to the link and redirects back to it (303). Attempts are rate-limited per link and IP.
+//	@Tags			Shortener
+//	@Accept			x-www-form-urlencoded
+//	@Produce		html
+//	@Param			shortID		path		string	true	"Shortened Link ID"
+//	@Param			password	formData	string	true	"Link password"
+//	@Success		303	{string}	string			"Redirected back to the short link"
+//	@Failure		401	{string}	string			"Invalid password, the form is shown again"
+//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		410	{string}	string			"Link expired page"
+//	@Failure		429	{string}	string			"Too many attempts"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/{shortID} [post]
+func (sc *ShortenerController) UnlockLink(ctx *gin.Context) {
+	shortID := ctx.Param("shortID")
+
+	token, status, err := sc.ShortenerService.UnlockLink(shortID, ctx.PostForm("password"), visitInfo(ctx))
+	if err != nil {
+		var notActive *shortener.NotActiveError
+		switch {
+		case errors.As(err, &notActive):
+			renderPage(ctx, status, comingSoonPage, newComingSoonPageData(notActive.ActiveFrom))
+		case status == 401 || status == 429:
+			renderPage(ctx, status, passwordPage, passwordPageData{Error: err.Error()})
+		case errors.Is(err, shortener.ErrLinkExpired):
+			renderPage(ctx, status, expiredPage, nil)
+		default:
+			respondError(ctx, status, err)
+		}
+		return
+	}
+
+	if token != "" {
+		secure := ctx.Request.TLS != nil || strings.HasPrefix(config.BaseURL, "https://")
+		ctx.SetSameSite(http.SameSiteLaxMode)
+		ctx.SetCookie(linkCookieName(linkAccessCookie, shortID), token, int(sc.AccessCookieTTL.Seconds()), "/", "", secure, true)
+	}
+	// Возвращаемся на короткую ссылку с той же строкой запроса, теперь уже с cookie
+	ctx.Redirect(http.StatusSeeOther, ctx.Request.URL.RequestURI())
+}
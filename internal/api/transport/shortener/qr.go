@@ -0,0 +1,115 @@
+package shortener
+
+import (
+	"strconv"
+
+	"github.com/bigxxby/dream-test-task/internal/config"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/bigxxby/dream-test-task/internal/qr"
+	"github.com/gin-gonic/gin"
+)
+
+// GetLinkQR godoc
+//	@Summary		QR code for a shortened link
+//	@Description	Renders a QR code of the public short URL as PNG or SVG. The encoded URL carries the qr=1 marker,
+//	@Description	so scans are counted as clicks with source "qr" in the stats; the marker is not passed on to the destination.
+//	@Description	Available to the link owner and to users the link is shared with.
+//	@Tags			Shortener
+//	@Produce		png
+//	@Produce		image/svg+xml
+//	@Param			shortID	path	string	true	"Shortened Link ID"
+//	@Param			format	query	string	false	"Image format"	Enums(png, svg)	default(png)
+//	@Param			size	query	int		false	"Width and height in pixels, 64-2048"	default(256)
+//	@Param			margin	query	int		false	"Quiet zone in modules, 0-16"	default(4)
+//	@Param			level	query	string	false	"Error correction level"	Enums(L, M, Q, H)	default(M)
+//	@Param			fg		query	string	false	"Foreground color, RRGGBB or RRGGBBAA"	default(000000)
+//	@Param			bg		query	string	false	"Background color, RRGGBB or RRGGBBAA"	default(ffffff)
+//	@Security		BearerAuth
+//	@Success		200	{file}		file			"QR code image"
+//	@Failure		400	{object}	ErrorResponse	"Invalid format, size, margin, level or color"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"No access to the link"
+//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/{shortID}/qr [get]
+func (sc *ShortenerController) GetLinkQR(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	format := ctx.DefaultQuery("format", "png")
+	if format != "png" && format != "svg" {
+		badQRRequest(ctx, "Format must be png or svg")
+		return
+	}
+	opts, message := qrOptions(ctx)
+	if message != "" {
+		badQRRequest(ctx, message)
+		return
+	}
+
+	link, status, err := sc.ShortenerService.GetLink(ctx.Param("shortID"), userIDUUID)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	code, err := qr.Encode(config.PublicURL(link.ShortId)+"?"+models.QRMarkerParam+"=1", opts)
+	if err != nil {
+		badQRRequest(ctx, err.Error())
+		return
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=300")
+	ctx.Header("Content-Disposition", `inline; filename="`+link.ShortId+"."+format+`"`)
+	if format == "svg" {
+		ctx.Data(200, "image/svg+xml", code.SVG())
+		return
+	}
+	data, err := code.PNG()
+	if err != nil {
+		respondError(ctx, 500, err)
+		return
+	}
+	ctx.Data(200, "image/png", data)
+}
+
+// qrOptions разбирает параметры отрисовки из строки запроса.
+// Возвращает текст ошибки для ответа 400, если параметр неверный.
+func qrOptions(ctx *gin.Context) (qr.Options, string) {
+	opts := qr.DefaultOptions()
+	var err error
+	if value := ctx.Query("size"); value != "" {
+		if opts.Size, err = strconv.Atoi(value); err != nil {
+			return opts, "Size must be a number"
+		}
+	}
+	if value := ctx.Query("margin"); value != "" {
+		if opts.Margin, err = strconv.Atoi(value); err != nil {
+			return opts, "Margin must be a number"
+		}
+	}
+	if value := ctx.Query("level"); value != "" {
+		opts.Level = value
+	}
+	if value := ctx.Query("fg"); value != "" {
+		if opts.Foreground, err = qr.ParseColor(value); err != nil {
+			return opts, err.Error()
+		}
+	}
+	if value := ctx.Query("bg"); value != "" {
+		if opts.Background, err = qr.ParseColor(value); err != nil {
+			return opts, err.Error()
+		}
+	}
+	return opts, ""
+}
+
+func badQRRequest(ctx *gin.Context, message string) {
+	ctx.JSON(400, gin.H{
+		"error":   message,
+		"message": "Bad request",
+		"success": false,
+	})
+}
@@ -0,0 +1,144 @@
+package shortener
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Структура запроса для выдачи доступа к ссылке
+type ShareLinkRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// GetSharedLinks godoc
+//	@Summary		Get links shared with the user
+//	@Description	Retrieves links of other users that the authenticated user has read-only access to.
+//	@Tags			Sharing
+//	@Security		BearerAuth
+//	@Success		200	{object}	GetLinksResponse	"Links retrieved successfully"
+//	@Failure		401	{object}	ErrorResponse		"Unauthorized"
+//	@Failure		500	{object}	ErrorResponse		"Internal server error"
+//	@Router			/shortener/shared [get]
+func (sc *ShortenerController) GetSharedLinks(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	links, status, err := sc.ShortenerService.GetSharedLinks(userIDUUID)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"links":   links,
+		"message": "Links found",
+		"success": true,
+	})
+}
+
+// ShareLink godoc
+//	@Summary		Share link stats with another user
+//	@Description	Grants another user read-only access to the link statistics. Only the link owner can share.
+//	@Tags			Sharing
+//	@Param			shortID	path	string				true	"Shortened Link ID"
+//	@Param			request	body	ShareLinkRequest	true	"User to share the link with"
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"Link shared"
+//	@Failure		400	{object}	ErrorResponse	"Bad request"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Link belongs to another user"
+//	@Failure		404	{object}	ErrorResponse	"Link or user not found"
+//	@Failure		409	{object}	ErrorResponse	"Link already shared with this user"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/{shortID}/shares [post]
+func (sc *ShortenerController) ShareLink(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	var req ShareLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(400, gin.H{
+			"error":   err.Error(),
+			"message": "Bad request",
+			"success": false,
+		})
+		return
+	}
+
+	share, status, err := sc.ShortenerService.ShareLink(ctx.Param("shortID"), userIDUUID, req.Username)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"share":   share,
+		"message": "Link shared",
+		"success": true,
+	})
+}
+
+// GetLinkShares godoc
+//	@Summary		List users the link is shared with
+//	@Description	Retrieves users that have read-only access to the link. Only the link owner can list them.
+//	@Tags			Sharing
+//	@Param			shortID	path	string	true	"Shortened Link ID"
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"Shares retrieved successfully"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Link belongs to another user"
+//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/{shortID}/shares [get]
+func (sc *ShortenerController) GetLinkShares(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	shares, status, err := sc.ShortenerService.GetLinkShares(ctx.Param("shortID"), userIDUUID)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"shares":  shares,
+		"message": "Shares found",
+		"success": true,
+	})
+}
+
+// UnshareLink godoc
+//	@Summary		Revoke shared access to a link
+//	@Description	Revokes read-only access of the given user to the link. Only the link owner can revoke.
+//	@Tags			Sharing
+//	@Param			shortID		path	string	true	"Shortened Link ID"
+//	@Param			username	path	string	true	"Username to revoke access from"
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"Access revoked"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Link belongs to another user"
+//	@Failure		404	{object}	ErrorResponse	"Link or user not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/{shortID}/shares/{username} [delete]
+func (sc *ShortenerController) UnshareLink(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := sc.ShortenerService.UnshareLink(ctx.Param("shortID"), userIDUUID, ctx.Param("username"))
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"message": "Access revoked",
+		"success": true,
+	})
+}
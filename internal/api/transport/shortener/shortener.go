@@ -1,7 +1,15 @@
 package shortener
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/config"
+	"github.com/bigxxby/dream-test-task/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -21,7 +29,46 @@ type ErrorResponse struct {
 
 // Структура запроса для создания короткой ссылки
 type CreateShortLinkRequest struct {
-	Url string `json:"url" binding:"required"`
+	Url            string              `json:"url" binding:"required"`
+	Alias          string              `json:"alias,omitempty" example:"spring-sale"`                         // 3-16 символов: a-z, 0-9, "-", "_"
+	ExpiresAt      *time.Time          `json:"expires_at,omitempty"`                                          // по умолчанию LINK_DEFAULT_TTL
+	NeverExpires   bool                `json:"never_expires,omitempty"`                                       // бессрочная ссылка, если роль это разрешает
+	ActiveFrom     *time.Time          `json:"active_from,omitempty"`                                         // ссылка начнёт работать с этого момента
+	PendingMode    string              `json:"pending_mode,omitempty" enums:"not_found,coming_soon,redirect"` // поведение до active_from
+	PendingURL     string              `json:"pending_url,omitempty"`                                         // для pending_mode = redirect
+	RedirectCode   int                 `json:"redirect_code,omitempty" enums:"301,302,307,308"`               // по умолчанию 302
+	ForwardQuery   bool                `json:"forward_query,omitempty"`                                       // добавлять параметры запроса к адресу назначения
+	UTMTemplate    string              `json:"utm_template,omitempty" example:"newsletter"`                   // имя шаблона меток, явные метки важнее
+	Password       string              `json:"password,omitempty"`                                            // пароль на переход, 4-72 символа
+	MaxClicks      int                 `json:"max_clicks,omitempty" example:"100"`                            // лимит переходов, 0 - без ограничения
+	OneTime        bool                `json:"one_time,omitempty"`                                            // одноразовая ссылка, то же что max_clicks = 1
+	FallbackURL    string              `json:"fallback_url,omitempty"`                                        // куда вести после исчерпания лимита вместо 410
+	TargetRules    []models.TargetRule `json:"target_rules,omitempty"`                                        // правила по устройству и языку, проверяются до geo_targets
+	GeoTargets     map[string]string   `json:"geo_targets,omitempty"`                                         // код страны ISO 3166-1 -> адрес, остальным - url
+	Variants       []models.Variant    `json:"variants,omitempty"`                                            // A/B-тест: взвешенные адреса вместо url
+	StickyVariants bool                `json:"sticky_variants,omitempty"`                                     // закреплять вариант за посетителем cookie
+	Interstitial   bool                `json:"interstitial,omitempty"`                                        // показывать страницу предпросмотра вместо редиректа
+	Tags           []string            `json:"tags,omitempty" example:"promo,spring"`                         // метки для фильтрации списка ссылок
+	UTMFields
+}
+
+// Метки кампании, которые добавляются к адресу назначения при редиректе
+type UTMFields struct {
+	UTMSource   string `json:"utm_source,omitempty" example:"newsletter"`
+	UTMMedium   string `json:"utm_medium,omitempty" example:"email"`
+	UTMCampaign string `json:"utm_campaign,omitempty" example:"spring_sale"`
+	UTMTerm     string `json:"utm_term,omitempty"`
+	UTMContent  string `json:"utm_content,omitempty"`
+}
+
+func (f UTMFields) params() models.UTMParams {
+	return models.UTMParams{
+		Source:   f.UTMSource,
+		Medium:   f.UTMMedium,
+		Campaign: f.UTMCampaign,
+		Term:     f.UTMTerm,
+		Content:  f.UTMContent,
+	}
 }
 
 // Ответ для создания короткой ссылки
@@ -31,6 +78,35 @@ type CreateShortLinkResponse struct {
 	Success   bool   `json:"success"`
 }
 
+// Структура запроса для изменения ссылки. Передаются только изменяемые поля.
+type UpdateShortLinkRequest struct {
+	Url            *string              `json:"url,omitempty"`
+	Alias          *string              `json:"alias,omitempty"`
+	ExpiresAt      *time.Time           `json:"expires_at,omitempty"`
+	NeverExpires   bool                 `json:"never_expires,omitempty"` // снять срок действия
+	ActiveFrom     *time.Time           `json:"active_from,omitempty"`
+	ActivateNow    bool                 `json:"activate_now,omitempty"` // снять окно активации
+	PendingMode    *string              `json:"pending_mode,omitempty" enums:"not_found,coming_soon,redirect"`
+	PendingURL     *string              `json:"pending_url,omitempty"`
+	RedirectCode   *int                 `json:"redirect_code,omitempty" enums:"301,302,307,308"`
+	ForwardQuery   *bool                `json:"forward_query,omitempty"`
+	UTMSource      *string              `json:"utm_source,omitempty"` // пустая строка удаляет метку
+	UTMMedium      *string              `json:"utm_medium,omitempty"`
+	UTMCampaign    *string              `json:"utm_campaign,omitempty"`
+	UTMTerm        *string              `json:"utm_term,omitempty"`
+	UTMContent     *string              `json:"utm_content,omitempty"`
+	Password       *string              `json:"password,omitempty"`     // пустая строка снимает пароль
+	MaxClicks      *int                 `json:"max_clicks,omitempty"`   // 0 снимает лимит
+	FallbackURL    *string              `json:"fallback_url,omitempty"` // пустая строка - 410 после исчерпания лимита
+	TargetRules    *[]models.TargetRule `json:"target_rules,omitempty"` // заменяет правила целиком, [] удаляет их
+	GeoTargets     *map[string]string   `json:"geo_targets,omitempty"`  // заменяет правила целиком, {} удаляет их
+	Variants       *[]models.Variant    `json:"variants,omitempty"`     // заменяет варианты целиком, [] удаляет их
+	StickyVariants *bool                `json:"sticky_variants,omitempty"`
+	Interstitial   *bool                `json:"interstitial,omitempty"`
+	Tags           *[]string            `json:"tags,omitempty"`    // заменяет метки целиком, [] удаляет их
+	Version        *int                 `json:"version,omitempty"` // альтернатива заголовку If-Match
+}
+
 // Ответ для получения ссылки
 type GetLinkResponse struct {
 	Link    string `json:"link"`
@@ -40,25 +116,47 @@ type GetLinkResponse struct {
 
 // Ответ для списка ссылок
 type GetLinksResponse struct {
-	Links   []string `json:"links"`
-	Message string   `json:"message"`
-	Success bool     `json:"success"`
+	Links      []models.ShortLink `json:"links"`
+	Pagination models.Pagination  `json:"pagination"`
+	Message    string             `json:"message"`
+	Success    bool               `json:"success"`
 }
 
 type IShortenerController interface {
 	CreateShortLink(ctx *gin.Context)
 	Redirect(ctx *gin.Context)
+	UnlockLink(ctx *gin.Context)
 	GetLinks(ctx *gin.Context)
+	GetBrokenLinks(ctx *gin.Context)
 	GetLink(ctx *gin.Context)
+	GetLinkTimeSeries(ctx *gin.Context)
 	DeleteLink(ctx *gin.Context)
+	UpdateLink(ctx *gin.Context)
+
+	GetSharedLinks(ctx *gin.Context)
+	ShareLink(ctx *gin.Context)
+	GetLinkShares(ctx *gin.Context)
+	UnshareLink(ctx *gin.Context)
+
+	GetLinkHistory(ctx *gin.Context)
+	RestoreLinkRevision(ctx *gin.Context)
+	ExtendLink(ctx *gin.Context)
+	GetLinkQR(ctx *gin.Context)
+	RefreshLinkMetadata(ctx *gin.Context)
+
+	GetUTMTemplates(ctx *gin.Context)
+	CreateUTMTemplate(ctx *gin.Context)
+	UpdateUTMTemplate(ctx *gin.Context)
+	DeleteUTMTemplate(ctx *gin.Context)
 }
 
-func NewShortenerController(shortenerService shortener.IShortenerService) IShortenerController {
-	return &ShortenerController{ShortenerService: shortenerService}
+func NewShortenerController(shortenerService shortener.IShortenerService, accessCookieTTL time.Duration) IShortenerController {
+	return &ShortenerController{ShortenerService: shortenerService, AccessCookieTTL: accessCookieTTL}
 }
 
 type ShortenerController struct {
 	ShortenerService shortener.IShortenerService
+	AccessCookieTTL  time.Duration // срок cookie доступа к ссылке с паролем
 }
 
 // DeleteLink godoc
@@ -69,10 +167,17 @@ type ShortenerController struct {
 //	@Security		BearerAuth
 //	@Success		200	{object}	SuccessResponse	"Link deleted successfully"
 //	@Failure		400	{object}	ErrorResponse	"ShortID is empty or invalid"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Link belongs to another user"
 //	@Failure		404	{object}	ErrorResponse	"Shortened link not found"
 //	@Failure		500	{object}	ErrorResponse	"Internal server error"
 //	@Router			/shortener/{shortID} [delete]
 func (sc *ShortenerController) DeleteLink(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
 	shortID := ctx.Param("shortID")
 	if shortID == "" {
 		ctx.JSON(400, gin.H{
@@ -83,28 +188,111 @@ func (sc *ShortenerController) DeleteLink(ctx *gin.Context) {
 		return
 	}
 
-	status, err := sc.ShortenerService.DeleteLink(shortID)
+	status, err := sc.ShortenerService.DeleteLink(shortID, userIDUUID)
 	if err != nil {
-		switch status {
-		case 404:
-			ctx.JSON(404, gin.H{
-				"error":   err.Error(),
-				"message": "Not found",
-				"success": false,
-			})
-			return
-		case 500:
-			ctx.JSON(500, gin.H{
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"message": "Link deleted",
+		"success": true,
+	})
+}
+
+// UpdateLink godoc
+//	@Summary		Update a shortened link
+//	@Description	Changes the destination URL, alias or expiration of a link. Only the link owner can update it.
+//	@Description	The current link version (ETag from the stats endpoint) must be sent in the If-Match header
+//	@Description	or in the version field, otherwise concurrent edits are rejected with 412.
+//	@Tags			Shortener
+//	@Accept			json
+//	@Produce		json
+//	@Param			shortID		path	string					true	"Shortened Link ID"
+//	@Param			If-Match	header	string					false	"Link version (ETag)"
+//	@Param			request		body	UpdateShortLinkRequest	true	"Fields to update"
+//	@Security		BearerAuth
+//	@Success		200	{object}	GetLinkResponse	"Link updated"
+//	@Header			200	{string}	ETag			"New link version"
+//	@Failure		400	{object}	ErrorResponse	"Invalid URL, alias or expiration"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Link belongs to another user"
+//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		409	{object}	ErrorResponse	"Alias already taken"
+//	@Failure		412	{object}	ErrorResponse	"Link was modified concurrently"
+//	@Failure		428	{object}	ErrorResponse	"Link version is required"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/{shortID} [patch]
+func (sc *ShortenerController) UpdateLink(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateShortLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(400, gin.H{
+			"error":   err.Error(),
+			"message": "Bad request",
+			"success": false,
+		})
+		return
+	}
+
+	params := shortener.UpdateLinkParams{
+		Url:          req.Url,
+		Alias:        req.Alias,
+		ExpiresAt:    req.ExpiresAt,
+		NeverExpires: req.NeverExpires,
+		ActiveFrom:   req.ActiveFrom,
+		ActivateNow:  req.ActivateNow,
+		PendingMode:  req.PendingMode,
+		PendingURL:   req.PendingURL,
+		RedirectCode: req.RedirectCode,
+		ForwardQuery: req.ForwardQuery,
+		UTM: shortener.UTMPatch{
+			Source:   req.UTMSource,
+			Medium:   req.UTMMedium,
+			Campaign: req.UTMCampaign,
+			Term:     req.UTMTerm,
+			Content:  req.UTMContent,
+		},
+		Password:       req.Password,
+		MaxClicks:      req.MaxClicks,
+		FallbackURL:    req.FallbackURL,
+		TargetRules:    (*models.TargetRules)(req.TargetRules),
+		GeoTargets:     (*models.GeoTargets)(req.GeoTargets),
+		Variants:       (*models.Variants)(req.Variants),
+		StickyVariants: req.StickyVariants,
+		Interstitial:   req.Interstitial,
+		Tags:           (*models.Tags)(req.Tags),
+	}
+	if req.Version != nil {
+		params.Version = *req.Version
+	}
+	if ifMatch := ctx.GetHeader("If-Match"); ifMatch != "" {
+		version, err := parseETag(ifMatch)
+		if err != nil {
+			ctx.JSON(400, gin.H{
 				"error":   err.Error(),
-				"message": "Internal server error",
+				"message": "Bad request",
 				"success": false,
 			})
 			return
 		}
+		params.Version = version
+	}
+
+	link, status, err := sc.ShortenerService.UpdateLink(ctx.Param("shortID"), userIDUUID, params)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
 	}
 
+	ctx.Header("ETag", link.ETag())
 	ctx.JSON(200, gin.H{
-		"message": "Link deleted",
+		"link":    link,
+		"message": "Link updated",
 		"success": true,
 	})
 }
@@ -112,15 +300,24 @@ func (sc *ShortenerController) DeleteLink(ctx *gin.Context) {
 // GetLink godoc
 //	@Summary		Get original link stats from short URL
 //	@Description	Retrieves the original URL statistics based on the provided shortened link ID.
+//	@Description	Available to the link owner and to users the link is shared with.
 //	@Tags			Shortener
 //	@Param			shortID	path	string	true	"Shortened Link ID"
 //	@Security		BearerAuth
 //	@Success		200	{object}	GetLinkResponse	"Link stats retrieved successfully"
+//	@Header			200	{string}	ETag			"Current link version"
 //	@Failure		400	{object}	ErrorResponse	"Invalid ShortID"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"No access to the link"
 //	@Failure		404	{object}	ErrorResponse	"Link not found"
 //	@Failure		500	{object}	ErrorResponse	"Internal server error"
 //	@Router			/shortener/stats/{shortID} [get]
 func (sc *ShortenerController) GetLink(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
 	shortID := ctx.Param("shortID")
 	if shortID == "" {
 		ctx.JSON(400, gin.H{
@@ -131,26 +328,13 @@ func (sc *ShortenerController) GetLink(ctx *gin.Context) {
 		return
 	}
 
-	link, status, err := sc.ShortenerService.GetLink(shortID)
+	link, status, err := sc.ShortenerService.GetLink(shortID, userIDUUID)
 	if err != nil {
-		switch status {
-		case 404:
-			ctx.JSON(404, gin.H{
-				"error":   err.Error(),
-				"message": "Not found",
-				"success": false,
-			})
-			return
-		case 500:
-			ctx.JSON(500, gin.H{
-				"error":   err.Error(),
-				"message": "Internal server error",
-				"success": false,
-			})
-			return
-		}
+		respondError(ctx, status, err)
+		return
 	}
 
+	ctx.Header("ETag", link.ETag())
 	ctx.JSON(200, gin.H{
 		"link":    link,
 		"message": "Link found",
@@ -161,9 +345,28 @@ func (sc *ShortenerController) GetLink(ctx *gin.Context) {
 // GetLinks godoc
 //	@Summary		Get all shortened links for a user
 //	@Description	Retrieves all the shortened links associated with the authenticated user.
+//	@Description	Each link carries the destination metadata and the last health check result (health.status,
+//	@Description	health.latency_ms, health.checked_at, health.broken).
+//	@Description	The list is paginated with an opaque cursor: pass pagination.next_cursor from the previous page
+//	@Description	as cursor while has_more is true, keeping the same sort and filters.
+//	@Description	status filters are exclusive, checked in order: expired (expires_at passed), scheduled (active_from
+//	@Description	not reached), exhausted (max_clicks used up), active (everything else).
+//	@Description	q is a full-text search: it is split into words at any character other than a letter or digit, and
+//	@Description	every word must match the start of a word in the destination URL, the short ID or alias or the page title.
 //	@Tags			Shortener
+//	@Param			cursor	query	string	false	"Cursor from pagination.next_cursor"
+//	@Param			limit	query	int		false	"Page size, 1-200"	default(50)
+//	@Param			sort	query	string	false	"Sort field"		Enums(created_at, clicks, last_click)	default(created_at)
+//	@Param			order	query	string	false	"Sort order"		Enums(asc, desc)						default(desc)
+//	@Param			status	query	string	false	"Only links in this state"	Enums(active, scheduled, exhausted, expired)
+//	@Param			tag		query	string	false	"Only links with this tag"
+//	@Param			from	query	string	false	"Created at or after (RFC 3339 or YYYY-MM-DD)"
+//	@Param			to		query	string	false	"Created before (RFC 3339 or YYYY-MM-DD)"
+//	@Param			domain	query	string	false	"Destination domain, subdomains included"
+//	@Param			q		query	string	false	"Search query"
 //	@Security		BearerAuth
 //	@Success		200	{object}	GetLinksResponse	"Links retrieved successfully"
+//	@Failure		400	{object}	ErrorResponse		"Invalid cursor or filter"
 //	@Failure		401	{object}	ErrorResponse		"Unauthorized"
 //	@Failure		500	{object}	ErrorResponse		"Internal server error"
 //	@Router			/shortener [get]
@@ -187,40 +390,75 @@ func (sc *ShortenerController) GetLinks(ctx *gin.Context) {
 		return
 	}
 
-	links, status, err := sc.ShortenerService.GetLinks(&userIDUUID)
+	params := shortener.ListLinksParams{
+		Cursor: ctx.Query("cursor"),
+		Limit:  ctx.Query("limit"),
+		Sort:   ctx.Query("sort"),
+		Order:  ctx.Query("order"),
+		Status: ctx.Query("status"),
+		Tag:    ctx.Query("tag"),
+		From:   ctx.Query("from"),
+		To:     ctx.Query("to"),
+		Domain: ctx.Query("domain"),
+		Search: ctx.Query("q"),
+	}
+	page, status, err := sc.ShortenerService.GetLinks(&userIDUUID, params)
 	if err != nil {
-		switch status {
-		case 500:
-			ctx.JSON(500, gin.H{
-				"error":   err.Error(),
-				"message": "Internal server error",
-				"success": false,
-			})
-			return
-		}
+		respondError(ctx, status, err)
+		return
 	}
 
 	ctx.JSON(200, gin.H{
-		"links":   links,
-		"message": "Links found",
-		"success": true,
+		"links":      page.Links,
+		"pagination": page.Pagination,
+		"message":    "Links found",
+		"success":    true,
 	})
 }
 
 // CreateShortLink godoc
 //	@Summary		Create a shortened link
 //	@Description	Creates a new shortened link from the provided URL.
+//	@Description	An optional case-insensitive alias can be used instead of a random short ID.
+//	@Description	UTM parameters are stored on the link and appended to the destination on redirect;
+//	@Description	utm_template applies a saved set of them, explicitly passed parameters take precedence.
+//	@Description	max_clicks (or one_time) disables the link after that many redirects.
+//	@Description	target_rules route visitors by OS, device, browser or language, e.g. iOS to the App Store.
+//	@Description	variants split the traffic between several weighted destinations for A/B tests.
+//	@Description	Without expires_at the server default TTL applies; never_expires and long expirations depend on the user role.
 //	@Tags			Shortener
 //	@Param			request	body	CreateShortLinkRequest	true	"Request body for creating short link"
 //	@Security		BearerAuth
 //	@Success		200	{object}	CreateShortLinkResponse	"Link created successfully"
-//	@Failure		400	{object}	ErrorResponse			"Invalid URL or missing parameters"
+//	@Failure		400	{object}	ErrorResponse			"Invalid URL, alias or missing parameters"
 //	@Failure		401	{object}	ErrorResponse			"Unauthorized"
+//	@Failure		404	{object}	ErrorResponse			"UTM template not found"
+//	@Failure		409	{object}	ErrorResponse			"Alias already taken"
 //	@Failure		500	{object}	ErrorResponse			"Internal server error"
 //	@Router			/shortener [post]
 func (sc *ShortenerController) CreateShortLink(ctx *gin.Context) {
 	type createShortLinkRequest struct {
-		Url string `json:"url"`
+		Url            string              `json:"url"`
+		Alias          string              `json:"alias"`
+		ExpiresAt      *time.Time          `json:"expires_at"`
+		NeverExpires   bool                `json:"never_expires"`
+		ActiveFrom     *time.Time          `json:"active_from"`
+		PendingMode    string              `json:"pending_mode"`
+		PendingURL     string              `json:"pending_url"`
+		RedirectCode   int                 `json:"redirect_code"`
+		ForwardQuery   bool                `json:"forward_query"`
+		UTMTemplate    string              `json:"utm_template"`
+		Password       string              `json:"password"`
+		MaxClicks      int                 `json:"max_clicks"`
+		OneTime        bool                `json:"one_time"`
+		FallbackURL    string              `json:"fallback_url"`
+		TargetRules    []models.TargetRule `json:"target_rules"`
+		GeoTargets     map[string]string   `json:"geo_targets"`
+		Variants       []models.Variant    `json:"variants"`
+		StickyVariants bool                `json:"sticky_variants"`
+		Interstitial   bool                `json:"interstitial"`
+		Tags           []string            `json:"tags"`
+		UTMFields
 	}
 	userId := ctx.MustGet("user_id").(string)
 	if userId == "" {
@@ -259,7 +497,30 @@ func (sc *ShortenerController) CreateShortLink(ctx *gin.Context) {
 		return
 	}
 
-	link, status, err := sc.ShortenerService.CreateShortLink(req.Url, &userIDUUID)
+	params := shortener.CreateLinkParams{
+		Url:            req.Url,
+		Alias:          req.Alias,
+		ExpiresAt:      req.ExpiresAt,
+		NeverExpires:   req.NeverExpires,
+		ActiveFrom:     req.ActiveFrom,
+		PendingMode:    req.PendingMode,
+		PendingURL:     req.PendingURL,
+		RedirectCode:   req.RedirectCode,
+		ForwardQuery:   req.ForwardQuery,
+		UTM:            req.UTMFields.params(),
+		UTMTemplate:    req.UTMTemplate,
+		Password:       req.Password,
+		MaxClicks:      req.MaxClicks,
+		OneTime:        req.OneTime,
+		FallbackURL:    req.FallbackURL,
+		TargetRules:    req.TargetRules,
+		GeoTargets:     req.GeoTargets,
+		Variants:       req.Variants,
+		StickyVariants: req.StickyVariants,
+		Interstitial:   req.Interstitial,
+		Tags:           req.Tags,
+	}
+	link, status, err := sc.ShortenerService.CreateShortLink(params, &userIDUUID)
 	if err != nil {
 		switch status {
 		case 400:
@@ -269,7 +530,21 @@ func (sc *ShortenerController) CreateShortLink(ctx *gin.Context) {
 				"success": false,
 			})
 			return
-		case 500:
+		case 404:
+			ctx.JSON(404, gin.H{
+				"error":   err.Error(),
+				"message": "Not found",
+				"success": false,
+			})
+			return
+		case 409:
+			ctx.JSON(409, gin.H{
+				"error":   err.Error(),
+				"message": "Alias already taken",
+				"success": false,
+			})
+			return
+		default:
 			ctx.JSON(500, gin.H{
 				"error":   err.Error(),
 				"message": "Internal server error",
@@ -289,15 +564,29 @@ func (sc *ShortenerController) CreateShortLink(ctx *gin.Context) {
 
 // Redirect godoc
 //	@Summary		Redirect to the original URL
-//	@Description	Redirects the user to the original URL from a shortened link ID.
+//	@Description	Public endpoint. Redirects the visitor to the original URL from a shortened link ID.
+//	@Description	The status code is chosen per link (301, 302, 307 or 308, 302 by default).
+//	@Description	Links with forward_query also pass the query string on; parameters already present in the original URL win.
+//	@Description	Password-protected links show an HTML password form until the access cookie is set.
+//	@Description	Links with max_clicks return 410 (or redirect to fallback_url) once the limit is used up.
+//	@Description	Before active_from the link answers according to pending_mode: 404, a coming soon page or a redirect to pending_url.
+//	@Description	target_rules are checked first, in order: the first rule matching the visitor OS, device class, browser
+//	@Description	and language wins. Then geo_targets send visitors to the URL for their country (resolved by IP).
+//	@Description	Everyone else goes to the original URL, or to one of the weighted variants if the link has them.
+//	@Description	With sticky_variants the chosen variant is kept in a per-link cookie, which the preview page also sees.
+//	@Description	A shortID ending with "+" shows a preview page with the destination, its title, the creator
+//	@Description	and the creation date instead of redirecting. Links with interstitial always show it;
+//	@Description	its Continue button adds continue=1, which is not passed on to the destination.
 //	@Tags			Shortener
-//	@Param			shortID	path	string	true	"Shortened Link ID"
-//	@Security		BearerAuth
-//	@Success		302	{string}	"Redirected to the original URL"
+//	@Param			shortID	path	string	true	"Shortened Link ID, with a trailing + for the preview page"
+//	@Success		200	{string}	string			"HTML preview page"
+//	@Success		302	{string}	string			"Redirected to the original URL"
+//	@Failure		401	{string}	string			"Password form for a protected link"
 //	@Failure		400	{object}	ErrorResponse	"ShortID is empty or invalid"
-//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		404	{object}	ErrorResponse	"Link not found or not active yet (HTML page for pending_mode coming_soon)"
+//	@Failure		410	{object}	ErrorResponse	"Click limit reached, or an HTML page for an expired link"
 //	@Failure		500	{object}	ErrorResponse	"Internal server error"
-//	@Router			/shortener/{shortID} [get]
+//	@Router			/{shortID} [get]
 func (sc *ShortenerController) Redirect(ctx *gin.Context) {
 	shortID := ctx.Param("shortID")
 	if shortID == "" {
@@ -309,25 +598,170 @@ func (sc *ShortenerController) Redirect(ctx *gin.Context) {
 		return
 	}
 
-	link, status, err := sc.ShortenerService.Redirect(shortID)
-	if err != nil {
-		switch status {
-		case 404:
-			ctx.JSON(404, gin.H{
-				"error":   err.Error(),
-				"message": "Not found",
-				"success": false,
-			})
+	if previewID, ok := strings.CutSuffix(shortID, "+"); ok {
+		preview, status, err := sc.ShortenerService.Preview(previewID, visitInfo(ctx))
+		if err != nil {
+			respondRedirectError(ctx, status, err)
 			return
-		case 500:
-			ctx.JSON(500, gin.H{
-				"error":   err.Error(),
-				"message": "Internal server error",
-				"success": false,
-			})
+		}
+		renderPreview(ctx, preview)
+		return
+	}
+
+	destination, status, err := sc.ShortenerService.Redirect(shortID, visitInfo(ctx))
+	if err != nil {
+		respondRedirectError(ctx, status, err)
+		return
+	}
+
+	if destination.Preview != nil {
+		renderPreview(ctx, destination.Preview)
+		return
+	}
+	if destination.Sticky {
+		setVariantCookie(ctx, shortID, destination.Variant)
+	}
+	ctx.Redirect(status, destination.URL)
+}
+
+// respondRedirectError отвечает посетителю короткой ссылки: HTML-страницей, где она есть, иначе JSON
+func respondRedirectError(ctx *gin.Context, status int, err error) {
+	var notActive *shortener.NotActiveError
+	if errors.As(err, &notActive) {
+		renderPage(ctx, status, comingSoonPage, newComingSoonPageData(notActive.ActiveFrom))
+		return
+	}
+	switch status {
+	case 401:
+		renderPage(ctx, 401, passwordPage, passwordPageData{})
+		return
+	case 404:
+		ctx.JSON(404, gin.H{
+			"error":   err.Error(),
+			"message": "Not found",
+			"success": false,
+		})
+		return
+	case 410:
+		if errors.Is(err, shortener.ErrLinkExpired) {
+			renderPage(ctx, 410, expiredPage, nil)
 			return
 		}
+		ctx.JSON(410, gin.H{
+			"error":   err.Error(),
+			"message": "Gone",
+			"success": false,
+		})
+		return
+	default:
+		ctx.JSON(500, gin.H{
+			"error":   err.Error(),
+			"message": "Internal server error",
+			"success": false,
+		})
+		return
 	}
+}
+
+// visitInfo собирает данные посетителя для записи клика
+func visitInfo(ctx *gin.Context) shortener.VisitInfo {
+	shortID := ctx.Param("shortID")
+	accessToken, _ := ctx.Cookie(linkCookieName(linkAccessCookie, shortID))
+	variant, _ := ctx.Cookie(linkCookieName(linkVariantCookie, shortID))
+	// Отметку continue снимает сервис: она служебная только для ссылок с interstitial
+	query, fromQR := models.StripMarker(ctx.Request.URL.RawQuery, models.QRMarkerParam)
+	visit := shortener.VisitInfo{
+		IP:             ctx.ClientIP(),
+		Referrer:       ctx.Request.Referer(),
+		UserAgent:      ctx.Request.UserAgent(),
+		AcceptLanguage: ctx.GetHeader("Accept-Language"),
+		Query:          query,
+		AccessToken:    accessToken,
+		Variant:        variant,
+	}
+	if fromQR {
+		visit.Source = models.ClickSourceQR
+	}
+	return visit
+}
 
-	ctx.Redirect(301, link)
+// linkVariantCookie закрепляет за посетителем вариант A/B-теста ссылки с sticky_variants.
+// Как и link_access, у каждой ссылки свой cookie, видимый и странице предпросмотра.
+const (
+	linkVariantCookie = "link_variant"
+	variantCookieTTL  = 30 * 24 * time.Hour
+)
+
+func setVariantCookie(ctx *gin.Context, shortID, variant string) {
+	name := linkCookieName(linkVariantCookie, shortID)
+	if current, _ := ctx.Cookie(name); current == variant {
+		return
+	}
+	secure := ctx.Request.TLS != nil || strings.HasPrefix(config.BaseURL, "https://")
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(name, variant, int(variantCookieTTL.Seconds()), "/", "", secure, true)
+}
+
+// userIDFromContext достаёт id пользователя, положенный AuthMiddleware.
+// При ошибке сам отвечает 401 и возвращает false.
+func userIDFromContext(ctx *gin.Context) (*uuid.UUID, bool) {
+	userId, _ := ctx.Get("user_id")
+	userIdStr, _ := userId.(string)
+	userIDUUID, err := uuid.Parse(userIdStr)
+	if err != nil {
+		ctx.JSON(401, gin.H{
+			"error":   "Unauthorized",
+			"message": "Unauthorized",
+			"success": false,
+		})
+		return nil, false
+	}
+	return &userIDUUID, true
+}
+
+// respondError отвечает ошибкой сервиса с подходящим статусом
+func respondError(ctx *gin.Context, status int, err error) {
+	var message string
+	switch status {
+	case 400:
+		message = "Bad request"
+	case 401:
+		message = "Unauthorized"
+	case 403:
+		message = "Forbidden"
+	case 404:
+		message = "Not found"
+	case 409:
+		message = "Conflict"
+	case 410:
+		message = "Gone"
+	case 412:
+		message = "Precondition failed"
+	case 428:
+		message = "Precondition required"
+	case 429:
+		message = "Too many requests"
+	case 502:
+		message = "Bad gateway"
+	case 503:
+		message = "Service unavailable"
+	default:
+		status = 500
+		message = "Internal server error"
+	}
+	ctx.JSON(status, gin.H{
+		"error":   err.Error(),
+		"message": message,
+		"success": false,
+	})
+}
+
+// parseETag достаёт версию ссылки из заголовка If-Match вида "3" или W/"3"
+func parseETag(etag string) (int, error) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	version, err := strconv.Atoi(strings.Trim(etag, `"`))
+	if err != nil {
+		return 0, errors.New("invalid If-Match header")
+	}
+	return version, nil
 }
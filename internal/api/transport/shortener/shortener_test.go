@@ -0,0 +1,863 @@
+package shortener_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	shortenerService "github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/bigxxby/dream-test-task/internal/api/transport/shortener"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockShortenerService is a mock implementation of the IShortenerService interface.
+type MockShortenerService struct {
+	mock.Mock
+}
+
+func (m *MockShortenerService) CreateShortLink(params shortenerService.CreateLinkParams, userId *uuid.UUID) (*models.ShortLink, int, error) {
+	args := m.Called(params, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.ShortLink), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) Redirect(shortID string, visit shortenerService.VisitInfo) (*shortenerService.Destination, int, error) {
+	args := m.Called(shortID, visit)
+	if args.Get(0) != nil {
+		return args.Get(0).(*shortenerService.Destination), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) Preview(shortID string, visit shortenerService.VisitInfo) (*shortenerService.LinkPreview, int, error) {
+	args := m.Called(shortID, visit)
+	if args.Get(0) != nil {
+		return args.Get(0).(*shortenerService.LinkPreview), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) GetLinks(userId *uuid.UUID, params shortenerService.ListLinksParams) (*models.LinkPage, int, error) {
+	args := m.Called(userId, params)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.LinkPage), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) GetLink(shortID string, userId *uuid.UUID) (*models.ShortLink, int, error) {
+	args := m.Called(shortID, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.ShortLink), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) DeleteLink(shortID string, userId *uuid.UUID) (int, error) {
+	args := m.Called(shortID, userId)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockShortenerService) UpdateLink(shortID string, userId *uuid.UUID, params shortenerService.UpdateLinkParams) (*models.ShortLink, int, error) {
+	args := m.Called(shortID, userId, params)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.ShortLink), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) GetLinkHistory(shortID string, userId *uuid.UUID) ([]models.LinkRevision, int, error) {
+	args := m.Called(shortID, userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]models.LinkRevision), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) RestoreLinkRevision(shortID string, userId *uuid.UUID, revision int) (*models.ShortLink, int, error) {
+	args := m.Called(shortID, userId, revision)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.ShortLink), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) ExtendLink(shortID string, userId *uuid.UUID, duration time.Duration) (*models.ShortLink, int, error) {
+	args := m.Called(shortID, userId, duration)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).(*models.ShortLink), args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) GetBrokenLinks(userId *uuid.UUID) ([]models.ShortLink, int, error) {
+	args := m.Called(userId)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.ShortLink), args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) RefreshLinkMetadata(shortID string, userId *uuid.UUID) (*models.ShortLink, int, error) {
+	args := m.Called(shortID, userId)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).(*models.ShortLink), args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) GetLinkTimeSeries(shortID string, userId *uuid.UUID, params shortenerService.TimeSeriesParams) (*models.ClickTimeSeries, int, error) {
+	args := m.Called(shortID, userId, params)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.ClickTimeSeries), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) GetSharedLinks(userId *uuid.UUID) ([]models.ShortLink, int, error) {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]models.ShortLink), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) ShareLink(shortID string, ownerId *uuid.UUID, username string) (*models.LinkShare, int, error) {
+	args := m.Called(shortID, ownerId, username)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.LinkShare), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) GetLinkShares(shortID string, ownerId *uuid.UUID) ([]models.LinkShare, int, error) {
+	args := m.Called(shortID, ownerId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]models.LinkShare), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) UnshareLink(shortID string, ownerId *uuid.UUID, username string) (int, error) {
+	args := m.Called(shortID, ownerId, username)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockShortenerService) GetUTMTemplates(userId *uuid.UUID) ([]models.UTMTemplate, int, error) {
+	args := m.Called(userId)
+	if args.Get(0) != nil {
+		return args.Get(0).([]models.UTMTemplate), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) CreateUTMTemplate(userId *uuid.UUID, name string, utm models.UTMParams) (*models.UTMTemplate, int, error) {
+	args := m.Called(userId, name, utm)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.UTMTemplate), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) UpdateUTMTemplate(userId *uuid.UUID, name string, utm models.UTMParams) (*models.UTMTemplate, int, error) {
+	args := m.Called(userId, name, utm)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.UTMTemplate), args.Int(1), args.Error(2)
+	}
+	return nil, args.Int(1), args.Error(2)
+}
+
+func (m *MockShortenerService) DeleteUTMTemplate(userId *uuid.UUID, name string) (int, error) {
+	args := m.Called(userId, name)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockShortenerService) UnlockLink(shortID string, password string, visit shortenerService.VisitInfo) (string, int, error) {
+	args := m.Called(shortID, password, visit)
+	return args.String(0), args.Int(1), args.Error(2)
+}
+
+// withUser имитирует AuthMiddleware
+func withUser(userId string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", userId)
+		c.Next()
+	}
+}
+
+func TestRedirect(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	router.GET("/:shortID", shortenerCtrl.Redirect)
+
+	// Публичный редирект без заголовка Authorization
+	visit := shortenerService.VisitInfo{
+		IP:             "192.0.2.1",
+		Referrer:       "https://news.example.com/post",
+		UserAgent:      "Mozilla/5.0",
+		AcceptLanguage: "en-US,en;q=0.9",
+	}
+	mockShortenerService.On("Redirect", "abc123", visit).Return(&shortenerService.Destination{URL: "https://example.com"}, 302, nil)
+
+	req, _ := http.NewRequest("GET", "/abc123", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("Referer", "https://news.example.com/post")
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+
+	// Код редиректа и строка запроса приходят из настроек ссылки
+	mockShortenerService.On("Redirect", "perm", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.Query == "utm_source=x&ref=1"
+	})).Return(&shortenerService.Destination{URL: "https://example.com/?ref=1"}, 308, nil)
+
+	req, _ = http.NewRequest("GET", "/perm?utm_source=x&ref=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "https://example.com/?ref=1", w.Header().Get("Location"))
+
+	// Тест с несуществующей ссылкой
+	mockShortenerService.On("Redirect", "nope", mock.Anything).Return(nil, 404, errors.New("link not found"))
+
+	req, _ = http.NewRequest("GET", "/nope", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "link not found")
+
+	// Исчерпанный лимит переходов - 410, а не 404
+	mockShortenerService.On("Redirect", "once", mock.Anything).Return(nil, 410, shortenerService.ErrLinkExhausted)
+
+	req, _ = http.NewRequest("GET", "/once", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Body.String(), "link click limit reached")
+
+	// Истёкшая ссылка - страница для посетителя вместо JSON
+	mockShortenerService.On("Redirect", "old", mock.Anything).Return(nil, 410, shortenerService.ErrLinkExpired)
+
+	req, _ = http.NewRequest("GET", "/old", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "Link expired")
+
+	// До начала окна активации - страница с временем запуска
+	launch := time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)
+	mockShortenerService.On("Redirect", "launch", mock.Anything).Return(nil, 404, &shortenerService.NotActiveError{ActiveFrom: launch})
+
+	req, _ = http.NewRequest("GET", "/launch", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Coming soon")
+	assert.Contains(t, w.Body.String(), "2030-03-01T09:00:00Z")
+}
+
+func TestExtendLink(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.POST("/shortener/:shortID/extend", withUser(userId.String()), shortenerCtrl.ExtendLink)
+
+	expiresAt := time.Now().Add(14 * 24 * time.Hour)
+	link := &models.ShortLink{ShortId: "abc123", ExpiresAt: &expiresAt, Version: 3}
+	mockShortenerService.On("ExtendLink", "abc123", &userId, 168*time.Hour).Return(link, 200, nil)
+	// без тела - срок по умолчанию
+	mockShortenerService.On("ExtendLink", "abc123", &userId, time.Duration(0)).Return(link, 200, nil)
+
+	req, _ := http.NewRequest("POST", "/shortener/abc123/extend", strings.NewReader(`{"duration":"168h"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	req, _ = http.NewRequest("POST", "/shortener/abc123/extend", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("POST", "/shortener/abc123/extend", strings.NewReader(`{"duration":"2 weeks"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockShortenerService.AssertNumberOfCalls(t, "ExtendLink", 2)
+}
+
+func TestPasswordProtectedLink(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService, AccessCookieTTL: 30 * time.Minute}
+	router.GET("/:shortID", shortenerCtrl.Redirect)
+	router.POST("/:shortID", shortenerCtrl.UnlockLink)
+
+	// Без cookie доступа показывается форма пароля
+	mockShortenerService.On("Redirect", "secret", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.AccessToken == ""
+	})).Return(nil, 401, shortenerService.ErrPasswordRequired)
+
+	req, _ := http.NewRequest("GET", "/secret", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `name="password"`)
+
+	// Неверный пароль - форма с ошибкой
+	mockShortenerService.On("UnlockLink", "secret", "wrong", mock.Anything).Return("", 401, errors.New("invalid password"))
+
+	req, _ = http.NewRequest("POST", "/secret", strings.NewReader("password=wrong"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid password")
+
+	// Верный пароль - cookie ссылки и возврат на неё с той же строкой запроса
+	mockShortenerService.On("UnlockLink", "secret", "hunter2", mock.Anything).Return("token", 200, nil)
+
+	req, _ = http.NewRequest("POST", "/secret?ref=1", strings.NewReader("password=hunter2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/secret?ref=1", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "link_access_secret", cookies[0].Name)
+		assert.Equal(t, "token", cookies[0].Value)
+		assert.Equal(t, "/", cookies[0].Path)
+		assert.True(t, cookies[0].HttpOnly)
+	}
+
+	// С cookie токен передаётся в сервис
+	mockShortenerService.On("Redirect", "secret", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.AccessToken == "token"
+	})).Return(&shortenerService.Destination{URL: "https://example.com"}, 302, nil)
+
+	req, _ = http.NewRequest("GET", "/secret", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+}
+
+// TestPreviewAfterUnlock проверяет, что браузер отправит cookie ссылки и на страницу предпросмотра
+func TestPreviewAfterUnlock(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService, AccessCookieTTL: 30 * time.Minute}
+	router.GET("/:shortID", shortenerCtrl.Redirect)
+	router.POST("/:shortID", shortenerCtrl.UnlockLink)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		req.URL.Scheme, req.URL.Host = "http", "sho.rt"
+		for _, cookie := range jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		jar.SetCookies(req.URL, w.Result().Cookies())
+		return w
+	}
+
+	mockShortenerService.On("UnlockLink", "secret", "hunter2", mock.Anything).Return("token", 200, nil)
+	req, _ := http.NewRequest("POST", "/secret", strings.NewReader("password=hunter2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Equal(t, http.StatusSeeOther, serve(req).Code)
+
+	mockShortenerService.On("Redirect", "secret", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.AccessToken == "token"
+	})).Return(&shortenerService.Destination{URL: "https://example.com/b", Variant: "B", Sticky: true}, 302, nil)
+	req, _ = http.NewRequest("GET", "/secret", nil)
+	assert.Equal(t, http.StatusFound, serve(req).Code)
+
+	// На /secret+ приходят и токен доступа, и закреплённый вариант
+	mockShortenerService.On("Preview", "secret", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.AccessToken == "token" && v.Variant == "B"
+	})).Return(&shortenerService.LinkPreview{ShortID: "secret", Destination: "https://example.com/b", Protected: true}, 200, nil)
+	req, _ = http.NewRequest("GET", "/secret+", nil)
+	w := serve(req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "https://example.com/b")
+	mockShortenerService.AssertExpectations(t)
+}
+
+func TestStickyVariantCookie(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	router.GET("/:shortID", shortenerCtrl.Redirect)
+
+	// Первый переход: вариант назначается и закрепляется cookie ссылки
+	mockShortenerService.On("Redirect", "ab", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.Variant == ""
+	})).Return(&shortenerService.Destination{URL: "https://example.com/b", Variant: "B", Sticky: true}, 302, nil)
+
+	req, _ := http.NewRequest("GET", "/ab", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/b", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "link_variant_ab", cookies[0].Name)
+		assert.Equal(t, "B", cookies[0].Value)
+		assert.Equal(t, "/", cookies[0].Path)
+	}
+
+	// Повторный переход: вариант из cookie передаётся в сервис, cookie не переписывается
+	mockShortenerService.On("Redirect", "ab", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.Variant == "B"
+	})).Return(&shortenerService.Destination{URL: "https://example.com/b", Variant: "B", Sticky: true}, 302, nil)
+
+	req, _ = http.NewRequest("GET", "/ab", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "https://example.com/b", w.Header().Get("Location"))
+	assert.Empty(t, w.Result().Cookies())
+}
+
+func TestDeleteLinkForeign(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.DELETE("/shortener/:shortID", withUser(userId.String()), shortenerCtrl.DeleteLink)
+
+	// Чужая ссылка
+	mockShortenerService.On("DeleteLink", "foreign", &userId).Return(403, errors.New("access denied"))
+
+	req, _ := http.NewRequest("DELETE", "/shortener/foreign", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "access denied")
+
+	// Своя ссылка
+	mockShortenerService.On("DeleteLink", "mine", &userId).Return(200, nil)
+
+	req, _ = http.NewRequest("DELETE", "/shortener/mine", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Link deleted")
+}
+
+func TestGetLinkUnauthorized(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	router.GET("/shortener/stats/:shortID", shortenerCtrl.GetLink)
+
+	req, _ := http.NewRequest("GET", "/shortener/stats/abc123", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockShortenerService.AssertNotCalled(t, "GetLink", mock.Anything, mock.Anything)
+}
+
+func TestCreateShortLinkAliasTaken(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.POST("/shortener", withUser(userId.String()), shortenerCtrl.CreateShortLink)
+
+	params := shortenerService.CreateLinkParams{Url: "https://example.com", Alias: "spring-sale"}
+	mockShortenerService.On("CreateShortLink", params, &userId).Return(nil, 409, errors.New("alias is already taken"))
+
+	reqBody := `{"url":"https://example.com","alias":"spring-sale"}`
+	req, _ := http.NewRequest("POST", "/shortener", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "alias is already taken")
+}
+
+func TestCreateShortLinkWithUTM(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.POST("/shortener", withUser(userId.String()), shortenerCtrl.CreateShortLink)
+
+	params := shortenerService.CreateLinkParams{
+		Url:         "https://example.com",
+		UTM:         models.UTMParams{Source: "newsletter", Campaign: "spring"},
+		UTMTemplate: "mail",
+	}
+	created := &models.ShortLink{ShortId: "abc123", LongLink: "https://example.com", UTM: params.UTM}
+	mockShortenerService.On("CreateShortLink", params, &userId).Return(created, 200, nil)
+
+	reqBody := `{"url":"https://example.com","utm_source":"newsletter","utm_campaign":"spring","utm_template":"mail"}`
+	req, _ := http.NewRequest("POST", "/shortener", strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"utm_source":"newsletter"`)
+	mockShortenerService.AssertExpectations(t)
+}
+
+func TestUpdateLinkVersion(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.PATCH("/shortener/:shortID", withUser(userId.String()), shortenerCtrl.UpdateLink)
+
+	newUrl := "https://example.org"
+	updated := &models.ShortLink{ShortId: "abc123", LongLink: newUrl, Version: 3}
+	mockShortenerService.On("UpdateLink", "abc123", &userId, shortenerService.UpdateLinkParams{Url: &newUrl, Version: 2}).Return(updated, 200, nil)
+	mockShortenerService.On("UpdateLink", "abc123", &userId, shortenerService.UpdateLinkParams{Url: &newUrl, Version: 1}).Return(nil, 412, errors.New("link was modified by someone else"))
+
+	// Версия из If-Match совпадает
+	req, _ := http.NewRequest("PATCH", "/shortener/abc123", strings.NewReader(`{"url":"https://example.org"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	// Устаревшая версия в теле запроса
+	req, _ = http.NewRequest("PATCH", "/shortener/abc123", strings.NewReader(`{"url":"https://example.org","version":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestGetLinkTimeSeries(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.GET("/shortener/stats/:shortID/timeseries", withUser(userId.String()), shortenerCtrl.GetLinkTimeSeries)
+
+	params := shortenerService.TimeSeriesParams{From: "2026-01-01", To: "2026-01-08", Interval: "day", Timezone: "Europe/Moscow"}
+	series := &models.ClickTimeSeries{Interval: "day", Timezone: "Europe/Moscow", Total: 42}
+	mockShortenerService.On("GetLinkTimeSeries", "abc123", &userId, params).Return(series, 200, nil)
+
+	req, _ := http.NewRequest("GET", "/shortener/stats/abc123/timeseries?from=2026-01-01&to=2026-01-08&interval=day&tz=Europe/Moscow", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":42`)
+}
+
+func TestGetLinkQR(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.GET("/shortener/:shortID/qr", withUser(userId.String()), shortenerCtrl.GetLinkQR)
+
+	mockShortenerService.On("GetLink", "flyer", &userId).Return(&models.ShortLink{ShortId: "flyer"}, 200, nil)
+
+	req, _ := http.NewRequest("GET", "/shortener/flyer/qr", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "\x89PNG"))
+
+	req, _ = http.NewRequest("GET", "/shortener/flyer/qr?format=svg&size=512&margin=2&level=H&fg=%23336699&bg=ffffff", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `width="512"`)
+	assert.Contains(t, w.Body.String(), `fill="#336699"`)
+
+	// Неверные параметры отклоняются до обращения к сервису
+	for _, query := range []string{"format=gif", "size=big", "size=10000", "level=Z", "fg=blue"} {
+		req, _ = http.NewRequest("GET", "/shortener/flyer/qr?"+query, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	mockShortenerService.On("GetLink", "other", &userId).Return(nil, 403, errors.New("forbidden"))
+	req, _ = http.NewRequest("GET", "/shortener/other/qr", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRedirectFromQR(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	router.GET("/:shortID", shortenerCtrl.Redirect)
+
+	// Метка QR-кода попадает в клик, но не в строку запроса для адреса назначения
+	mockShortenerService.On("Redirect", "flyer", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.Source == models.ClickSourceQR && v.Query == "ref=1"
+	})).Return(&shortenerService.Destination{URL: "https://example.com"}, 302, nil)
+
+	req, _ := http.NewRequest("GET", "/flyer?qr=1&ref=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	// qr с другим значением - параметр адреса назначения, а не метка QR-кода
+	mockShortenerService.On("Redirect", "flyer", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.Source == "" && v.Query == "qr=wifi&ref=1"
+	})).Return(&shortenerService.Destination{URL: "https://example.com"}, 302, nil)
+
+	req, _ = http.NewRequest("GET", "/flyer?qr=wifi&ref=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	mockShortenerService.AssertExpectations(t)
+}
+
+func TestLinkPreview(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	router.GET("/:shortID", shortenerCtrl.Redirect)
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// "+" после идентификатора показывает предпросмотр вместо редиректа
+	mockShortenerService.On("Preview", "promo", mock.Anything).Return(&shortenerService.LinkPreview{
+		ShortID:     "promo",
+		Destination: "https://example.com/sale?a=1&b=2",
+		Title:       "Spring <sale>",
+		Creator:     "alice",
+		CreatedAt:   created,
+	}, 200, nil)
+
+	req, _ := http.NewRequest("GET", "/promo+?qr=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	body := w.Body.String()
+	assert.Contains(t, body, "https://example.com/sale?a=1&amp;b=2")
+	assert.Contains(t, body, "Spring &lt;sale&gt;")
+	assert.Contains(t, body, "<strong>example.com</strong>")
+	assert.Contains(t, body, "by alice")
+	assert.Contains(t, body, "1 May 2024")
+	assert.Contains(t, body, `href="/promo?qr=1"`)
+	mockShortenerService.AssertNotCalled(t, "Redirect", mock.Anything, mock.Anything)
+
+	// Ссылка с interstitial: кнопка ведёт на ссылку с отметкой continue
+	mockShortenerService.On("Redirect", "safe", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.Query == ""
+	})).Return(&shortenerService.Destination{Preview: &shortenerService.LinkPreview{
+		ShortID:      "safe",
+		Destination:  "https://example.com",
+		CreatedAt:    created,
+		Interstitial: true,
+	}}, 200, nil)
+
+	req, _ = http.NewRequest("GET", "/safe", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `href="/safe?continue=1"`)
+
+	// Отметку continue разбирает сервис: она служебная только для ссылок с interstitial
+	mockShortenerService.On("Redirect", "safe", mock.MatchedBy(func(v shortenerService.VisitInfo) bool {
+		return v.Query == "ref=1&continue=1"
+	})).Return(&shortenerService.Destination{URL: "https://example.com"}, 302, nil)
+
+	req, _ = http.NewRequest("GET", "/safe?ref=1&continue=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+
+	// A/B-тест без назначенного варианта: показываются все адреса
+	mockShortenerService.On("Preview", "ab", mock.Anything).Return(&shortenerService.LinkPreview{
+		ShortID:      "ab",
+		Alternatives: []string{"https://example.com/a", "https://example.com/b"},
+		CreatedAt:    created,
+	}, 200, nil)
+
+	req, _ = http.NewRequest("GET", "/ab+", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<p class="url">https://example.com/a</p>`)
+	assert.Contains(t, w.Body.String(), `<p class="url">https://example.com/b</p>`)
+	assert.NotContains(t, w.Body.String(), "password protected")
+
+	// Ссылка с паролем: адрес не показывается
+	mockShortenerService.On("Preview", "secret", mock.Anything).Return(&shortenerService.LinkPreview{
+		ShortID:   "secret",
+		CreatedAt: created,
+		Protected: true,
+	}, 200, nil)
+
+	req, _ = http.NewRequest("GET", "/secret+", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "password protected")
+	assert.Contains(t, w.Body.String(), `href="/secret"`)
+}
+
+func TestRefreshLinkMetadata(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.POST("/shortener/:shortID/metadata", withUser(userId.String()), shortenerCtrl.RefreshLinkMetadata)
+
+	link := &models.ShortLink{ShortId: "promo", LongLink: "https://example.com", Title: "Example Domain", FaviconURL: "https://example.com/favicon.ico"}
+	mockShortenerService.On("RefreshLinkMetadata", "promo", &userId).Return(link, 200, nil)
+	mockShortenerService.On("RefreshLinkMetadata", "down", &userId).Return(nil, 502, errors.New("destination responded with status 503"))
+
+	req, _ := http.NewRequest("POST", "/shortener/promo/metadata", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Example Domain"`)
+	assert.Contains(t, w.Body.String(), `"favicon_url":"https://example.com/favicon.ico"`)
+
+	req, _ = http.NewRequest("POST", "/shortener/down/metadata", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body.String(), "Bad gateway")
+}
+
+func TestGetBrokenLinks(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.GET("/shortener/broken", withUser(userId.String()), shortenerCtrl.GetBrokenLinks)
+
+	checkedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	links := []models.ShortLink{{
+		ShortId:  "old",
+		LongLink: "https://example.com/removed",
+		Health:   models.LinkHealth{Status: 404, LatencyMs: 87, CheckedAt: &checkedAt, Failures: 2, Broken: true},
+	}}
+	mockShortenerService.On("GetBrokenLinks", &userId).Return(links, 200, nil)
+
+	req, _ := http.NewRequest("GET", "/shortener/broken", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"short_id":"old"`)
+	assert.Contains(t, body, `"health":{"status":404,"latency_ms":87,"checked_at":"2024-05-01T12:00:00Z","failures":2,"broken":true}`)
+}
+
+func TestGetLinks(t *testing.T) {
+	mockShortenerService := new(MockShortenerService)
+	router := gin.Default()
+	shortenerCtrl := &shortener.ShortenerController{ShortenerService: mockShortenerService}
+	userId := uuid.New()
+	router.GET("/shortener", withUser(userId.String()), shortenerCtrl.GetLinks)
+
+	params := shortenerService.ListLinksParams{
+		Cursor: "abc",
+		Limit:  "2",
+		Sort:   "clicks",
+		Order:  "asc",
+		Status: "active",
+		Tag:    "promo",
+		From:   "2024-05-01",
+		Domain: "example.com",
+		Search: "spring sale",
+	}
+	page := &models.LinkPage{
+		Links: []models.ShortLink{{ShortId: "sale", LongLink: "https://example.com/spring", Tags: models.Tags{"promo"}}},
+		Pagination: models.Pagination{
+			Limit:      2,
+			Sort:       "clicks",
+			Order:      "asc",
+			NextCursor: "next",
+			HasMore:    true,
+		},
+	}
+	mockShortenerService.On("GetLinks", &userId, params).Return(page, 200, nil)
+
+	req, _ := http.NewRequest("GET", "/shortener?cursor=abc&limit=2&sort=clicks&order=asc&status=active&tag=promo&from=2024-05-01&domain=example.com&q=spring+sale", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `"short_id":"sale"`)
+	assert.Contains(t, body, `"tags":["promo"]`)
+	assert.Contains(t, body, `"pagination":{"limit":2,"sort":"clicks","order":"asc","next_cursor":"next","has_more":true}`)
+
+	mockShortenerService.On("GetLinks", &userId, shortenerService.ListLinksParams{Sort: "title"}).
+		Return(nil, 400, errors.New("sort must be one of created_at, clicks, last_click"))
+	req, _ = http.NewRequest("GET", "/shortener?sort=title", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "sort must be one of")
+}
@@ -0,0 +1,53 @@
+package shortener
+
+import (
+	"github.com/bigxxby/dream-test-task/internal/api/service/shortener"
+	"github.com/gin-gonic/gin"
+)
+
+// GetLinkTimeSeries godoc
+//	@Summary		Get click time series for a link
+//	@Description	Returns click counts per hour, day or week and top referrers, browsers, OS, devices, countries,
+//	@Description	target rules (geo:<country> for geo_targets, default when nothing matched), A/B variants
+//	@Description	and sources (qr for QR code scans, link for everything else).
+//	@Description	Links with variants also get per-variant clicks, unique visitors and share.
+//	@Description	Buckets are aligned in the requested timezone, weeks start on Monday.
+//	@Description	Available to the link owner and to users the link is shared with.
+//	@Tags			Stats
+//	@Param			shortID		path	string	true	"Shortened Link ID"
+//	@Param			from		query	string	false	"Period start, RFC3339 or YYYY-MM-DD (default depends on interval)"
+//	@Param			to			query	string	false	"Period end, RFC3339 or YYYY-MM-DD (default now)"
+//	@Param			interval	query	string	false	"Bucket size"	Enums(hour, day, week)	default(day)
+//	@Param			tz			query	string	false	"IANA timezone"	default(UTC)
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"Time series retrieved successfully"
+//	@Failure		400	{object}	ErrorResponse	"Invalid period, interval or timezone"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"No access to the link"
+//	@Failure		404	{object}	ErrorResponse	"Link not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/stats/{shortID}/timeseries [get]
+func (sc *ShortenerController) GetLinkTimeSeries(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	params := shortener.TimeSeriesParams{
+		From:     ctx.Query("from"),
+		To:       ctx.Query("to"),
+		Interval: ctx.Query("interval"),
+		Timezone: ctx.Query("tz"),
+	}
+	series, status, err := sc.ShortenerService.GetLinkTimeSeries(ctx.Param("shortID"), userIDUUID, params)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"stats":   series,
+		"message": "Stats found",
+		"success": true,
+	})
+}
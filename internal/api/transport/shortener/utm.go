@@ -0,0 +1,151 @@
+package shortener
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Структура запроса для создания шаблона меток
+type CreateUTMTemplateRequest struct {
+	Name string `json:"name" binding:"required" example:"newsletter"`
+	UTMFields
+}
+
+// GetUTMTemplates godoc
+//	@Summary		Get UTM templates
+//	@Description	Retrieves UTM templates of the authenticated user, sorted by name.
+//	@Tags			UTM
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"Templates retrieved successfully"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/utm-templates [get]
+func (sc *ShortenerController) GetUTMTemplates(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	templates, status, err := sc.ShortenerService.GetUTMTemplates(userIDUUID)
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"templates": templates,
+		"message":   "Templates found",
+		"success":   true,
+	})
+}
+
+// CreateUTMTemplate godoc
+//	@Summary		Create a UTM template
+//	@Description	Saves a named set of UTM parameters that can be applied when creating links via utm_template.
+//	@Tags			UTM
+//	@Param			request	body	CreateUTMTemplateRequest	true	"Template name and UTM parameters"
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"Template created"
+//	@Failure		400	{object}	ErrorResponse	"Invalid name or parameters"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		409	{object}	ErrorResponse	"Template with this name already exists"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/utm-templates [post]
+func (sc *ShortenerController) CreateUTMTemplate(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	var req CreateUTMTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(400, gin.H{
+			"error":   err.Error(),
+			"message": "Bad request",
+			"success": false,
+		})
+		return
+	}
+
+	template, status, err := sc.ShortenerService.CreateUTMTemplate(userIDUUID, req.Name, req.UTMFields.params())
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"template": template,
+		"message":  "Template created",
+		"success":  true,
+	})
+}
+
+// UpdateUTMTemplate godoc
+//	@Summary		Replace parameters of a UTM template
+//	@Description	Replaces all UTM parameters of the template. Links created from it earlier keep their parameters.
+//	@Tags			UTM
+//	@Param			name	path	string		true	"Template name"
+//	@Param			request	body	UTMFields	true	"New UTM parameters"
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"Template updated"
+//	@Failure		400	{object}	ErrorResponse	"Invalid parameters"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	ErrorResponse	"Template not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/utm-templates/{name} [put]
+func (sc *ShortenerController) UpdateUTMTemplate(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	var req UTMFields
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(400, gin.H{
+			"error":   err.Error(),
+			"message": "Bad request",
+			"success": false,
+		})
+		return
+	}
+
+	template, status, err := sc.ShortenerService.UpdateUTMTemplate(userIDUUID, ctx.Param("name"), req.params())
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"template": template,
+		"message":  "Template updated",
+		"success":  true,
+	})
+}
+
+// DeleteUTMTemplate godoc
+//	@Summary		Delete a UTM template
+//	@Description	Deletes the template. Links created from it keep their parameters.
+//	@Tags			UTM
+//	@Param			name	path	string	true	"Template name"
+//	@Security		BearerAuth
+//	@Success		200	{object}	SuccessResponse	"Template deleted"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	ErrorResponse	"Template not found"
+//	@Failure		500	{object}	ErrorResponse	"Internal server error"
+//	@Router			/shortener/utm-templates/{name} [delete]
+func (sc *ShortenerController) DeleteUTMTemplate(ctx *gin.Context) {
+	userIDUUID, ok := userIDFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := sc.ShortenerService.DeleteUTMTemplate(userIDUUID, ctx.Param("name"))
+	if err != nil {
+		respondError(ctx, status, err)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"message": "Template deleted",
+		"success": true,
+	})
+}
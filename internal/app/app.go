@@ -1,12 +1,27 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	shortenerRepo "github.com/bigxxby/dream-test-task/internal/api/repo/shortener"
+	"github.com/bigxxby/dream-test-task/internal/cache"
+	"github.com/bigxxby/dream-test-task/internal/clicks"
 	"github.com/bigxxby/dream-test-task/internal/config"
 	"github.com/bigxxby/dream-test-task/internal/database/connection"
 	"github.com/bigxxby/dream-test-task/internal/database/migration"
+	"github.com/bigxxby/dream-test-task/internal/geoip"
+	"github.com/bigxxby/dream-test-task/internal/health"
+	"github.com/bigxxby/dream-test-task/internal/metadata"
 	"github.com/bigxxby/dream-test-task/internal/router"
+	"github.com/bigxxby/dream-test-task/internal/safehttp"
 )
 
 func App() {
@@ -37,10 +52,153 @@ func App() {
 	// 	return
 	// }
 
-	router, err := router.NewRouter(db)
+	// Клики пишутся в базу пачками в фоне
+	clickPipeline, err := clicks.NewPipeline(shortenerRepo.NewShortenerRepo(db), clicks.Options{
+		BufferSize:     config.ClickBufferSize,
+		BatchSize:      config.ClickBatchSize,
+		FlushInterval:  config.ClickFlushInterval,
+		OverflowPolicy: config.ClickOverflowPolicy,
+		EnqueueTimeout: config.ClickEnqueueTimeout,
+	})
 	if err != nil {
 		log.Println(err)
 		return
 	}
-	router.Run(":" + config.AppPort)
+
+	linkCache, closeCache, err := newLinkCache(config)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer closeCache()
+
+	geoLocator, closeGeoIP, err := newGeoLocator(config)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer closeGeoIP()
+
+	healthChecker, err := newHealthChecker(config, shortenerRepo.NewShortenerRepo(db))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	metadataQueue, err := newMetadataQueue(config)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	router, err := router.NewRouter(db, config, clickPipeline, linkCache, geoLocator, healthChecker, metadataQueue)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	server := &http.Server{
+		Addr:    ":" + config.AppPort,
+		Handler: router,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err = <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Println(err)
+		}
+	case sig := <-quit:
+		log.Println("shutting down:", sig)
+	}
+
+	// Сначала дожидаемся текущих запросов, затем записываем оставшиеся клики
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println(err)
+	}
+	if err := clickPipeline.Close(ctx); err != nil {
+		log.Println("click events were not flushed:", err)
+	}
+	if healthChecker != nil {
+		if err := healthChecker.Close(ctx); err != nil {
+			log.Println("health check did not stop:", err)
+		}
+	}
+	if metadataQueue != nil {
+		if err := metadataQueue.Close(ctx); err != nil {
+			log.Println("metadata fetches did not stop:", err)
+		}
+	}
+}
+
+// newLinkCache выбирает хранилище кэша редиректов по конфигурации
+func newLinkCache(cfg *config.Config) (cache.Cache, func(), error) {
+	if !cfg.CacheEnabled {
+		return nil, func() {}, nil
+	}
+	switch cfg.CacheBackend {
+	case "memory":
+		return cache.NewLRU(cfg.CacheSize), func() {}, nil
+	case "redis":
+		redisCache, err := cache.NewRedis(cache.RedisOptions{
+			URL:       cfg.RedisURL,
+			LocalSize: cfg.CacheSize,
+			LocalTTL:  cfg.CacheLocalTTL,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return redisCache, func() { redisCache.Close() }, nil
+	default:
+		return nil, nil, errors.New("unknown cache backend " + cfg.CacheBackend)
+	}
 }
+
+// newGeoLocator открывает базу GeoIP, если она настроена. Без неё страна посетителя не определяется.
+func newGeoLocator(cfg *config.Config) (geoip.Locator, func(), error) {
+	if cfg.GeoIPDBPath == "" {
+		return nil, func() {}, nil
+	}
+	db, err := geoip.Open(cfg.GeoIPDBPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open geoip database: %w", err)
+	}
+	return db, func() { db.Close() }, nil
+}
+
+// newHealthChecker запускает фоновую проверку адресов назначения, если она включена.
+// При нескольких репликах её достаточно включить на одной.
+func newHealthChecker(cfg *config.Config, store health.Store) (*health.Checker, error) {
+	if !cfg.HealthCheckEnabled {
+		return nil, nil
+	}
+	prober := health.NewClient(safehttp.Options{Timeout: cfg.HealthCheckTimeout, MaxRedirects: 10})
+	return health.NewChecker(store, prober, health.Options{
+		Interval:         cfg.HealthCheckInterval,
+		Recheck:          cfg.HealthCheckRecheck,
+		BatchSize:        cfg.HealthCheckBatchSize,
+		Concurrency:      cfg.HealthCheckConcurrency,
+		FailureThreshold: cfg.HealthFailureThreshold,
+	})
+}
+
+// newMetadataQueue запускает фоновую загрузку метаданных страниц назначения, если она включена
+func newMetadataQueue(cfg *config.Config) (*metadata.Queue, error) {
+	if !cfg.MetadataEnabled {
+		return nil, nil
+	}
+	return metadata.NewQueue(metadata.QueueOptions{
+		Size:    cfg.MetadataQueue,
+		Workers: cfg.MetadataWorkers,
+	})
+}
+
+// shutdownTimeout - сколько ждём завершения запросов и записи кликов при остановке
+const shutdownTimeout = 15 * time.Second
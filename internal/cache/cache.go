@@ -0,0 +1,11 @@
+package cache
+
+import "time"
+
+// Cache - хранилище байтовых значений с TTL.
+// Ошибка означает, что хранилище недоступно, и вызывающий должен обойтись без него.
+type Cache interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+}
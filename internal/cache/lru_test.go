@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Minute)
+
+	// обращение к a делает вытесняемым b
+	_, ok, _ := c.Get("a")
+	assert.True(t, ok)
+	c.Set("c", []byte("3"), time.Minute)
+
+	_, ok, _ = c.Get("b")
+	assert.False(t, ok)
+	value, ok, _ := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRUExpires(t *testing.T) {
+	now := time.Now()
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", nil, time.Second)
+	_, ok, _ := c.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok, _ = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRUDelete(t *testing.T) {
+	c := NewLRU(10)
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Minute)
+
+	assert.NoError(t, c.Delete("a", "missing"))
+	_, ok, _ := c.Get("a")
+	assert.False(t, ok)
+	_, ok, _ = c.Get("b")
+	assert.True(t, ok)
+}
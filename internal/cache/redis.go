@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrUnavailable - Redis недавно не ответил, обращения к нему временно не выполняются
+var ErrUnavailable = errors.New("cache is unavailable")
+
+type RedisOptions struct {
+	URL        string        // redis://[:password@]host:port/db
+	Prefix     string        // префикс ключей и канала сброса
+	Timeout    time.Duration // на одно обращение; небольшой, чтобы не задерживать редирект
+	RetryAfter time.Duration // сколько не обращаться к Redis после ошибки
+	LocalSize  int           // размер локального кэша процесса
+	LocalTTL   time.Duration // срок записи в локальном кэше
+}
+
+// Redis - общий для всех реплик кэш с локальным LRU перед ним.
+// Удаление ключей публикуется в канал, и каждая реплика сбрасывает их у себя.
+// Пока Redis недоступен, работает только локальный кэш. Delete тогда сбрасывает
+// только локальную запись и возвращает ошибку: в Redis запись остаётся до конца
+// своего срока из Set, и другие реплики, в том числе после восстановления Redis,
+// могут отдавать её до этого времени.
+type Redis struct {
+	client     *redis.Client
+	pubsub     *redis.PubSub
+	prefix     string
+	timeout    time.Duration
+	retryAfter time.Duration
+	local      *LRU
+	localTTL   time.Duration
+	downUntil  atomic.Int64 // unix nano
+}
+
+// NewRedis подключается к Redis и подписывается на сброс ключей.
+// Недоступность Redis при запуске не ошибка: обращения начнут проходить, когда он поднимется.
+func NewRedis(opts RedisOptions) (*Redis, error) {
+	redisOpts, err := redis.ParseURL(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "shortener:"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 100 * time.Millisecond
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = 5 * time.Second
+	}
+	if opts.LocalTTL <= 0 {
+		opts.LocalTTL = 10 * time.Second
+	}
+	redisOpts.DialTimeout = opts.Timeout
+	redisOpts.ReadTimeout = opts.Timeout
+	redisOpts.WriteTimeout = opts.Timeout
+	redisOpts.MaxRetries = -1 // повторы увеличили бы задержку редиректа
+
+	r := &Redis{
+		client:     redis.NewClient(redisOpts),
+		prefix:     opts.Prefix,
+		timeout:    opts.Timeout,
+		retryAfter: opts.RetryAfter,
+		local:      NewLRU(opts.LocalSize),
+		localTTL:   opts.LocalTTL,
+	}
+	r.pubsub = r.client.Subscribe(context.Background(), r.invalidationChannel())
+	go r.listen()
+	return r, nil
+}
+
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	if value, ok, _ := r.local.Get(key); ok {
+		return value, true, nil
+	}
+	if !r.available() {
+		return nil, false, ErrUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, r.fail(err)
+	}
+	r.local.Set(key, value, r.localTTL)
+	return value, true, nil
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	r.local.Set(key, value, min(ttl, r.localTTL))
+	if !r.available() {
+		return ErrUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	if err := r.client.Set(ctx, r.prefix+key, value, ttl).Err(); err != nil {
+		return r.fail(err)
+	}
+	return nil
+}
+
+// Delete удаляет ключи из Redis и рассылает их сброс всем репликам
+func (r *Redis) Delete(keys ...string) error {
+	r.local.Delete(keys...)
+	if len(keys) == 0 {
+		return nil
+	}
+	if !r.available() {
+		return ErrUnavailable
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, prefixed...)
+		pipe.Publish(ctx, r.invalidationChannel(), strings.Join(keys, "\n"))
+		return nil
+	})
+	if err != nil {
+		return r.fail(err)
+	}
+	return nil
+}
+
+// Close отписывается от сброса и закрывает соединения
+func (r *Redis) Close() error {
+	r.pubsub.Close()
+	return r.client.Close()
+}
+
+// listen сбрасывает локальные записи по сообщениям других реплик.
+// Подписка сама переподключается после обрыва.
+func (r *Redis) listen() {
+	for msg := range r.pubsub.Channel() {
+		r.local.Delete(strings.Split(msg.Payload, "\n")...)
+	}
+}
+
+func (r *Redis) invalidationChannel() string {
+	return r.prefix + "invalidate"
+}
+
+func (r *Redis) available() bool {
+	return time.Now().UnixNano() >= r.downUntil.Load()
+}
+
+// fail запоминает ошибку, чтобы следующие RetryAfter обращения сразу шли в базу
+func (r *Redis) fail(err error) error {
+	if r.available() {
+		log.Printf("redis cache is unavailable for %s: %v", r.retryAfter, err)
+	}
+	r.downUntil.Store(time.Now().Add(r.retryAfter).UnixNano())
+	return err
+}
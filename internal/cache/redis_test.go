@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedis(t *testing.T, server *miniredis.Miniredis) *Redis {
+	r, err := NewRedis(RedisOptions{
+		URL:        "redis://" + server.Addr(),
+		RetryAfter: time.Minute,
+		LocalSize:  100,
+		LocalTTL:   time.Minute,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestRedisSharesValuesBetweenReplicas(t *testing.T) {
+	server := miniredis.RunT(t)
+	a := newTestRedis(t, server)
+	b := newTestRedis(t, server)
+
+	assert.NoError(t, a.Set("link:abc", []byte("value"), time.Minute))
+	value, ok, err := b.Get("link:abc")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), value)
+
+	server.FastForward(time.Minute)
+	b.local.Delete("link:abc")
+	_, ok, _ = b.Get("link:abc")
+	assert.False(t, ok)
+}
+
+func TestRedisBroadcastsInvalidation(t *testing.T) {
+	server := miniredis.RunT(t)
+	a := newTestRedis(t, server)
+	b := newTestRedis(t, server)
+	assert.Eventually(t, func() bool { return server.PubSubNumSub(a.invalidationChannel())[a.invalidationChannel()] == 2 }, time.Second, 5*time.Millisecond)
+
+	// b держит значение в локальном кэше
+	a.Set("link:abc", []byte("old"), time.Minute)
+	b.Get("link:abc")
+	assert.Equal(t, 1, b.local.Len())
+
+	assert.NoError(t, a.Delete("link:abc"))
+	assert.Eventually(t, func() bool { return b.local.Len() == 0 }, time.Second, 5*time.Millisecond)
+	assert.False(t, server.Exists("shortener:link:abc"))
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	r := newTestRedis(t, server)
+	r.Set("link:cached", []byte("value"), time.Minute)
+	server.Close()
+
+	// локальные записи продолжают работать
+	_, ok, err := r.Get("link:cached")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, _, err = r.Get("link:other")
+	assert.Error(t, err)
+	// после ошибки Redis не опрашивается до RetryAfter
+	_, _, err = r.Get("link:other")
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
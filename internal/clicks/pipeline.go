@@ -0,0 +1,208 @@
+package clicks
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/metrics"
+	"github.com/bigxxby/dream-test-task/internal/models"
+)
+
+// Политики при переполненном буфере
+const (
+	OverflowDrop  = "drop"  // событие сразу отбрасывается, редирект не ждёт
+	OverflowBlock = "block" // ждём место в буфере не дольше EnqueueTimeout, затем отбрасываем
+)
+
+// Recorder принимает клики для асинхронной записи
+type Recorder interface {
+	// Record ставит событие в очередь. false означает, что событие отброшено.
+	Record(event models.ClickEvent) bool
+}
+
+// Store записывает пачку кликов: увеличивает счётчики ссылок и сохраняет события.
+// Слайс переиспользуется после возврата, хранить его нельзя.
+type Store interface {
+	SaveClickBatch(events []models.ClickEvent) error
+}
+
+type Options struct {
+	BufferSize     int           // сколько событий может ждать записи; ограничивает память
+	BatchSize      int           // максимальный размер одной записи в базу
+	FlushInterval  time.Duration // как долго событие может ждать неполной пачки
+	OverflowPolicy string        // drop | block
+	EnqueueTimeout time.Duration // только для block
+}
+
+// Pipeline буферизует клики в памяти и пишет их в базу пачками в одной горутине
+type Pipeline struct {
+	store  Store
+	opts   Options
+	events chan models.ClickEvent
+	done   chan struct{}
+
+	// mu защищает events от записи после закрытия
+	mu     sync.RWMutex
+	closed bool
+
+	enqueued    atomic.Uint64
+	dropped     atomic.Uint64
+	flushed     atomic.Uint64
+	failed      atomic.Uint64
+	flushErrors atomic.Uint64
+}
+
+// NewPipeline проверяет настройки и запускает горутину записи
+func NewPipeline(store Store, opts Options) (*Pipeline, error) {
+	if opts.BufferSize <= 0 || opts.BatchSize <= 0 {
+		return nil, errors.New("click buffer and batch sizes must be positive")
+	}
+	if opts.FlushInterval <= 0 {
+		return nil, errors.New("click flush interval must be positive")
+	}
+	switch opts.OverflowPolicy {
+	case OverflowDrop:
+	case OverflowBlock:
+		if opts.EnqueueTimeout <= 0 {
+			return nil, errors.New("click enqueue timeout must be positive for the block policy")
+		}
+	default:
+		return nil, errors.New("unknown click overflow policy " + opts.OverflowPolicy)
+	}
+
+	p := &Pipeline{
+		store:  store,
+		opts:   opts,
+		events: make(chan models.ClickEvent, opts.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+func (p *Pipeline) Record(event models.ClickEvent) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		p.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case p.events <- event:
+		p.enqueued.Add(1)
+		return true
+	default:
+	}
+
+	if p.opts.OverflowPolicy == OverflowBlock {
+		timer := time.NewTimer(p.opts.EnqueueTimeout)
+		defer timer.Stop()
+		select {
+		case p.events <- event:
+			p.enqueued.Add(1)
+			return true
+		case <-timer.C:
+		}
+	}
+	p.dropped.Add(1)
+	return false
+}
+
+// Close перестаёт принимать клики и дожидается записи всего, что уже в буфере.
+// Если ctx истёк раньше, оставшиеся события теряются.
+func (p *Pipeline) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Pipeline) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.ClickEvent, 0, p.opts.BatchSize)
+	for {
+		select {
+		case event, ok := <-p.events:
+			if !ok {
+				p.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= p.opts.BatchSize {
+				p.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			p.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (p *Pipeline) flush(batch []models.ClickEvent) {
+	if len(batch) == 0 {
+		return
+	}
+	// Взаимные блокировки хранилище повторяет само, другие ошибки не повторяем:
+	// пока база недоступна, буфер всё равно переполнится
+	if err := p.store.SaveClickBatch(batch); err != nil {
+		p.flushErrors.Add(1)
+		p.failed.Add(uint64(len(batch)))
+		log.Printf("failed to write %d click events: %v", len(batch), err)
+		return
+	}
+	p.flushed.Add(uint64(len(batch)))
+}
+
+// Stats - счётчики конвейера с момента запуска
+type Stats struct {
+	Enqueued    uint64 `json:"enqueued"`
+	Dropped     uint64 `json:"dropped"`      // не попали в буфер
+	Flushed     uint64 `json:"flushed"`      // записаны в базу
+	Failed      uint64 `json:"failed"`       // потеряны из-за ошибки записи
+	FlushErrors uint64 `json:"flush_errors"` // неудачных записей пачек
+	Queued      int    `json:"queued"`       // ждут записи сейчас
+}
+
+func (p *Pipeline) Stats() Stats {
+	return Stats{
+		Enqueued:    p.enqueued.Load(),
+		Dropped:     p.dropped.Load(),
+		Flushed:     p.flushed.Load(),
+		Failed:      p.failed.Load(),
+		FlushErrors: p.flushErrors.Load(),
+		Queued:      len(p.events),
+	}
+}
+
+// Metrics реализует metrics.Source
+func (p *Pipeline) Metrics() []metrics.Metric {
+	stats := p.Stats()
+	return []metrics.Metric{
+		{Name: "click_events_enqueued_total", Help: "Click events accepted into the buffer.", Type: metrics.Counter, Value: float64(stats.Enqueued)},
+		{Name: "click_events_dropped_total", Help: "Click events dropped because the buffer was full or closed.", Type: metrics.Counter, Value: float64(stats.Dropped)},
+		{Name: "click_events_flushed_total", Help: "Click events written to the database.", Type: metrics.Counter, Value: float64(stats.Flushed)},
+		{Name: "click_events_failed_total", Help: "Click events lost because a batch write failed.", Type: metrics.Counter, Value: float64(stats.Failed)},
+		{Name: "click_batch_errors_total", Help: "Failed batch writes.", Type: metrics.Counter, Value: float64(stats.FlushErrors)},
+		{Name: "click_events_queued", Help: "Click events waiting in the buffer.", Type: metrics.Gauge, Value: float64(stats.Queued)},
+		{Name: "click_buffer_capacity", Help: "Size of the click buffer.", Type: metrics.Gauge, Value: float64(p.opts.BufferSize)},
+	}
+}
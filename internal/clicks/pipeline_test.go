@@ -0,0 +1,123 @@
+package clicks_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/clicks"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore запоминает размеры пачек; release, если задан, задерживает запись
+type memoryStore struct {
+	mu      sync.Mutex
+	batches []int
+	total   int
+	release chan struct{}
+}
+
+func (s *memoryStore) SaveClickBatch(events []models.ClickEvent) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, len(events))
+	s.total += len(events)
+	return nil
+}
+
+func (s *memoryStore) written() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total
+}
+
+func newEvent() models.ClickEvent {
+	id := uuid.New()
+	return models.ClickEvent{LinkID: &id, CreatedAt: time.Now()}
+}
+
+func TestPipelineFlushesFullBatches(t *testing.T) {
+	store := &memoryStore{}
+	p, err := clicks.NewPipeline(store, clicks.Options{
+		BufferSize:     100,
+		BatchSize:      10,
+		FlushInterval:  time.Hour,
+		OverflowPolicy: clicks.OverflowDrop,
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 25; i++ {
+		assert.True(t, p.Record(newEvent()))
+	}
+	assert.Eventually(t, func() bool { return store.written() == 20 }, time.Second, 5*time.Millisecond)
+
+	// остаток неполной пачки записывается при остановке
+	assert.NoError(t, p.Close(context.Background()))
+	assert.Equal(t, []int{10, 10, 5}, store.batches)
+	assert.Equal(t, uint64(25), p.Stats().Flushed)
+
+	assert.False(t, p.Record(newEvent()))
+	assert.Equal(t, uint64(1), p.Stats().Dropped)
+}
+
+func TestPipelineFlushesOnInterval(t *testing.T) {
+	store := &memoryStore{}
+	p, err := clicks.NewPipeline(store, clicks.Options{
+		BufferSize:     100,
+		BatchSize:      100,
+		FlushInterval:  10 * time.Millisecond,
+		OverflowPolicy: clicks.OverflowDrop,
+	})
+	assert.NoError(t, err)
+	defer p.Close(context.Background())
+
+	p.Record(newEvent())
+	assert.Eventually(t, func() bool { return store.written() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestPipelineDropsWhenFull(t *testing.T) {
+	for _, policy := range []string{clicks.OverflowDrop, clicks.OverflowBlock} {
+		t.Run(policy, func(t *testing.T) {
+			store := &memoryStore{release: make(chan struct{})}
+			p, err := clicks.NewPipeline(store, clicks.Options{
+				BufferSize:     2,
+				BatchSize:      1,
+				FlushInterval:  time.Hour,
+				OverflowPolicy: policy,
+				EnqueueTimeout: 10 * time.Millisecond,
+			})
+			assert.NoError(t, err)
+
+			// первое событие забирает писатель и зависает на записи, два заполняют буфер
+			assert.True(t, p.Record(newEvent()))
+			assert.Eventually(t, func() bool { return p.Stats().Queued == 0 }, time.Second, time.Millisecond)
+			assert.True(t, p.Record(newEvent()))
+			assert.True(t, p.Record(newEvent()))
+
+			assert.False(t, p.Record(newEvent()))
+			stats := p.Stats()
+			assert.Equal(t, uint64(3), stats.Enqueued)
+			assert.Equal(t, uint64(1), stats.Dropped)
+
+			close(store.release)
+			assert.NoError(t, p.Close(context.Background()))
+			assert.Equal(t, 3, store.written())
+		})
+	}
+}
+
+func TestNewPipelineValidatesOptions(t *testing.T) {
+	_, err := clicks.NewPipeline(&memoryStore{}, clicks.Options{
+		BufferSize:     1,
+		BatchSize:      1,
+		FlushInterval:  time.Second,
+		OverflowPolicy: "wait",
+	})
+	assert.Error(t, err)
+}
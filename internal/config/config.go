@@ -3,15 +3,21 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 var JwtSecret []byte
 var AppPort string
+var BaseURL string
+var IPHashSalt []byte
 
 type Config struct {
 	AppPort string
+	BaseURL string
 
 	DBUser     string
 	DBPassword string
@@ -21,6 +27,63 @@ type Config struct {
 	DBSSLMode  string
 
 	JwtSecret string
+
+	// Short id generation
+	ShortIDStrategy string // random | sequence | hashid
+	ShortIDLength   int
+	ShortIDSalt     string
+
+	// Click analytics
+	IPHashSalt string
+
+	// Click ingestion pipeline
+	ClickBufferSize     int
+	ClickBatchSize      int
+	ClickFlushInterval  time.Duration
+	ClickOverflowPolicy string // drop | block
+	ClickEnqueueTimeout time.Duration
+
+	// Short link cache on the redirect path
+	CacheEnabled     bool
+	CacheBackend     string // memory | redis
+	CacheSize        int
+	CacheTTL         time.Duration
+	CacheNegativeTTL time.Duration
+	CacheLocalTTL    time.Duration // redis: срок записей в памяти процесса
+	RedisURL         string
+
+	// Password-protected links
+	LinkAccessSecret     string
+	LinkAccessTTL        time.Duration // срок cookie после ввода пароля
+	LinkPasswordAttempts int           // попыток на ссылку и IP за LinkPasswordWindow
+	LinkPasswordWindow   time.Duration
+
+	// Link expiration policy
+	LinkDefaultTTL time.Duration            // срок ссылки без явного expires_at, 0 - бессрочно
+	LinkMaxTTL     map[string]time.Duration // максимальный срок по ролям; нет роли или 0 - без ограничения
+
+	// Prometheus metrics
+	MetricsToken string // токен для GET /metrics, пусто - метрики отключены
+
+	// Visitor location
+	GeoIPDBPath    string   // путь к базе MaxMind (.mmdb), пусто - страна не определяется
+	TrustedProxies []string // адреса и сети прокси, которым доверяем X-Forwarded-For
+
+	// Destination page metadata
+	MetadataEnabled  bool
+	MetadataTimeout  time.Duration
+	MetadataMaxBytes int // сколько байт страницы читаем
+	MetadataQueue    int // сколько фоновых загрузок может ждать
+	MetadataWorkers  int // одновременных фоновых загрузок
+
+	// Destination health checks
+	HealthCheckEnabled     bool
+	HealthCheckInterval    time.Duration // как часто берётся очередная пачка ссылок
+	HealthCheckRecheck     time.Duration // как часто проверяется каждая ссылка
+	HealthCheckBatchSize   int
+	HealthCheckConcurrency int
+	HealthCheckTimeout     time.Duration
+	HealthFailureThreshold int // неудач подряд, после которых ссылка считается сломанной
 }
 
 // SetConfig reads the configuration from a JSON file and returns a Config struct
@@ -42,6 +105,7 @@ func GetCofig() (*Config, error) {
 		DBName:     os.Getenv("DB_NAME"),
 		DBSSLMode:  os.Getenv("DB_SSL_MODE"),
 		AppPort:    os.Getenv("APP_PORT"),
+		BaseURL:    os.Getenv("APP_BASE_URL"),
 		JwtSecret:  os.Getenv("JWT_SECRET"),
 	}
 
@@ -61,7 +125,241 @@ func GetCofig() (*Config, error) {
 			return nil, fmt.Errorf("missing required configuration value for %s", key)
 		}
 	}
+	// Optional: public address used to build short urls
+	if config.BaseURL == "" {
+		config.BaseURL = "http://localhost:" + config.AppPort
+	}
+
+	// Optional: short id generation
+	var err error
+	config.ShortIDStrategy = getEnv("SHORT_ID_STRATEGY", "random")
+	config.ShortIDLength, err = getEnvInt("SHORT_ID_LENGTH", 6)
+	if err != nil {
+		return nil, err
+	}
+	config.ShortIDSalt = getEnv("SHORT_ID_SALT", config.JwtSecret)
+
+	// Optional: salt for hashing visitor ip addresses
+	config.IPHashSalt = getEnv("IP_HASH_SALT", config.JwtSecret)
+
+	// Optional: asynchronous click ingestion
+	config.ClickBufferSize, err = getEnvInt("CLICK_BUFFER_SIZE", 10000)
+	if err != nil {
+		return nil, err
+	}
+	config.ClickBatchSize, err = getEnvInt("CLICK_BATCH_SIZE", 500)
+	if err != nil {
+		return nil, err
+	}
+	config.ClickFlushInterval, err = getEnvDuration("CLICK_FLUSH_INTERVAL", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.ClickOverflowPolicy = getEnv("CLICK_OVERFLOW_POLICY", "drop")
+	config.ClickEnqueueTimeout, err = getEnvDuration("CLICK_ENQUEUE_TIMEOUT", 50*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional: short link cache
+	config.CacheEnabled, err = getEnvBool("CACHE_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	config.CacheBackend = getEnv("CACHE_BACKEND", "memory")
+	config.CacheSize, err = getEnvInt("CACHE_SIZE", 10000)
+	if err != nil {
+		return nil, err
+	}
+	config.CacheTTL, err = getEnvDuration("CACHE_TTL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	config.CacheNegativeTTL, err = getEnvDuration("CACHE_NEGATIVE_TTL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.CacheLocalTTL, err = getEnvDuration("CACHE_LOCAL_TTL", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.RedisURL = getEnv("REDIS_URL", "redis://localhost:6379/0")
+
+	// Optional: bearer token for /metrics
+	config.MetricsToken = getEnv("METRICS_TOKEN", "")
+
+	// Optional: password-protected links
+	config.LinkAccessSecret = getEnv("LINK_ACCESS_SECRET", config.JwtSecret)
+	config.LinkAccessTTL, err = getEnvDuration("LINK_ACCESS_TTL", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	config.LinkPasswordAttempts, err = getEnvInt("LINK_PASSWORD_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+	config.LinkPasswordWindow, err = getEnvDuration("LINK_PASSWORD_WINDOW", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional: link expiration policy
+	config.LinkDefaultTTL, err = getEnvDuration("LINK_DEFAULT_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	config.LinkMaxTTL, err = getEnvDurationMap("LINK_MAX_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional: visitor location
+	config.GeoIPDBPath = os.Getenv("GEOIP_DB_PATH")
+	config.TrustedProxies = getEnvList("TRUSTED_PROXIES")
+
+	// Optional: destination page metadata
+	config.MetadataEnabled, err = getEnvBool("METADATA_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	config.MetadataTimeout, err = getEnvDuration("METADATA_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.MetadataMaxBytes, err = getEnvInt("METADATA_MAX_BYTES", 512<<10)
+	if err != nil {
+		return nil, err
+	}
+	config.MetadataQueue, err = getEnvInt("METADATA_QUEUE_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	config.MetadataWorkers, err = getEnvInt("METADATA_WORKERS", 4)
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional: destination health checks
+	config.HealthCheckEnabled, err = getEnvBool("HEALTH_CHECK_ENABLED", true)
+	if err != nil {
+		return nil, err
+	}
+	config.HealthCheckInterval, err = getEnvDuration("HEALTH_CHECK_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	config.HealthCheckRecheck, err = getEnvDuration("HEALTH_CHECK_RECHECK", 6*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	config.HealthCheckBatchSize, err = getEnvInt("HEALTH_CHECK_BATCH_SIZE", 100)
+	if err != nil {
+		return nil, err
+	}
+	config.HealthCheckConcurrency, err = getEnvInt("HEALTH_CHECK_CONCURRENCY", 5)
+	if err != nil {
+		return nil, err
+	}
+	config.HealthCheckTimeout, err = getEnvDuration("HEALTH_CHECK_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	config.HealthFailureThreshold, err = getEnvInt("HEALTH_FAILURE_THRESHOLD", 2)
+	if err != nil {
+		return nil, err
+	}
+
 	JwtSecret = []byte(config.JwtSecret)
 	AppPort = config.AppPort
+	BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	IPHashSalt = []byte(config.IPHashSalt)
 	return config, nil
 }
+
+// PublicURL returns the public redirect url for the given short id
+func PublicURL(shortID string) string {
+	base := BaseURL
+	if base == "" {
+		base = "http://localhost:" + AppPort
+	}
+	return base + "/" + shortID
+}
+
+// getEnv returns the environment variable or the default value if it is empty
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvInt parses an integer environment variable, falling back to the default value
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer configuration value for %s: %w", key, err)
+	}
+	return parsed, nil
+}
+
+// getEnvDuration parses a duration environment variable such as "500ms" or "2s"
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration configuration value for %s: %w", key, err)
+	}
+	return parsed, nil
+}
+
+// getEnvList splits a comma separated environment variable, skipping empty items
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvDurationMap parses a list of durations such as "user=720h,admin=0"
+func getEnvDurationMap(key string) (map[string]time.Duration, error) {
+	parsed := make(map[string]time.Duration)
+	value := os.Getenv(key)
+	if value == "" {
+		return parsed, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, duration, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid configuration value for %s: expected name=duration, got %q", key, pair)
+		}
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration configuration value for %s: %w", key, err)
+		}
+		parsed[name] = d
+	}
+	return parsed, nil
+}
+
+// getEnvBool parses a boolean environment variable such as "true" or "0"
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean configuration value for %s: %w", key, err)
+	}
+	return parsed, nil
+}
@@ -16,7 +16,8 @@ func GetDB(cfg *config.Config) (*gorm.DB, error) {
 	)
 
 	// Подключаемся к базе данных через GORM
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError превращает нарушение уникальности в gorm.ErrDuplicatedKey, не завязывая репозитории на коды PostgreSQL
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
 	}
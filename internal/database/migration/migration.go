@@ -14,5 +14,37 @@ func Migrate(db *gorm.DB) error {
 	if err != nil {
 		return err
 	}
+	err = db.AutoMigrate(&models.LinkShare{})
+	if err != nil {
+		return err
+	}
+	err = db.AutoMigrate(&models.LinkRevision{})
+	if err != nil {
+		return err
+	}
+	err = db.AutoMigrate(&models.ClickEvent{})
+	if err != nil {
+		return err
+	}
+	err = db.AutoMigrate(&models.UTMTemplate{})
+	if err != nil {
+		return err
+	}
+	// последовательность для стратегий генерации sequence и hashid
+	err = db.Exec("CREATE SEQUENCE IF NOT EXISTS short_link_id_seq").Error
+	if err != nil {
+		return err
+	}
+	// Идентификаторы уникальны без учёта регистра: индекс закрывает гонку двух запросов с "Foo" и "foo"
+	// и ускоряет проверку IsShortIDTaken по LOWER(short_id)
+	err = db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_short_links_short_id_lower ON short_links (LOWER(short_id))").Error
+	if err != nil {
+		return err
+	}
+	// GIN-индекс для полнотекстового поиска в списке ссылок
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_short_links_search ON short_links USING GIN (" + models.LinkSearchVector + ")").Error
+	if err != nil {
+		return err
+	}
 	return nil
 }
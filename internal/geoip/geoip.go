@@ -0,0 +1,69 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Locator определяет страну по IP-адресу посетителя
+type Locator interface {
+	// Country возвращает код страны ISO 3166-1 alpha-2 или пустую строку, если страна неизвестна
+	Country(ip string) string
+}
+
+// DB - локальная база в формате MaxMind (.mmdb), например GeoLite2-Country или GeoIP2-City
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+// record - поля базы, которые нам нужны. Для адресов без страны (например, анонимных
+// прокси) используется страна регистрации сети.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// Open открывает базу по пути к файлу
+func Open(path string) (*DB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{reader: reader}, nil
+}
+
+// FromBytes открывает базу, уже загруженную в память
+func FromBytes(data []byte) (*DB, error) {
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{reader: reader}, nil
+}
+
+func (db *DB) Country(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	var rec record
+	if err := db.reader.Lookup(parsed, &rec); err != nil {
+		return ""
+	}
+	code := rec.Country.ISOCode
+	if code == "" {
+		code = rec.RegisteredCountry.ISOCode
+	}
+	return strings.ToUpper(code)
+}
+
+func (db *DB) Close() error {
+	return db.reader.Close()
+}
@@ -0,0 +1,151 @@
+package geoip_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildCountryDB собирает минимальную IPv4-базу в формате MaxMind:
+// дерево поиска с 24-битными записями, секция данных и метаданные.
+// networks - CIDR -> поля country и registered_country.
+func buildCountryDB(t *testing.T, networks map[string][2]string) []byte {
+	const empty = -1
+	type node [2]int // >= 0 - следующий узел, <= -2 - данные -(2+i), -1 - пусто
+	nodes := []node{{empty, empty}}
+
+	var data bytes.Buffer
+	var offsets []int
+	for cidr, countries := range networks {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		ones, _ := network.Mask.Size()
+		ip := network.IP.To4()
+
+		offsets = append(offsets, data.Len())
+		writeCountryRecord(&data, countries[0], countries[1])
+		ref := -(2 + len(offsets) - 1)
+
+		current := 0
+		for i := 0; i < ones; i++ {
+			bit := int(ip[i/8]>>(7-uint(i%8))) & 1
+			if i == ones-1 {
+				nodes[current][bit] = ref
+				break
+			}
+			if nodes[current][bit] == empty {
+				nodes = append(nodes, node{empty, empty})
+				nodes[current][bit] = len(nodes) - 1
+			}
+			current = nodes[current][bit]
+		}
+	}
+
+	nodeCount := len(nodes)
+	var out bytes.Buffer
+	for _, n := range nodes {
+		for _, child := range n {
+			value := nodeCount
+			switch {
+			case child >= 0:
+				value = child
+			case child <= -2:
+				value = nodeCount + 16 + offsets[-child-2]
+			}
+			out.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	writeControl(&out, 7, 8)
+	writeString(&out, "binary_format_major_version")
+	writeUint(&out, 5, 2)
+	writeString(&out, "binary_format_minor_version")
+	writeUint(&out, 5, 0)
+	writeString(&out, "build_epoch")
+	writeUint(&out, 9, 1700000000)
+	writeString(&out, "database_type")
+	writeString(&out, "Test-Country")
+	writeString(&out, "description")
+	writeControl(&out, 7, 0)
+	writeString(&out, "ip_version")
+	writeUint(&out, 5, 4)
+	writeString(&out, "node_count")
+	writeUint(&out, 6, uint64(nodeCount))
+	writeString(&out, "record_size")
+	writeUint(&out, 5, 24)
+	return out.Bytes()
+}
+
+func writeCountryRecord(buf *bytes.Buffer, country, registered string) {
+	fields := 0
+	if country != "" {
+		fields++
+	}
+	if registered != "" {
+		fields++
+	}
+	writeControl(buf, 7, fields)
+	if country != "" {
+		writeString(buf, "country")
+		writeControl(buf, 7, 1)
+		writeString(buf, "iso_code")
+		writeString(buf, country)
+	}
+	if registered != "" {
+		writeString(buf, "registered_country")
+		writeControl(buf, 7, 1)
+		writeString(buf, "iso_code")
+		writeString(buf, registered)
+	}
+}
+
+// writeControl пишет управляющий байт: тип и размер (< 29)
+func writeControl(buf *bytes.Buffer, typ int, size int) {
+	if typ > 7 {
+		buf.WriteByte(byte(size))
+		buf.WriteByte(byte(typ - 7))
+		return
+	}
+	buf.WriteByte(byte(typ<<5 | size))
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeControl(buf, 2, len(s))
+	buf.WriteString(s)
+}
+
+func writeUint(buf *bytes.Buffer, typ int, value uint64) {
+	var raw [8]byte
+	binary.BigEndian.PutUint64(raw[:], value)
+	trimmed := bytes.TrimLeft(raw[:], "\x00")
+	writeControl(buf, typ, len(trimmed))
+	buf.Write(trimmed)
+}
+
+func TestCountry(t *testing.T) {
+	db, err := geoip.FromBytes(buildCountryDB(t, map[string][2]string{
+		"81.2.69.0/24":  {"GB", "GB"},
+		"89.160.0.0/16": {"se", ""},
+		"2.125.0.0/16":  {"", "DE"},
+	}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, "GB", db.Country("81.2.69.142"))
+	assert.Equal(t, "SE", db.Country("89.160.20.112"))
+
+	// без страны адреса используется страна регистрации сети
+	assert.Equal(t, "DE", db.Country("2.125.160.216"))
+
+	assert.Equal(t, "", db.Country("10.0.0.1"))
+	assert.Equal(t, "", db.Country("not an ip"))
+	assert.Equal(t, "", db.Country(""))
+}
@@ -0,0 +1,193 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/metrics"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/bigxxby/dream-test-task/internal/safehttp"
+)
+
+// Store выбирает ссылки для проверки и сохраняет результат
+type Store interface {
+	GetLinksToCheck(checkedBefore, now time.Time, limit int) ([]models.ShortLink, error)
+	UpdateLinkHealth(link *models.ShortLink) error
+}
+
+type Options struct {
+	Interval         time.Duration // как часто берётся очередная пачка ссылок
+	Recheck          time.Duration // как часто проверяется каждая ссылка
+	BatchSize        int           // ссылок за один проход
+	Concurrency      int           // одновременных запросов
+	FailureThreshold int           // сколько неудач подряд делают ссылку сломанной
+}
+
+// Checker в фоне проверяет адреса назначения действующих ссылок
+type Checker struct {
+	store  Store
+	prober Prober
+	opts   Options
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	checked  atomic.Uint64
+	failed   atomic.Uint64
+	broken   atomic.Uint64
+	skipped  atomic.Uint64
+	runError atomic.Uint64
+}
+
+// NewChecker проверяет настройки и запускает проверку в фоне. Первая пачка берётся через Interval.
+func NewChecker(store Store, prober Prober, opts Options) (*Checker, error) {
+	if opts.Interval <= 0 || opts.Recheck <= 0 {
+		return nil, errors.New("health check interval and recheck period must be positive")
+	}
+	if opts.BatchSize <= 0 || opts.Concurrency <= 0 {
+		return nil, errors.New("health check batch size and concurrency must be positive")
+	}
+	if opts.FailureThreshold <= 0 {
+		return nil, errors.New("health check failure threshold must be positive")
+	}
+
+	c := &Checker{
+		store:  store,
+		prober: prober,
+		opts:   opts,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go c.run()
+	return c, nil
+}
+
+// Close останавливает проверку и ждёт окончания текущего прохода
+func (c *Checker) Close(ctx context.Context) error {
+	c.once.Do(func() { close(c.stop) })
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Checker) run() {
+	defer close(c.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-c.stop
+		cancel() // прерываем запросы текущего прохода
+	}()
+
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if _, err := c.CheckBatch(ctx, time.Now()); err != nil {
+				c.runError.Add(1)
+				log.Println("health check failed:", err)
+			}
+		}
+	}
+}
+
+// CheckBatch проверяет одну пачку ссылок, которые не проверялись дольше Recheck,
+// и возвращает количество проверенных
+func (c *Checker) CheckBatch(ctx context.Context, now time.Time) (int, error) {
+	links, err := c.store.GetLinksToCheck(now.Add(-c.opts.Recheck), now, c.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	var checked atomic.Int64
+	slots := make(chan struct{}, c.opts.Concurrency)
+	for i := range links {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(link *models.ShortLink) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if c.check(ctx, link) {
+				checked.Add(1)
+			}
+		}(&links[i])
+	}
+	wg.Wait()
+	return int(checked.Load()), nil
+}
+
+// check проверяет одну ссылку; false, если проверка прервана или результат не сохранён
+func (c *Checker) check(ctx context.Context, link *models.ShortLink) bool {
+	result := c.prober.Probe(ctx, link.LongLink)
+	if ctx.Err() != nil {
+		return false // остановка сервиса - не повод считать адрес нерабочим
+	}
+
+	now := time.Now()
+	wasBroken := link.Health.Broken
+	switch {
+	case errors.Is(result.Err, safehttp.ErrPrivateAddress):
+		// Адрес во внутренней сети мы не запрашиваем, но и сломанным не считаем
+		link.Health.Skip(safehttp.ErrPrivateAddress.Error(), now)
+		c.skipped.Add(1)
+	default:
+		link.Health.Record(result.Status, result.Latency, result.Err, c.opts.FailureThreshold, now)
+		if link.Health.Failures > 0 {
+			c.failed.Add(1)
+		}
+		if link.Health.Broken && !wasBroken {
+			c.broken.Add(1)
+		}
+	}
+
+	if err := c.store.UpdateLinkHealth(link); err != nil {
+		log.Printf("failed to save health of %s: %v", link.ShortId, err)
+		return false
+	}
+	c.checked.Add(1)
+	return true
+}
+
+// Stats - счётчики проверки с момента запуска
+type Stats struct {
+	Checked   uint64 `json:"checked"`    // сохранённых проверок
+	Failed    uint64 `json:"failed"`     // неудачных проверок
+	Broken    uint64 `json:"broken"`     // ссылок, ставших сломанными
+	Skipped   uint64 `json:"skipped"`    // адресов во внутренней сети
+	RunErrors uint64 `json:"run_errors"` // проходов, упавших с ошибкой базы
+}
+
+func (c *Checker) Stats() Stats {
+	return Stats{
+		Checked:   c.checked.Load(),
+		Failed:    c.failed.Load(),
+		Broken:    c.broken.Load(),
+		Skipped:   c.skipped.Load(),
+		RunErrors: c.runError.Load(),
+	}
+}
+
+// Metrics реализует metrics.Source
+func (c *Checker) Metrics() []metrics.Metric {
+	stats := c.Stats()
+	return []metrics.Metric{
+		{Name: "link_health_checks_total", Help: "Destination health checks saved.", Type: metrics.Counter, Value: float64(stats.Checked)},
+		{Name: "link_health_failures_total", Help: "Health checks where the destination did not respond or returned 404, 410 or 5xx.", Type: metrics.Counter, Value: float64(stats.Failed)},
+		{Name: "link_health_broken_total", Help: "Links flagged as broken.", Type: metrics.Counter, Value: float64(stats.Broken)},
+		{Name: "link_health_skipped_total", Help: "Destinations in private networks that were not checked.", Type: metrics.Counter, Value: float64(stats.Skipped)},
+		{Name: "link_health_run_errors_total", Help: "Health check runs that failed to load links.", Type: metrics.Counter, Value: float64(stats.RunErrors)},
+	}
+}
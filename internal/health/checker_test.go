@@ -0,0 +1,182 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/health"
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/bigxxby/dream-test-task/internal/safehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore отдаёт ссылки из памяти и запоминает результаты проверок
+type memoryStore struct {
+	mu    sync.Mutex
+	links []models.ShortLink
+}
+
+func (s *memoryStore) GetLinksToCheck(checkedBefore, now time.Time, limit int) ([]models.ShortLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.ShortLink
+	for _, link := range s.links {
+		if link.Health.CheckedAt == nil || link.Health.CheckedAt.Before(checkedBefore) {
+			due = append(due, link)
+		}
+	}
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *memoryStore) UpdateLinkHealth(link *models.ShortLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.links {
+		if s.links[i].ShortId == link.ShortId {
+			s.links[i].Health = link.Health
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) health(shortID string) models.LinkHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, link := range s.links {
+		if link.ShortId == shortID {
+			return link.Health
+		}
+	}
+	return models.LinkHealth{}
+}
+
+func TestProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head":
+			// сайт не поддерживает HEAD, но страница есть
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte("hello"))
+		case "/moved":
+			http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := health.NewClient(safehttp.Options{Timeout: time.Second, MaxRedirects: 5, AllowPrivate: true})
+
+	result := client.Probe(context.Background(), server.URL+"/ok")
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 200, result.Status)
+	assert.Greater(t, result.Latency, time.Duration(0))
+
+	assert.Equal(t, 200, client.Probe(context.Background(), server.URL+"/no-head").Status)
+	assert.Equal(t, 410, client.Probe(context.Background(), server.URL+"/moved").Status)
+	assert.Equal(t, 404, client.Probe(context.Background(), server.URL+"/missing").Status)
+
+	result = client.Probe(context.Background(), "http://127.0.0.1:1/unreachable")
+	assert.Error(t, result.Err)
+	assert.Zero(t, result.Status)
+}
+
+func TestCheckBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := &memoryStore{links: []models.ShortLink{
+		{ShortId: "ok", LongLink: server.URL + "/page"},
+		{ShortId: "missing", LongLink: server.URL + "/missing"},
+		{ShortId: "intranet", LongLink: "http://10.0.0.1/wiki"},
+	}}
+	prober := &privateAwareProber{health.NewClient(safehttp.Options{Timeout: time.Second, AllowPrivate: true})}
+	checker, err := health.NewChecker(store, prober, health.Options{
+		Interval:         time.Hour,
+		Recheck:          time.Hour,
+		BatchSize:        10,
+		Concurrency:      2,
+		FailureThreshold: 2,
+	})
+	require.NoError(t, err)
+	defer checker.Close(context.Background())
+
+	checked, err := checker.CheckBatch(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 3, checked)
+
+	ok := store.health("ok")
+	assert.Equal(t, 204, ok.Status)
+	assert.NotNil(t, ok.CheckedAt)
+	assert.False(t, ok.Broken)
+
+	// одна неудача - ещё не поломка
+	missing := store.health("missing")
+	assert.Equal(t, 404, missing.Status)
+	assert.Equal(t, 1, missing.Failures)
+	assert.False(t, missing.Broken)
+
+	intranet := store.health("intranet")
+	assert.Equal(t, safehttp.ErrPrivateAddress.Error(), intranet.Error)
+	assert.False(t, intranet.Broken)
+
+	// недавно проверенные ссылки пропускаются до истечения Recheck
+	checked, err = checker.CheckBatch(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, checked)
+
+	checked, err = checker.CheckBatch(context.Background(), time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, checked)
+	assert.True(t, store.health("missing").Broken)
+	assert.False(t, store.health("ok").Broken)
+
+	stats := checker.Stats()
+	assert.Equal(t, uint64(6), stats.Checked)
+	assert.Equal(t, uint64(2), stats.Failed)
+	assert.Equal(t, uint64(1), stats.Broken)
+	assert.Equal(t, uint64(2), stats.Skipped)
+}
+
+// privateAwareProber пропускает httptest на 127.0.0.1, но отвечает ErrPrivateAddress для 10.0.0.0/8,
+// как настоящий клиент без AllowPrivate
+type privateAwareProber struct {
+	*health.Client
+}
+
+func (p *privateAwareProber) Probe(ctx context.Context, rawURL string) health.Result {
+	if rawURL == "http://10.0.0.1/wiki" {
+		return health.Result{Err: errors.Join(errors.New("dial tcp 10.0.0.1:80"), safehttp.ErrPrivateAddress)}
+	}
+	return p.Client.Probe(ctx, rawURL)
+}
+
+func TestNewCheckerValidatesOptions(t *testing.T) {
+	_, err := health.NewChecker(&memoryStore{}, nil, health.Options{Interval: time.Minute, Recheck: time.Hour, BatchSize: 10, Concurrency: 1})
+	assert.EqualError(t, err, "health check failure threshold must be positive")
+
+	_, err = health.NewChecker(&memoryStore{}, nil, health.Options{Recheck: time.Hour, BatchSize: 10, Concurrency: 1, FailureThreshold: 1})
+	assert.Error(t, err)
+}
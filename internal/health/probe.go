@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/bigxxby/dream-test-task/internal/safehttp"
+)
+
+// Result - ответ адреса назначения. Err заполнен, если ответа не было.
+type Result struct {
+	Status  int
+	Latency time.Duration
+	Err     error
+}
+
+// Prober проверяет, отвечает ли адрес назначения
+type Prober interface {
+	Probe(ctx context.Context, rawURL string) Result
+}
+
+// userAgent - часть сайтов отвечает 403 на запросы без User-Agent
+const userAgent = "dream-shortener/1.0 (+link health check)"
+
+// maxDrainBytes - сколько тела ответа GET дочитываем, чтобы переиспользовать соединение
+const maxDrainBytes = 64 << 10
+
+// Client проверяет адреса через safehttp: сначала HEAD, а если он не удался - GET,
+// потому что многие сайты не поддерживают HEAD или отвечают на него иначе.
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(opts safehttp.Options) *Client {
+	return &Client{http: safehttp.NewClient(opts)}
+}
+
+func (c *Client) Probe(ctx context.Context, rawURL string) Result {
+	result := c.do(ctx, http.MethodHead, rawURL)
+	if result.Err == nil && result.Status != 405 && result.Status != 501 && !models.IsBrokenStatus(result.Status) {
+		return result
+	}
+	if ctx.Err() != nil {
+		return result
+	}
+	return c.do(ctx, http.MethodGet, rawURL)
+}
+
+func (c *Client) do(ctx context.Context, method, rawURL string) Result {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return Result{Err: err}
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return Result{Latency: latency, Err: err}
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	resp.Body.Close()
+	return Result{Status: resp.StatusCode, Latency: latency}
+}
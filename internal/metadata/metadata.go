@@ -0,0 +1,243 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bigxxby/dream-test-task/internal/safehttp"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// Ограничения на сохраняемые значения
+const (
+	MaxTitleLength       = 512
+	MaxDescriptionLength = 1024
+	MaxURLLength         = 2048
+)
+
+// ErrNotHTML - по адресу не HTML-страница
+var ErrNotHTML = errors.New("destination is not an html page")
+
+// Page - метаданные страницы назначения. Пустое поле означает, что значение не найдено.
+type Page struct {
+	Title       string
+	Description string
+	FaviconURL  string
+	Image       string // og:image
+}
+
+// Fetcher загружает метаданные страницы по адресу
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*Page, error)
+}
+
+type Options struct {
+	Timeout      time.Duration // на весь запрос вместе с редиректами
+	MaxBytes     int64         // сколько байт страницы читаем; заголовки обычно в первых килобайтах
+	MaxRedirects int
+	UserAgent    string
+	AllowPrivate bool // разрешить адреса внутренней сети; только для тестов
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Timeout:      5 * time.Second,
+		MaxBytes:     512 << 10,
+		MaxRedirects: 5,
+		UserAgent:    "dream-shortener/1.0 (+link preview)",
+	}
+}
+
+// Client загружает страницы по HTTP через safehttp: адреса внутренней сети не запрашиваются
+type Client struct {
+	http *http.Client
+	opts Options
+}
+
+func NewClient(opts Options) *Client {
+	client := safehttp.NewClient(safehttp.Options{
+		Timeout:      opts.Timeout,
+		MaxRedirects: opts.MaxRedirects,
+		AllowPrivate: opts.AllowPrivate,
+	})
+	return &Client{http: client, opts: opts}
+}
+
+func (c *Client) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", target.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if errors.Is(err, safehttp.ErrPrivateAddress) {
+			return nil, safehttp.ErrPrivateAddress
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("destination responded with status %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); contentType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTML
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, c.opts.MaxBytes), contentType)
+	if err != nil {
+		return nil, err
+	}
+	// После редиректов относительные адреса считаются от итоговой страницы
+	return Parse(body, resp.Request.URL), nil
+}
+
+// Parse читает заголовок, описание, иконку и og:image из <head> страницы.
+// Open Graph важнее обычных тегов. Без <link rel="icon"> иконкой считается /favicon.ico.
+func Parse(r io.Reader, base *url.URL) *Page {
+	var (
+		page                 Page
+		title, description   string
+		ogTitle, ogDesc      string
+		icon, shortcutIcon   string
+		inTitle, titleParsed bool
+	)
+	origin := base
+
+	tokenizer := html.NewTokenizer(r)
+loop:
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			break loop // конец документа или лимита
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle, titleParsed = false, true
+			case "head":
+				break loop
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+			switch string(name) {
+			case "body":
+				break loop
+			case "title":
+				inTitle = !titleParsed && tokenType == html.StartTagToken
+			case "base":
+				if href, err := base.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+					base = href
+				}
+			case "meta":
+				key := strings.ToLower(attrs["property"])
+				if key == "" {
+					key = strings.ToLower(attrs["name"])
+				}
+				content := attrs["content"]
+				switch key {
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDesc = content
+				case "description":
+					description = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if page.Image == "" {
+						page.Image = content
+					}
+				}
+			case "link":
+				// rel="icon" важнее устаревшего rel="shortcut icon"
+				rels := strings.Fields(strings.ToLower(attrs["rel"]))
+				switch {
+				case !slices.Contains(rels, "icon"):
+				case slices.Contains(rels, "shortcut"):
+					if shortcutIcon == "" {
+						shortcutIcon = attrs["href"]
+					}
+				case icon == "":
+					icon = attrs["href"]
+				}
+			}
+		}
+	}
+
+	page.Title = clean(firstNonEmpty(ogTitle, title), MaxTitleLength)
+	page.Description = clean(firstNonEmpty(ogDesc, description), MaxDescriptionLength)
+	page.Image = resolve(base, page.Image)
+	page.FaviconURL = resolve(base, firstNonEmpty(icon, shortcutIcon))
+	if page.FaviconURL == "" {
+		page.FaviconURL = resolve(origin, "/favicon.ico")
+	}
+	return &page
+}
+
+// resolve превращает ссылку страницы в абсолютный http(s) адрес; остальное отбрасывается
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return ""
+	}
+	value := resolved.String()
+	if len(value) > MaxURLLength {
+		return ""
+	}
+	return value
+}
+
+// clean схлопывает пробелы и обрезает строку до limit символов
+func clean(value string, limit int) string {
+	value = strings.Join(strings.Fields(strings.ToValidUTF8(value, "")), " ")
+	if utf8.RuneCountInString(value) <= limit {
+		return value
+	}
+	runes := []rune(value)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
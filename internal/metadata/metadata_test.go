@@ -0,0 +1,132 @@
+package metadata_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/metadata"
+	"github.com/bigxxby/dream-test-task/internal/safehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>
+    Spring   sale &amp; more
+  </title>
+  <meta name="description" content="Everything is 50% off">
+  <meta property="og:image" content="/img/cover.png">
+  <link rel="shortcut icon" href="/static/old.ico">
+  <link rel="icon" type="image/png" href="icons/favicon.png">
+</head>
+<body><title>not the title</title></body>
+</html>`
+
+func testOptions() metadata.Options {
+	opts := metadata.DefaultOptions()
+	opts.AllowPrivate = true // httptest слушает 127.0.0.1
+	return opts
+}
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blog/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articlePage))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/blog/post", http.StatusFound)
+	})
+	mux.HandleFunc("/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := metadata.NewClient(testOptions())
+
+	// относительные адреса считаются от итоговой страницы после редиректа
+	page, err := client.Fetch(context.Background(), server.URL+"/moved")
+	require.NoError(t, err)
+	assert.Equal(t, "Spring sale & more", page.Title)
+	assert.Equal(t, "Everything is 50% off", page.Description)
+	assert.Equal(t, server.URL+"/blog/icons/favicon.png", page.FaviconURL)
+	assert.Equal(t, server.URL+"/img/cover.png", page.Image)
+
+	_, err = client.Fetch(context.Background(), server.URL+"/report.pdf")
+	assert.ErrorIs(t, err, metadata.ErrNotHTML)
+
+	_, err = client.Fetch(context.Background(), server.URL+"/missing")
+	assert.EqualError(t, err, "destination responded with status 404")
+
+	_, err = client.Fetch(context.Background(), "ftp://example.com/file")
+	assert.Error(t, err)
+}
+
+func TestFetchLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><!--" + strings.Repeat("x", 4096) + "--><title>Too far</title></head></html>"))
+	}))
+	defer server.Close()
+
+	// всё, что дальше MaxBytes, не читается
+	opts := testOptions()
+	opts.MaxBytes = 1024
+	page, err := metadata.NewClient(opts).Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Empty(t, page.Title)
+	assert.Equal(t, server.URL+"/favicon.ico", page.FaviconURL)
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	requested := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		w.Write([]byte("<title>secret</title>"))
+	}))
+	defer internal.Close()
+
+	client := metadata.NewClient(metadata.DefaultOptions())
+	_, err := client.Fetch(context.Background(), internal.URL)
+	assert.ErrorIs(t, err, safehttp.ErrPrivateAddress)
+
+	// имя, которое разрешается в локальный адрес, тоже блокируется
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(internal.URL, "http://"))
+	_, err = client.Fetch(context.Background(), "http://localhost:"+port)
+	assert.ErrorIs(t, err, safehttp.ErrPrivateAddress)
+	assert.False(t, requested)
+}
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("https://example.com/a/b")
+
+	page := metadata.Parse(strings.NewReader(`<html><head>
+		<base href="https://cdn.example.com/assets/">
+		<title>Plain title</title>
+		<meta property="og:title" content="Open Graph title">
+		<meta property="og:description" content="OG description">
+		<meta name="description" content="Meta description">
+		<meta property="og:image" content="javascript:alert(1)">
+		<link rel="apple-touch-icon" href="touch.png">
+	</head></html>`), base)
+	assert.Equal(t, "Open Graph title", page.Title)
+	assert.Equal(t, "OG description", page.Description)
+	assert.Empty(t, page.Image)
+	assert.Equal(t, "https://example.com/favicon.ico", page.FaviconURL)
+
+	long := strings.Repeat("я", metadata.MaxTitleLength+10)
+	page = metadata.Parse(strings.NewReader("<title>"+long+"</title>"), base)
+	assert.Equal(t, metadata.MaxTitleLength, len([]rune(page.Title)))
+	assert.True(t, strings.HasSuffix(page.Title, "…"))
+}
@@ -0,0 +1,144 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bigxxby/dream-test-task/internal/metrics"
+)
+
+// Task - фоновая загрузка метаданных. ctx отменяется при остановке очереди.
+type Task func(ctx context.Context)
+
+// Scheduler принимает загрузки метаданных для выполнения в фоне
+type Scheduler interface {
+	// Schedule ставит задачу в очередь. false означает, что очередь заполнена или остановлена
+	// и задача отброшена.
+	Schedule(task Task) bool
+}
+
+type QueueOptions struct {
+	Size    int // сколько загрузок может ждать; ограничивает память
+	Workers int // одновременных загрузок
+}
+
+// Queue выполняет загрузки метаданных фиксированным числом горутин.
+// Метаданные не обязательны для работы ссылки, поэтому при заполненной очереди
+// задача отбрасывается, а не задерживает ответ API.
+type Queue struct {
+	tasks  chan Task
+	stop   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu защищает tasks от записи после остановки
+	mu     sync.RWMutex
+	closed bool
+
+	scheduled atomic.Uint64
+	dropped   atomic.Uint64
+	completed atomic.Uint64
+}
+
+// NewQueue проверяет настройки и запускает горутины загрузки
+func NewQueue(opts QueueOptions) (*Queue, error) {
+	if opts.Size <= 0 || opts.Workers <= 0 {
+		return nil, errors.New("metadata queue size and workers must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		tasks:  make(chan Task, opts.Size),
+		stop:   make(chan struct{}),
+		cancel: cancel,
+	}
+	q.wg.Add(opts.Workers)
+	for i := 0; i < opts.Workers; i++ {
+		go q.run(ctx)
+	}
+	return q, nil
+}
+
+func (q *Queue) Schedule(task Task) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		q.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case q.tasks <- task:
+		q.scheduled.Add(1)
+		return true
+	default:
+		q.dropped.Add(1)
+		return false
+	}
+}
+
+// Close перестаёт принимать задачи, прерывает текущие загрузки и ждёт остановки горутин.
+// Задачи, которые ещё ждут в очереди, отбрасываются: метаданные можно обновить вручную.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.stop)
+		q.cancel()
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) run(ctx context.Context) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case task := <-q.tasks:
+			task(ctx)
+			q.completed.Add(1)
+		}
+	}
+}
+
+// QueueStats - счётчики очереди с момента запуска
+type QueueStats struct {
+	Scheduled uint64 `json:"scheduled"`
+	Dropped   uint64 `json:"dropped"`   // очередь была заполнена или остановлена
+	Completed uint64 `json:"completed"` // выполнены, успешно или с ошибкой
+	Queued    int    `json:"queued"`    // ждут выполнения сейчас
+}
+
+func (q *Queue) Stats() QueueStats {
+	return QueueStats{
+		Scheduled: q.scheduled.Load(),
+		Dropped:   q.dropped.Load(),
+		Completed: q.completed.Load(),
+		Queued:    len(q.tasks),
+	}
+}
+
+func (q *Queue) Metrics() []metrics.Metric {
+	stats := q.Stats()
+	return []metrics.Metric{
+		{Name: "link_metadata_scheduled_total", Help: "Metadata fetches queued.", Type: metrics.Counter, Value: float64(stats.Scheduled)},
+		{Name: "link_metadata_dropped_total", Help: "Metadata fetches dropped because the queue was full or stopped.", Type: metrics.Counter, Value: float64(stats.Dropped)},
+		{Name: "link_metadata_completed_total", Help: "Metadata fetches finished, successfully or not.", Type: metrics.Counter, Value: float64(stats.Completed)},
+		{Name: "link_metadata_queued", Help: "Metadata fetches waiting in the queue.", Type: metrics.Gauge, Value: float64(stats.Queued)},
+	}
+}
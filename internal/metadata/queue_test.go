@@ -0,0 +1,84 @@
+package metadata_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueRunsTasks(t *testing.T) {
+	q, err := metadata.NewQueue(metadata.QueueOptions{Size: 10, Workers: 2})
+	require.NoError(t, err)
+	defer q.Close(context.Background())
+
+	done := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		assert.True(t, q.Schedule(func(ctx context.Context) { done <- i }))
+	}
+	for i := 0; i < 5; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("task was not run")
+		}
+	}
+	assert.Equal(t, uint64(5), q.Stats().Scheduled)
+}
+
+func TestQueueDropsWhenFull(t *testing.T) {
+	q, err := metadata.NewQueue(metadata.QueueOptions{Size: 1, Workers: 1})
+	require.NoError(t, err)
+
+	// единственная горутина занята, в очереди помещается одна задача
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.True(t, q.Schedule(func(ctx context.Context) {
+		close(started)
+		<-release
+	}))
+	<-started
+	assert.True(t, q.Schedule(func(ctx context.Context) {}))
+	assert.False(t, q.Schedule(func(ctx context.Context) {}))
+	assert.Equal(t, uint64(1), q.Stats().Dropped)
+
+	close(release)
+	assert.NoError(t, q.Close(context.Background()))
+}
+
+func TestQueueCloseCancelsRunningTasks(t *testing.T) {
+	q, err := metadata.NewQueue(metadata.QueueOptions{Size: 1, Workers: 1})
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	require.True(t, q.Schedule(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	}))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, q.Close(ctx))
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("running task was not cancelled")
+	}
+
+	// после остановки задачи не принимаются
+	assert.False(t, q.Schedule(func(ctx context.Context) {}))
+	assert.NoError(t, q.Close(context.Background()))
+}
+
+func TestQueueOptions(t *testing.T) {
+	_, err := metadata.NewQueue(metadata.QueueOptions{Size: 0, Workers: 1})
+	assert.Error(t, err)
+	_, err = metadata.NewQueue(metadata.QueueOptions{Size: 1, Workers: 0})
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Типы метрик в формате Prometheus
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+)
+
+// Metric - одно значение для экспорта
+type Metric struct {
+	Name  string
+	Help  string
+	Type  string
+	Value float64
+}
+
+// Source - компонент, который отдаёт свои метрики
+type Source interface {
+	Metrics() []Metric
+}
+
+// Handler отдаёт метрики всех источников в текстовом формате Prometheus
+func Handler(sources ...Source) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var sb strings.Builder
+		for _, source := range sources {
+			for _, m := range source.Metrics() {
+				fmt.Fprintf(&sb, "# HELP %s %s\n", m.Name, m.Help)
+				fmt.Fprintf(&sb, "# TYPE %s %s\n", m.Name, m.Type)
+				fmt.Fprintf(&sb, "%s %s\n", m.Name, strconv.FormatFloat(m.Value, 'f', -1, 64))
+			}
+		}
+		ctx.Data(200, "text/plain; version=0.0.4; charset=utf-8", []byte(sb.String()))
+	}
+}
+
+// RequireToken пропускает к метрикам только запросы с заголовком "Authorization: Bearer <token>".
+// Метрики раскрывают внутреннее состояние кэша и очередей, поэтому публиковать их всем нельзя.
+func RequireToken(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(ctx *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(ctx.GetHeader("Authorization")), expected) != 1 {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type source []metrics.Metric
+
+func (s source) Metrics() []metrics.Metric {
+	return s
+}
+
+func TestHandlerRequiresToken(t *testing.T) {
+	router := gin.New()
+	router.GET("/metrics", metrics.RequireToken("s3cret"), metrics.Handler(source{
+		{Name: "clicks_total", Help: "Clicks.", Type: metrics.Counter, Value: 3},
+	}))
+
+	for _, header := range []string{"", "s3cret", "Bearer wrong", "Bearer s3cret2"} {
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+		assert.NotContains(t, w.Body.String(), "clicks_total", header)
+	}
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "# TYPE clicks_total counter\nclicks_total 3\n")
+}
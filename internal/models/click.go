@@ -0,0 +1,146 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ClickEvent - один переход по короткой ссылке
+type ClickEvent struct {
+	ID        *uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	LinkID    *uuid.UUID `json:"link_id" gorm:"type:uuid;not null;index:idx_click_link_time"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;index:idx_click_link_time"`
+	Referrer  string     `json:"referrer" gorm:"size:255"` // только хост
+	Browser   string     `json:"browser" gorm:"size:64"`
+	OS        string     `json:"os" gorm:"size:64"`
+	Device    string     `json:"device" gorm:"size:16"` // desktop, mobile, tablet, bot, unknown
+	IPHash    string     `json:"-" gorm:"size:64"`      // HMAC адреса, сам адрес не храним
+	Language  string     `json:"language" gorm:"size:35"`
+	Country   string     `json:"country" gorm:"size:2"`  // ISO 3166-1 alpha-2, пусто если неизвестна
+	Rule      string     `json:"rule" gorm:"size:64"`    // сработавшее правило TargetRules или geo:<страна> для GeoTargets, пусто - адрес по умолчанию
+	Variant   string     `json:"variant" gorm:"size:32"` // вариант A/B-теста, пусто - ссылка без вариантов или сработало правило
+	Source    string     `json:"source" gorm:"size:16"`  // qr - переход по QR-коду, пусто - обычный переход
+}
+
+// Источники переходов
+const (
+	ClickSourceQR = "qr"
+	// QRMarkerParam - параметр, которым помечен адрес в QR-коде; в адрес назначения не передаётся
+	QRMarkerParam = "qr"
+	// ContinueParam - отметка перехода со страницы предпросмотра; в адрес назначения не передаётся
+	ContinueParam = "continue"
+)
+
+// StripMarker убирает из строки запроса отметку param=1, которую добавляет сам сервис
+// (QR-код или страница предпросмотра), сохраняя порядок остальных параметров.
+// Параметр с другим значением принадлежит адресу назначения и остаётся.
+// Возвращает true, если отметка была.
+func StripMarker(rawQuery, param string) (string, bool) {
+	if rawQuery == "" {
+		return "", false
+	}
+	marker := param + "=1"
+	found := false
+	kept := make([]string, 0, strings.Count(rawQuery, "&")+1)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == marker {
+			found = true
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&"), found
+}
+
+// Интервалы агрегации кликов
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// Разрезы статистики: имя в ответе -> колонка click_events
+var ClickBreakdownFields = map[string]string{
+	"referrer": "referrer",
+	"browser":  "browser",
+	"os":       "os",
+	"device":   "device",
+	"country":  "country",
+	"rule":     "rule",
+	"variant":  "variant",
+	"source":   "source",
+}
+
+// ClickBucket - количество кликов за один интервал
+type ClickBucket struct {
+	Time     time.Time `json:"time"`
+	Clicks   int64     `json:"clicks"`
+	Visitors int64     `json:"visitors"` // уникальные по хэшу IP
+}
+
+// ClickBreakdownItem - количество кликов для одного значения разреза
+type ClickBreakdownItem struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+// VariantClicks - переходы на один вариант A/B-теста за период
+type VariantClicks struct {
+	Name     string  `json:"name"`
+	URL      string  `json:"url"`
+	Weight   int     `json:"weight"`
+	Clicks   int64   `json:"clicks"`
+	Visitors int64   `json:"visitors"`
+	Share    float64 `json:"share"` // доля кликов варианта среди всех вариантов, 0..1
+}
+
+// ClickTimeSeries - статистика переходов по ссылке за период
+type ClickTimeSeries struct {
+	From       time.Time                       `json:"from"`
+	To         time.Time                       `json:"to"`
+	Interval   string                          `json:"interval"`
+	Timezone   string                          `json:"timezone"`
+	Total      int64                           `json:"total"`
+	Buckets    []ClickBucket                   `json:"buckets"`
+	Breakdowns map[string][]ClickBreakdownItem `json:"breakdowns"`
+	Variants   []VariantClicks                 `json:"variants,omitempty"` // все варианты ссылки, включая без кликов
+}
+
+// TruncateToInterval возвращает начало интервала, в который попадает t, в часовом поясе t.
+// Недели начинаются с понедельника.
+func TruncateToInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case IntervalWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		offset := (int(day.Weekday()) + 6) % 7 // дней с понедельника
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// NextInterval возвращает начало следующего интервала
+func NextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return t.Add(time.Hour)
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+func (u *ClickEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	new := uuid.New()
+	u.ID = &new
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+	return
+}
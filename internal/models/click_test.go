@@ -0,0 +1,87 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateToInterval(t *testing.T) {
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	tests := []struct {
+		name     string
+		time     time.Time
+		interval string
+		want     time.Time
+	}{
+		{"hour", time.Date(2024, 3, 5, 14, 42, 7, 0, moscow), models.IntervalHour, time.Date(2024, 3, 5, 14, 0, 0, 0, moscow)},
+		{"day", time.Date(2024, 3, 5, 0, 30, 0, 0, moscow), models.IntervalDay, time.Date(2024, 3, 5, 0, 0, 0, 0, moscow)},
+		{"day in local zone, not utc", time.Date(2024, 3, 5, 1, 0, 0, 0, moscow), models.IntervalDay, time.Date(2024, 3, 5, 0, 0, 0, 0, moscow)},
+		{"week from wednesday", time.Date(2024, 3, 6, 10, 0, 0, 0, moscow), models.IntervalWeek, time.Date(2024, 3, 4, 0, 0, 0, 0, moscow)},
+		{"week from monday", time.Date(2024, 3, 4, 0, 0, 0, 0, moscow), models.IntervalWeek, time.Date(2024, 3, 4, 0, 0, 0, 0, moscow)},
+		{"week from sunday", time.Date(2024, 3, 10, 23, 59, 0, 0, moscow), models.IntervalWeek, time.Date(2024, 3, 4, 0, 0, 0, 0, moscow)},
+		{"week across month", time.Date(2024, 3, 1, 12, 0, 0, 0, moscow), models.IntervalWeek, time.Date(2024, 2, 26, 0, 0, 0, 0, moscow)},
+		{"day with spring dst", time.Date(2024, 3, 31, 15, 0, 0, 0, berlin), models.IntervalDay, time.Date(2024, 3, 31, 0, 0, 0, 0, berlin)},
+		{"hour after spring dst", time.Date(2024, 3, 31, 3, 30, 0, 0, berlin), models.IntervalHour, time.Date(2024, 3, 31, 3, 0, 0, 0, berlin)},
+		{"week with autumn dst", time.Date(2024, 10, 27, 20, 0, 0, 0, berlin), models.IntervalWeek, time.Date(2024, 10, 21, 0, 0, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := models.TruncateToInterval(tt.time, tt.interval)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+			assert.Equal(t, tt.time.Location(), got.Location())
+		})
+	}
+}
+
+func TestNextInterval(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		interval string
+		want     time.Time
+		length   time.Duration
+	}{
+		{"hour", time.Date(2024, 3, 5, 14, 0, 0, 0, berlin), models.IntervalHour, time.Date(2024, 3, 5, 15, 0, 0, 0, berlin), time.Hour},
+		// в 02:00 часы переводятся на 03:00, несуществующего часа нет в ряду
+		{"hour before spring dst", time.Date(2024, 3, 31, 1, 0, 0, 0, berlin), models.IntervalHour, time.Date(2024, 3, 31, 3, 0, 0, 0, berlin), time.Hour},
+		{"day", time.Date(2024, 3, 5, 0, 0, 0, 0, berlin), models.IntervalDay, time.Date(2024, 3, 6, 0, 0, 0, 0, berlin), 24 * time.Hour},
+		// сутки перевода часов короче или длиннее, но начинаются в полночь
+		{"day with spring dst", time.Date(2024, 3, 31, 0, 0, 0, 0, berlin), models.IntervalDay, time.Date(2024, 4, 1, 0, 0, 0, 0, berlin), 23 * time.Hour},
+		{"day with autumn dst", time.Date(2024, 10, 27, 0, 0, 0, 0, berlin), models.IntervalDay, time.Date(2024, 10, 28, 0, 0, 0, 0, berlin), 25 * time.Hour},
+		{"week with autumn dst", time.Date(2024, 10, 21, 0, 0, 0, 0, berlin), models.IntervalWeek, time.Date(2024, 10, 28, 0, 0, 0, 0, berlin), 7*24*time.Hour + time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := models.NextInterval(tt.start, tt.interval)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+			assert.Equal(t, tt.length, got.Sub(tt.start))
+		})
+	}
+}
+
+func TestStripMarker(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+		found bool
+	}{
+		{"", "", false},
+		{"qr=1", "", true},
+		{"ref=a&qr=1&b=2", "ref=a&b=2", true},
+		// значение, которое сервис не добавляет, принадлежит адресу назначения
+		{"qr=wifi&ref=a", "qr=wifi&ref=a", false},
+		{"qrcode=1", "qrcode=1", false},
+	}
+	for _, tt := range tests {
+		query, found := models.StripMarker(tt.query, models.QRMarkerParam)
+		assert.Equal(t, tt.want, query, tt.query)
+		assert.Equal(t, tt.found, found, tt.query)
+	}
+}
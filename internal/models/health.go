@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// LinkHealth - результат последней проверки адреса назначения.
+// В таблице ссылок хранится колонками health_status, health_checked_at и т.д.
+type LinkHealth struct {
+	Status    int        `json:"status,omitempty"`                                                         // HTTP-код ответа, 0 - ответа не было
+	LatencyMs int64      `json:"latency_ms,omitempty"`                                                     // время ответа
+	Error     string     `json:"error,omitempty" gorm:"size:255"`                                          // сетевая ошибка, если ответа не было
+	CheckedAt *time.Time `json:"checked_at,omitempty" gorm:"index:idx_short_links_health_checked_at"`      // nil - адрес ещё не проверялся
+	Failures  int        `json:"failures,omitempty" gorm:"not null;default:0"`                             // неудачных проверок подряд
+	Broken    bool       `json:"broken" gorm:"not null;default:false;index:idx_short_links_health_broken"` // адрес недоступен Failures раз подряд
+}
+
+// LinkHealthColumns - колонки проверки адреса; их пишет только проверка, а не правка ссылки
+var LinkHealthColumns = []string{"health_status", "health_latency_ms", "health_error", "health_checked_at", "health_failures", "health_broken"}
+
+// IsBrokenStatus сообщает, что код ответа означает нерабочий адрес. 401, 403 и 429 не считаются
+// поломкой: страница существует, но закрыта от проверки.
+func IsBrokenStatus(status int) bool {
+	return status == 404 || status == 410 || status >= 500
+}
+
+// Record сохраняет результат проверки. Адрес помечается сломанным после threshold неудач подряд,
+// чтобы разовый сбой сайта не давал ложных срабатываний.
+func (h *LinkHealth) Record(status int, latency time.Duration, checkErr error, threshold int, now time.Time) {
+	h.Status = status
+	h.LatencyMs = latency.Milliseconds()
+	h.Error = ""
+	if checkErr != nil {
+		h.Error = checkErr.Error()
+		if len(h.Error) > 255 {
+			h.Error = h.Error[:255]
+		}
+	}
+	h.CheckedAt = &now
+
+	if checkErr != nil || IsBrokenStatus(status) {
+		h.Failures++
+	} else {
+		h.Failures = 0
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+	h.Broken = h.Failures >= threshold
+}
+
+// Skip отмечает, что адрес не проверялся по причине reason (например, он ведёт во внутреннюю сеть).
+// Такая ссылка не считается сломанной.
+func (h *LinkHealth) Skip(reason string, now time.Time) {
+	*h = LinkHealth{Error: reason, CheckedAt: &now}
+}
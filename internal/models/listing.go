@@ -0,0 +1,142 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Сортировка списка ссылок
+const (
+	LinkSortCreatedAt = "created_at"
+	LinkSortClicks    = "clicks"
+	LinkSortLastClick = "last_click"
+)
+
+// Фильтр по состоянию ссылки. Состояния не пересекаются: каждая ссылка находится ровно в одном,
+// проверяются в порядке expired, scheduled, exhausted, active.
+const (
+	LinkStatusExpired   = "expired"   // срок действия истёк
+	LinkStatusScheduled = "scheduled" // не истекла, но ActiveFrom ещё не наступил
+	LinkStatusExhausted = "exhausted" // не истекла, запущена, но лимит переходов исчерпан
+	LinkStatusActive    = "active"    // не истекла, уже запущена и лимит переходов не исчерпан
+)
+
+// LinkSearchVector - выражение tsvector для полнотекстового поиска по адресу назначения, идентификатору
+// и заголовку. Всё, кроме букв и цифр, заменяется пробелами, чтобы части адреса искались как отдельные слова.
+// По этому же выражению построен GIN-индекс, поэтому запрос должен использовать его без изменений.
+const LinkSearchVector = `to_tsvector('simple', regexp_replace(long_link || ' ' || short_id || ' ' || coalesce(title, ''), '[^[:alnum:]]+', ' ', 'g'))`
+
+const (
+	DefaultLinkPageSize = 50
+	MaxLinkPageSize     = 200
+)
+
+// NeverClicked - значение last_click для ссылок без переходов при сортировке и в курсоре:
+// такие ссылки идут в конце при сортировке по убыванию
+var NeverClicked = time.Unix(0, 0).UTC()
+
+// LinkQuery - страница списка ссылок пользователя: фильтры, сортировка и курсор
+type LinkQuery struct {
+	Sort        string // LinkSort*
+	Desc        bool
+	Limit       int
+	After       *LinkCursor // nil - первая страница
+	Status      string      // LinkStatus*, пусто - все
+	Tag         string
+	CreatedFrom *time.Time // включительно
+	CreatedTo   *time.Time // не включительно
+	Domain      string     // домен адреса назначения вместе с поддоменами
+	Search      []string   // слова из букв и цифр в нижнем регистре; каждое ищется по префиксу в LinkSearchVector
+	Now         time.Time  // момент, относительно которого определяется состояние
+}
+
+// Status возвращает состояние ссылки в момент now, одно из LinkStatus*
+func (u *ShortLink) Status(now time.Time) string {
+	switch {
+	case u.ExpiresAt != nil && !now.Before(*u.ExpiresAt):
+		return LinkStatusExpired
+	case !u.IsActiveAt(now):
+		return LinkStatusScheduled
+	case u.ClicksExhausted():
+		return LinkStatusExhausted
+	default:
+		return LinkStatusActive
+	}
+}
+
+// LinkCursor - позиция последней ссылки страницы: значение поля сортировки и ID
+// для однозначного порядка ссылок с одинаковым значением
+type LinkCursor struct {
+	Sort   string     `json:"s"`
+	Clicks int        `json:"c,omitempty"`
+	Time   *time.Time `json:"t,omitempty"`
+	ID     uuid.UUID  `json:"id"`
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// NewLinkCursor запоминает позицию ссылки в списке, отсортированном по sort
+func NewLinkCursor(link *ShortLink, sort string) *LinkCursor {
+	cursor := &LinkCursor{Sort: sort}
+	if link.ID != nil {
+		cursor.ID = *link.ID
+	}
+	switch sort {
+	case LinkSortClicks:
+		cursor.Clicks = link.Clicks
+	case LinkSortLastClick:
+		lastClick := NeverClicked
+		if link.LastClick != nil {
+			lastClick = *link.LastClick
+		}
+		cursor.Time = &lastClick
+	default:
+		createdAt := link.CreatedAt
+		cursor.Time = &createdAt
+	}
+	return cursor
+}
+
+// Encode возвращает непрозрачную строку для параметра cursor
+func (c *LinkCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeLinkCursor разбирает курсор; он должен быть выдан для той же сортировки
+func DecodeLinkCursor(value, sort string) (*LinkCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cursor LinkCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+	if cursor.Sort != sort {
+		return nil, errors.New("cursor was issued for another sort order")
+	}
+	if sort != LinkSortClicks && cursor.Time == nil {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// Pagination - сведения о странице списка
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	Sort       string `json:"sort"`
+	Order      string `json:"order"`                 // asc или desc
+	NextCursor string `json:"next_cursor,omitempty"` // передать в cursor для следующей страницы
+	HasMore    bool   `json:"has_more"`
+}
+
+// LinkPage - страница списка ссылок
+type LinkPage struct {
+	Links      []ShortLink `json:"links"`
+	Pagination Pagination  `json:"pagination"`
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LinkRevision - запись истории изменений ссылки.
+// Revision совпадает с версией ссылки после изменения. Old*/New* показывают главное в истории,
+// State хранит все изменяемые поля после изменения и нужен для отката.
+type LinkRevision struct {
+	ID            *uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	LinkID        *uuid.UUID `json:"link_id" gorm:"type:uuid;not null;uniqueIndex:idx_link_revision"`
+	Revision      int        `json:"revision" gorm:"not null;uniqueIndex:idx_link_revision"`
+	ActorID       *uuid.UUID `json:"actor_id" gorm:"type:uuid"`
+	OldLongLink   string     `json:"old_long_url,omitempty" gorm:"type:text"`
+	NewLongLink   string     `json:"new_long_url" gorm:"type:text;not null"`
+	OldShortId    string     `json:"old_short_id,omitempty" gorm:"size:16"`
+	NewShortId    string     `json:"new_short_id" gorm:"size:16;not null"`
+	NewIsAlias    bool       `json:"new_is_alias"`
+	OldExpiresAt  *time.Time `json:"old_expires_at,omitempty"`
+	NewExpiresAt  *time.Time `json:"new_expires_at,omitempty"`
+	OldActiveFrom *time.Time `json:"old_active_from,omitempty"`
+	NewActiveFrom *time.Time `json:"new_active_from,omitempty"`
+	RestoredFrom  *int       `json:"restored_from,omitempty"`             // ревизия, из которой сделан откат
+	State         *LinkState `json:"-" gorm:"serializer:json;type:jsonb"` // nil у ревизий, записанных до появления снимков
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (u *LinkRevision) BeforeCreate(tx *gorm.DB) (err error) {
+	new := uuid.New()
+	u.ID = &new
+	return
+}
+
+// NewLinkRevision описывает переход ссылки из состояния before в after.
+// before == nil означает создание ссылки.
+func NewLinkRevision(before, after *ShortLink, actorId *uuid.UUID) *LinkRevision {
+	revision := &LinkRevision{
+		LinkID:        after.ID,
+		Revision:      after.Version,
+		ActorID:       actorId,
+		NewLongLink:   after.LongLink,
+		NewShortId:    after.ShortId,
+		NewIsAlias:    after.IsAlias,
+		NewExpiresAt:  after.ExpiresAt,
+		NewActiveFrom: after.ActiveFrom,
+		State:         NewLinkState(after),
+	}
+	if before != nil {
+		revision.OldLongLink = before.LongLink
+		revision.OldShortId = before.ShortId
+		revision.OldExpiresAt = before.ExpiresAt
+		revision.OldActiveFrom = before.ActiveFrom
+	}
+	return revision
+}
+
+// ErrRevisionIncomplete - ревизия записана без снимка состояния, откат к ней не вернул бы остальные поля
+var ErrRevisionIncomplete = errors.New("revision does not contain the full link state and cannot be restored")
+
+// ApplyTo возвращает ссылку в состояние после этой ревизии
+func (r *LinkRevision) ApplyTo(link *ShortLink) error {
+	if r.State == nil {
+		return ErrRevisionIncomplete
+	}
+	r.State.ApplyTo(link)
+	return nil
+}
+
+// LinkState - изменяемые поля ссылки. Счётчики, метаданные страницы и результат проверки
+// адреса в снимок не входят: их меняет не владелец, а сервис.
+type LinkState struct {
+	LongLink       string      `json:"long_url"`
+	ShortId        string      `json:"short_id"`
+	IsAlias        bool        `json:"is_alias"`
+	ExpiresAt      *time.Time  `json:"expires_at,omitempty"`
+	ActiveFrom     *time.Time  `json:"active_from,omitempty"`
+	PendingMode    string      `json:"pending_mode,omitempty"`
+	PendingURL     string      `json:"pending_url,omitempty"`
+	RedirectCode   int         `json:"redirect_code"`
+	ForwardQuery   bool        `json:"forward_query"`
+	UTM            UTMParams   `json:"utm"`
+	PasswordHash   string      `json:"password_hash,omitempty"`
+	MaxClicks      int         `json:"max_clicks"`
+	FallbackURL    string      `json:"fallback_url,omitempty"`
+	TargetRules    TargetRules `json:"target_rules,omitempty"`
+	GeoTargets     GeoTargets  `json:"geo_targets,omitempty"`
+	Variants       Variants    `json:"variants,omitempty"`
+	StickyVariants bool        `json:"sticky_variants"`
+	Interstitial   bool        `json:"interstitial"`
+	Tags           Tags        `json:"tags,omitempty"`
+}
+
+func NewLinkState(link *ShortLink) *LinkState {
+	return &LinkState{
+		LongLink:       link.LongLink,
+		ShortId:        link.ShortId,
+		IsAlias:        link.IsAlias,
+		ExpiresAt:      link.ExpiresAt,
+		ActiveFrom:     link.ActiveFrom,
+		PendingMode:    link.PendingMode,
+		PendingURL:     link.PendingURL,
+		RedirectCode:   link.RedirectCode,
+		ForwardQuery:   link.ForwardQuery,
+		UTM:            link.UTM,
+		PasswordHash:   link.PasswordHash,
+		MaxClicks:      link.MaxClicks,
+		FallbackURL:    link.FallbackURL,
+		TargetRules:    link.TargetRules,
+		GeoTargets:     link.GeoTargets,
+		Variants:       link.Variants,
+		StickyVariants: link.StickyVariants,
+		Interstitial:   link.Interstitial,
+		Tags:           link.Tags,
+	}
+}
+
+// ApplyTo переносит снимок в ссылку
+func (s *LinkState) ApplyTo(link *ShortLink) {
+	link.LongLink = s.LongLink
+	link.ShortId = s.ShortId
+	link.IsAlias = s.IsAlias
+	link.ExpiresAt = s.ExpiresAt
+	link.ActiveFrom = s.ActiveFrom
+	link.PendingMode = s.PendingMode
+	link.PendingURL = s.PendingURL
+	link.RedirectCode = s.RedirectCode
+	link.ForwardQuery = s.ForwardQuery
+	link.UTM = s.UTM
+	link.PasswordHash = s.PasswordHash
+	link.PasswordProtected = s.PasswordHash != ""
+	link.MaxClicks = s.MaxClicks
+	link.FallbackURL = s.FallbackURL
+	link.TargetRules = s.TargetRules
+	link.GeoTargets = s.GeoTargets
+	link.Variants = s.Variants
+	link.StickyVariants = s.StickyVariants
+	link.Interstitial = s.Interstitial
+	link.Tags = s.Tags
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LinkShare даёт пользователю доступ только на чтение статистики чужой ссылки
+type LinkShare struct {
+	ID        *uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	LinkID    *uuid.UUID `json:"link_id" gorm:"type:uuid;not null;uniqueIndex:idx_link_share"`
+	UserID    *uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_link_share"`
+	Username  string     `json:"username" gorm:"->;-:migration"` // заполняется из users в GetLinkShares
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (u *LinkShare) BeforeCreate(tx *gorm.DB) (err error) {
+	new := uuid.New()
+	u.ID = &new
+	return
+}
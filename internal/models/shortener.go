@@ -2,31 +2,178 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bigxxby/dream-test-task/internal/config"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// ReservedShortIDs - первые сегменты путей, занятые роутером или
+// зарезервированные под него. Такие идентификаторы не могут быть короткими ссылками.
+var ReservedShortIDs = []string{
+	"auth",
+	"swagger",
+	"shortener",
+	"api",
+	"admin",
+	"docs",
+	"static",
+	"health",
+	"metrics",
+	"login",
+	"register",
+	"whoami",
+}
+
+const (
+	AliasMinLength = 3
+	AliasMaxLength = 16 // ограничение колонки short_id
+)
+
+var aliasRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// NormalizeAlias приводит алиас к каноничному виду. Алиасы регистронезависимы.
+func NormalizeAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
+
+// IsReservedShortID проверяет, совпадает ли идентификатор с зарезервированным путём
+func IsReservedShortID(shortID string) bool {
+	for _, reserved := range ReservedShortIDs {
+		if strings.EqualFold(shortID, reserved) {
+			return true
+		}
+	}
+	return false
+}
+
 type ShortLink struct {
 	ID        *uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
-	UserID    *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index:idx_short_links_user_created,priority:1"`
 	LongLink  string     `json:"long_url" gorm:"type:text;not null"`
 	ShortId   string     `json:"short_id" gorm:"size:16;unique;not null"`
+	IsAlias   bool       `json:"is_alias" gorm:"default:false"`
 	Clicks    int        `json:"clicks" gorm:"default:0"`
 	LastClick *time.Time `json:"last_click"`
-	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime;index:idx_short_links_user_created,priority:2"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Version   int        `json:"version" gorm:"not null;default:1"` // для оптимистичной блокировки
+
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`                  // до этого момента ссылка не ведёт на адрес назначения
+	PendingMode string     `json:"pending_mode,omitempty" gorm:"size:16"`  // поведение до ActiveFrom: not_found (по умолчанию), coming_soon, redirect
+	PendingURL  string     `json:"pending_url,omitempty" gorm:"type:text"` // для pending_mode = redirect
+
+	// Метаданные страницы назначения, загружаются в фоне после создания ссылки и смены адреса
+	Title             string     `json:"title,omitempty" gorm:"size:512"`
+	Description       string     `json:"description,omitempty" gorm:"type:text"`
+	FaviconURL        string     `json:"favicon_url,omitempty" gorm:"type:text"`
+	OGImage           string     `json:"og_image,omitempty" gorm:"type:text"`
+	MetadataFetchedAt *time.Time `json:"metadata_fetched_at,omitempty"`
+
+	Interstitial bool `json:"interstitial" gorm:"not null;default:false"`       // всегда показывать предпросмотр вместо редиректа
+	Tags         Tags `json:"tags,omitempty" gorm:"serializer:json;type:jsonb"` // метки для группировки и фильтрации списка
+
+	Health LinkHealth `json:"health" gorm:"embedded;embeddedPrefix:health_"` // последняя проверка адреса назначения
+
+	RedirectCode int  `json:"redirect_code" gorm:"not null;default:302"`   // 301, 302, 307 или 308
+	ForwardQuery bool `json:"forward_query" gorm:"not null;default:false"` // добавлять параметры запроса к адресу назначения
+
+	UTM UTMParams `json:"utm" gorm:"embedded;embeddedPrefix:utm_"` // метки кампании, добавляются при редиректе
+
+	TargetRules TargetRules `json:"target_rules,omitempty" gorm:"serializer:json;type:jsonb"` // правила по устройству и языку, проверяются до GeoTargets
+	GeoTargets  GeoTargets  `json:"geo_targets,omitempty" gorm:"serializer:json;type:jsonb"`  // страна посетителя -> адрес назначения
+
+	Variants       Variants `json:"variants,omitempty" gorm:"serializer:json;type:jsonb"` // A/B-тест: взвешенные адреса вместо LongLink
+	StickyVariants bool     `json:"sticky_variants" gorm:"not null;default:false"`        // закреплять вариант за посетителем cookie
+
+	PasswordHash      string `json:"-" gorm:"size:60"` // bcrypt, пусто - ссылка без пароля
+	PasswordProtected bool   `json:"password_protected" gorm:"-"`
+
+	MaxClicks   int    `json:"max_clicks" gorm:"not null;default:0"`    // 0 - без ограничения, 1 - одноразовая ссылка
+	UsedClicks  int    `json:"used_clicks" gorm:"not null;default:0"`   // переходы, засчитанные в MaxClicks; растёт синхронно, в отличие от Clicks
+	FallbackURL string `json:"fallback_url,omitempty" gorm:"type:text"` // куда вести после исчерпания MaxClicks вместо 410
+}
+
+const (
+	LinkPasswordMinLength = 4
+	LinkPasswordMaxLength = 72 // ограничение bcrypt
+)
+
+// LinkMetadataColumns - колонки метаданных страницы назначения; их пишет только загрузчик метаданных
+var LinkMetadataColumns = []string{"title", "description", "favicon_url", "og_image", "metadata_fetched_at"}
+
+// DefaultRedirectCode - временный редирект: браузер не кэширует его,
+// поэтому изменение адреса и подсчёт кликов продолжают работать
+const DefaultRedirectCode = 302
+
+// IsValidRedirectCode проверяет, что код - один из поддерживаемых редиректов
+func IsValidRedirectCode(code int) bool {
+	switch code {
+	case 301, 302, 307, 308:
+		return true
+	}
+	return false
 }
 
 func (u *ShortLink) BeforeCreate(tx *gorm.DB) (err error) {
 	new := uuid.New()
 	u.ID = &new
+	if u.Version == 0 {
+		u.Version = 1
+	}
+	if u.RedirectCode == 0 {
+		u.RedirectCode = DefaultRedirectCode
+	}
 	return
 }
 
+func (u *ShortLink) AfterFind(tx *gorm.DB) (err error) {
+	u.PasswordProtected = u.PasswordHash != ""
+	return
+}
+
+// SetPassword хэширует пароль ссылки так же, как User.HashPassword.
+// Пустая строка снимает защиту.
+func (u *ShortLink) SetPassword(password string) error {
+	if password == "" {
+		u.PasswordHash = ""
+		u.PasswordProtected = false
+		return nil
+	}
+	if len(password) < LinkPasswordMinLength || len(password) > LinkPasswordMaxLength {
+		return fmt.Errorf("link password must be between %d and %d characters long", LinkPasswordMinLength, LinkPasswordMaxLength)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hashedPassword)
+	u.PasswordProtected = true
+	return nil
+}
+
+func (u *ShortLink) ComparePassword(password string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password))
+	return err == nil
+}
+
+// ETag возвращает значение заголовка ETag для текущей версии ссылки
+func (u *ShortLink) ETag() string {
+	return `"` + strconv.Itoa(u.Version) + `"`
+}
+
+// IsOwnedBy проверяет, принадлежит ли ссылка пользователю
+func (u *ShortLink) IsOwnedBy(userId *uuid.UUID) bool {
+	return u.UserID != nil && userId != nil && *u.UserID == *userId
+}
+
 func (u *ShortLink) UpdateClicks() {
 	u.Clicks++
 	now := time.Now()
@@ -34,18 +181,165 @@ func (u *ShortLink) UpdateClicks() {
 }
 
 // regexp
+var urlRegex = regexp.MustCompile(`^(https?|ftp)://[^\s/$.?#].[^\s]*$`)
+
 func (u *ShortLink) ValidateLongLink() error {
-	var regex = `^(https?|ftp)://[^\s/$.?#].[^\s]*$`
-	match, _ := regexp.MatchString(regex, u.LongLink)
-	if !match {
+	if !urlRegex.MatchString(u.LongLink) {
 		return errors.New("invalid URL")
 	}
 	return nil
 
 }
 
-// adds app port and host to short link
+// ValidateClickLimit проверяет лимит переходов и адрес, на который ведёт исчерпанная ссылка
+func (u *ShortLink) ValidateClickLimit() error {
+	if u.MaxClicks < 0 {
+		return errors.New("max_clicks must not be negative")
+	}
+	if u.FallbackURL != "" && !urlRegex.MatchString(u.FallbackURL) {
+		return errors.New("invalid fallback URL")
+	}
+	return nil
+}
+
+// Поведение ссылки до ActiveFrom
+const (
+	PendingNotFound   = "not_found"   // как несуществующая ссылка
+	PendingComingSoon = "coming_soon" // страница с временем запуска
+	PendingRedirect   = "redirect"    // редирект на PendingURL
+)
+
+// ValidateActivation проверяет окно активации и поведение ссылки до него
+func (u *ShortLink) ValidateActivation() error {
+	switch u.PendingMode {
+	case "", PendingNotFound, PendingComingSoon:
+	case PendingRedirect:
+		if u.PendingURL == "" {
+			return errors.New("pending_url is required for pending_mode redirect")
+		}
+	default:
+		return errors.New("pending_mode must be one of not_found, coming_soon, redirect")
+	}
+	if u.PendingURL != "" && !urlRegex.MatchString(u.PendingURL) {
+		return errors.New("invalid pending URL")
+	}
+	if u.ActiveFrom != nil && u.ExpiresAt != nil && !u.ActiveFrom.Before(*u.ExpiresAt) {
+		return errors.New("active_from must be before expiration time")
+	}
+	return nil
+}
+
+// IsActiveAt сообщает, началось ли окно активации ссылки к моменту now
+func (u *ShortLink) IsActiveAt(now time.Time) bool {
+	return u.ActiveFrom == nil || !now.Before(*u.ActiveFrom)
+}
+
+// ClicksExhausted сообщает, что лимит переходов исчерпан.
+// Для редиректа значение может быть устаревшим, окончательно решает ConsumeClick в репозитории.
+func (u *ShortLink) ClicksExhausted() bool {
+	return u.MaxClicks > 0 && u.UsedClicks >= u.MaxClicks
+}
+
+// SetAlias проверяет алиас и использует его как короткий идентификатор.
+// Разрешены латиница, цифры, "-" и "_", длина от 3 до 16 символов.
+func (u *ShortLink) SetAlias(alias string) error {
+	alias = NormalizeAlias(alias)
+	if len(alias) < AliasMinLength || len(alias) > AliasMaxLength {
+		return fmt.Errorf("alias must be between %d and %d characters long", AliasMinLength, AliasMaxLength)
+	}
+	if !aliasRegex.MatchString(alias) {
+		return errors.New("alias may contain only latin letters, digits, '-' and '_' and must start with a letter or digit")
+	}
+	if IsReservedShortID(alias) {
+		return errors.New("alias is reserved")
+	}
+	u.ShortId = alias
+	u.IsAlias = true
+	return nil
+}
+
+// RedirectStatus возвращает код редиректа ссылки, для старых записей - код по умолчанию
+func (u *ShortLink) RedirectStatus() int {
+	if IsValidRedirectCode(u.RedirectCode) {
+		return u.RedirectCode
+	}
+	return DefaultRedirectCode
+}
+
+// Target выбирает адрес назначения для посетителя: первое подходящее правило
+// из TargetRules, затем адрес для его страны из GeoTargets, затем вариант
+// v.Variant из Variants, иначе LongLink. rule и variant - имена сработавшего
+// правила (для GeoTargets - "geo:" и код страны) и варианта, пустые, если адрес выбран иначе.
+func (u *ShortLink) Target(v Visitor) (target, rule, variant string) {
+	if matched := u.TargetRules.Match(v); matched != nil {
+		return matched.URL, matched.Name, ""
+	}
+	if target, ok := u.GeoTargets[v.Country]; ok && v.Country != "" {
+		return target, GeoRulePrefix + v.Country, ""
+	}
+	if chosen := u.Variants.Find(v.Variant); chosen != nil {
+		return chosen.URL, "", chosen.Name
+	}
+	return u.LongLink, "", ""
+}
+
+// DestinationURL возвращает адрес для редиректа на LongLink без учёта посетителя
+func (u *ShortLink) DestinationURL(rawQuery string) string {
+	return u.DestinationURLFor(u.LongLink, rawQuery)
+}
+
+// DestinationURLFor возвращает адрес для редиректа: target с метками UTM ссылки
+// (они заменяют одноимённые параметры адреса) и, если включён ForwardQuery,
+// с параметрами входящего запроса. Входящие параметры не заменяют уже имеющиеся,
+// чтобы посетитель не мог подменить параметры и метки ссылки.
+func (u *ShortLink) DestinationURLFor(target, rawQuery string) string {
+	utm := u.UTM.Values()
+	var incoming url.Values
+	if u.ForwardQuery && rawQuery != "" {
+		incoming, _ = url.ParseQuery(rawQuery) // при ошибке остаются разобранные параметры
+	}
+	if len(utm) == 0 && len(incoming) == 0 {
+		return target
+	}
+	destination, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+
+	// Собственная строка запроса адреса по возможности остаётся как есть, новые ключи дописываются в конец
+	existing := destination.Query()
+	extra := url.Values{}
+	rebuild := false
+	for key, values := range utm {
+		if _, exists := existing[key]; exists {
+			rebuild = true
+		}
+		existing[key] = values
+	}
+	if rebuild {
+		destination.RawQuery = existing.Encode()
+	} else {
+		for key, values := range utm {
+			extra[key] = values
+		}
+	}
+	for key, values := range incoming {
+		if _, exists := existing[key]; !exists {
+			extra[key] = values
+		}
+	}
+
+	if len(extra) > 0 {
+		if destination.RawQuery != "" {
+			destination.RawQuery += "&"
+		}
+		destination.RawQuery += extra.Encode()
+	}
+	return destination.String()
+}
+
+// adds public base url to short link
 func (u *ShortLink) ParseShortId() error {
-	u.ShortId = "http://localhost:" + config.AppPort + "/shortener/" + u.ShortId
+	u.ShortId = config.PublicURL(u.ShortId)
 	return nil
 }
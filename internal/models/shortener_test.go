@@ -0,0 +1,310 @@
+package models_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestinationURL(t *testing.T) {
+	link := &models.ShortLink{LongLink: "https://example.com/page?ref=site#top", ForwardQuery: true}
+
+	// параметры ссылки важнее входящих, новые дописываются в конец
+	assert.Equal(t, "https://example.com/page?ref=site&utm_source=mail#top", link.DestinationURL("ref=evil&utm_source=mail"))
+	assert.Equal(t, "https://example.com/page?ref=site#top", link.DestinationURL("ref=evil"))
+	assert.Equal(t, "https://example.com/page?ref=site#top", link.DestinationURL(""))
+
+	link.ForwardQuery = false
+	assert.Equal(t, "https://example.com/page?ref=site#top", link.DestinationURL("utm_source=mail"))
+}
+
+func TestDestinationURLWithUTM(t *testing.T) {
+	link := &models.ShortLink{
+		LongLink:     "https://example.com/?ref=site",
+		ForwardQuery: true,
+		UTM:          models.UTMParams{Source: "newsletter", Campaign: "spring"},
+	}
+	// метки ссылки нельзя подменить входящими параметрами
+	assert.Equal(t, "https://example.com/?ref=site&utm_campaign=spring&utm_source=newsletter&x=1", link.DestinationURL("utm_source=evil&x=1"))
+
+	// одноимённый параметр адреса назначения заменяется меткой
+	link.LongLink = "https://example.com/?utm_source=old&ref=site"
+	assert.Equal(t, "https://example.com/?ref=site&utm_campaign=spring&utm_source=newsletter", link.DestinationURL(""))
+}
+
+func TestRedirectStatus(t *testing.T) {
+	assert.Equal(t, 302, (&models.ShortLink{}).RedirectStatus())
+	assert.Equal(t, 308, (&models.ShortLink{RedirectCode: 308}).RedirectStatus())
+	assert.Equal(t, 302, (&models.ShortLink{RedirectCode: 200}).RedirectStatus())
+}
+
+func TestLinkPassword(t *testing.T) {
+	link := &models.ShortLink{}
+	assert.Error(t, link.SetPassword("abc"))
+
+	assert.NoError(t, link.SetPassword("hunter2"))
+	assert.True(t, link.ComparePassword("hunter2"))
+	assert.False(t, link.ComparePassword("hunter3"))
+
+	// пустой пароль снимает защиту
+	assert.NoError(t, link.SetPassword(""))
+	assert.Empty(t, link.PasswordHash)
+	assert.False(t, link.ComparePassword(""))
+}
+
+func TestClickLimit(t *testing.T) {
+	link := &models.ShortLink{MaxClicks: -1}
+	assert.Error(t, link.ValidateClickLimit())
+
+	link = &models.ShortLink{MaxClicks: 1, FallbackURL: "not a url"}
+	assert.Error(t, link.ValidateClickLimit())
+
+	link.FallbackURL = "https://example.com/expired"
+	assert.NoError(t, link.ValidateClickLimit())
+	assert.False(t, link.ClicksExhausted())
+
+	link.UsedClicks = 1
+	assert.True(t, link.ClicksExhausted())
+
+	// без лимита ссылка не исчерпывается
+	link.MaxClicks = 0
+	assert.False(t, link.ClicksExhausted())
+}
+
+func TestActivationWindow(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	link := &models.ShortLink{ActiveFrom: &later}
+	assert.NoError(t, link.ValidateActivation())
+	assert.False(t, link.IsActiveAt(now))
+	assert.True(t, link.IsActiveAt(later))
+
+	link.PendingMode = models.PendingRedirect
+	assert.Error(t, link.ValidateActivation())
+	link.PendingURL = "https://example.com/teaser"
+	assert.NoError(t, link.ValidateActivation())
+
+	link.PendingMode = "later"
+	assert.Error(t, link.ValidateActivation())
+
+	// окно должно начинаться до истечения срока
+	link.PendingMode = models.PendingComingSoon
+	link.ExpiresAt = &now
+	assert.Error(t, link.ValidateActivation())
+}
+
+func TestGeoTargets(t *testing.T) {
+	targets, err := models.GeoTargets{"de": "https://example.de", " FR ": "https://example.fr"}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, models.GeoTargets{"DE": "https://example.de", "FR": "https://example.fr"}, targets)
+
+	_, err = models.GeoTargets{"Germany": "https://example.de"}.Normalize()
+	assert.Error(t, err)
+	_, err = models.GeoTargets{"DE": "example.de"}.Normalize()
+	assert.Error(t, err)
+	_, err = models.GeoTargets{"de": "https://a.de", "DE": "https://b.de"}.Normalize()
+	assert.Error(t, err)
+
+	link := &models.ShortLink{
+		LongLink:   "https://example.com/?lang=en",
+		GeoTargets: targets,
+		UTM:        models.UTMParams{Source: "mail"},
+	}
+	destination := func(country string) string {
+		target, _, _ := link.Target(models.Visitor{Country: country})
+		return link.DestinationURLFor(target, "")
+	}
+	assert.Equal(t, "https://example.de?utm_source=mail", destination("DE"))
+
+	_, rule, _ := link.Target(models.Visitor{Country: "FR"})
+	assert.Equal(t, "geo:FR", rule)
+	_, rule, _ = link.Target(models.Visitor{Country: "US"})
+	assert.Empty(t, rule)
+	assert.Equal(t, "https://example.com/?lang=en&utm_source=mail", destination("US"))
+	assert.Equal(t, "https://example.com/?lang=en&utm_source=mail", destination(""))
+}
+
+func TestTargetRules(t *testing.T) {
+	rules, err := models.TargetRules{
+		{Name: "ios", OS: "iOS", URL: "https://apps.apple.com/app/id1"},
+		{OS: "android", URL: "https://play.google.com/store/apps/details?id=app"},
+		{Device: "Desktop", Language: "de", URL: "https://example.de"},
+	}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "rule-2", rules[1].Name)
+	assert.Equal(t, "desktop", rules[2].Device)
+
+	_, err = models.TargetRules{{Name: "any", URL: "https://example.com"}}.Normalize()
+	assert.Error(t, err)
+	_, err = models.TargetRules{{Device: "phone", URL: "https://example.com"}}.Normalize()
+	assert.Error(t, err)
+	_, err = models.TargetRules{{OS: "iOS", URL: "apps.apple.com"}}.Normalize()
+	assert.Error(t, err)
+	_, err = models.TargetRules{{Name: "a", OS: "iOS", URL: "https://a.com"}, {Name: "a", OS: "Android", URL: "https://b.com"}}.Normalize()
+	assert.Error(t, err)
+	_, err = models.TargetRules{{Name: "geo:DE", OS: "iOS", URL: "https://a.com"}}.Normalize()
+	assert.Error(t, err)
+
+	link := &models.ShortLink{
+		LongLink:    "https://example.com",
+		TargetRules: rules,
+		GeoTargets:  models.GeoTargets{"DE": "https://example.com/de"},
+	}
+	cases := []struct {
+		visitor models.Visitor
+		target  string
+		rule    string
+	}{
+		{models.Visitor{OS: "iOS", Device: "mobile"}, "https://apps.apple.com/app/id1", "ios"},
+		{models.Visitor{OS: "Android", Device: "tablet"}, "https://play.google.com/store/apps/details?id=app", "rule-2"},
+		{models.Visitor{OS: "Windows", Device: "desktop", Language: "de-AT"}, "https://example.de", "rule-3"},
+		// "de" не подходит для "deu", правило не сработало - дальше GeoTargets
+		{models.Visitor{OS: "Windows", Device: "desktop", Language: "deu", Country: "DE"}, "https://example.com/de", "geo:DE"},
+		{models.Visitor{OS: "Linux", Device: "desktop", Language: "en-US"}, "https://example.com", ""},
+	}
+	for _, c := range cases {
+		target, rule, _ := link.Target(c.visitor)
+		assert.Equal(t, c.target, target, c.visitor)
+		assert.Equal(t, c.rule, rule, c.visitor)
+	}
+}
+
+func TestVariants(t *testing.T) {
+	variants, err := models.Variants{
+		{URL: "https://example.com/a", Weight: 3},
+		{Name: "new", URL: "https://example.com/b"},
+	}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "A", variants[0].Name)
+	assert.Equal(t, 1, variants[1].Weight)
+	assert.Equal(t, 4, variants.TotalWeight())
+
+	assert.Equal(t, "A", variants.Pick(0).Name)
+	assert.Equal(t, "A", variants.Pick(2).Name)
+	assert.Equal(t, "new", variants.Pick(3).Name)
+	assert.Nil(t, variants.Pick(4))
+
+	_, err = models.Variants{{URL: "https://example.com/a"}}.Normalize()
+	assert.Error(t, err)
+	_, err = models.Variants{{URL: "https://example.com/a", Weight: -1}, {URL: "https://example.com/b"}}.Normalize()
+	assert.Error(t, err)
+	_, err = models.Variants{{Name: "x", URL: "https://example.com/a"}, {Name: "x", URL: "https://example.com/b"}}.Normalize()
+	assert.Error(t, err)
+
+	// правила и страны важнее вариантов
+	link := &models.ShortLink{
+		LongLink:    "https://example.com",
+		TargetRules: models.TargetRules{{Name: "ios", OS: "iOS", URL: "https://apps.apple.com/app/id1"}},
+		Variants:    variants,
+	}
+	target, rule, variant := link.Target(models.Visitor{OS: "iOS", Variant: "new"})
+	assert.Equal(t, []string{"https://apps.apple.com/app/id1", "ios", ""}, []string{target, rule, variant})
+	target, rule, variant = link.Target(models.Visitor{OS: "Android", Variant: "new"})
+	assert.Equal(t, []string{"https://example.com/b", "", "new"}, []string{target, rule, variant})
+}
+
+func TestLinkHealthRecord(t *testing.T) {
+	now := time.Now()
+	var h models.LinkHealth
+
+	h.Record(200, 120*time.Millisecond, nil, 2, now)
+	assert.Equal(t, 200, h.Status)
+	assert.Equal(t, int64(120), h.LatencyMs)
+	assert.False(t, h.Broken)
+
+	// 403 и 429 - страница закрыта от проверки, но существует
+	h.Record(403, 0, nil, 2, now)
+	assert.Zero(t, h.Failures)
+
+	h.Record(503, 0, nil, 2, now)
+	assert.Equal(t, 1, h.Failures)
+	assert.False(t, h.Broken)
+
+	h.Record(0, 0, errors.New("connection refused"), 2, now)
+	assert.Equal(t, 2, h.Failures)
+	assert.True(t, h.Broken)
+	assert.Equal(t, "connection refused", h.Error)
+
+	// один удачный ответ снимает отметку
+	h.Record(301, 0, nil, 2, now)
+	assert.Zero(t, h.Failures)
+	assert.False(t, h.Broken)
+	assert.Empty(t, h.Error)
+
+	h.Skip("private address", now)
+	assert.False(t, h.Broken)
+	assert.Zero(t, h.Status)
+	assert.Equal(t, "private address", h.Error)
+}
+
+func TestTags(t *testing.T) {
+	tags, err := models.Tags{" Promo ", "spring_2024", "promo", "Весна"}.Normalize()
+	assert.NoError(t, err)
+	assert.Equal(t, models.Tags{"promo", "spring_2024", "весна"}, tags)
+
+	tags, err = models.Tags{}.Normalize()
+	assert.NoError(t, err)
+	assert.Nil(t, tags)
+
+	_, err = models.Tags{"two words"}.Normalize()
+	assert.Error(t, err)
+	_, err = models.Tags{"-promo"}.Normalize()
+	assert.Error(t, err)
+	_, err = models.Tags{""}.Normalize()
+	assert.Error(t, err)
+}
+
+func TestLinkCursor(t *testing.T) {
+	id := uuid.New()
+	lastClick := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	link := &models.ShortLink{ID: &id, Clicks: 7, LastClick: &lastClick, CreatedAt: lastClick.Add(-time.Hour)}
+
+	cursor, err := models.DecodeLinkCursor(models.NewLinkCursor(link, models.LinkSortLastClick).Encode(), models.LinkSortLastClick)
+	assert.NoError(t, err)
+	assert.Equal(t, id, cursor.ID)
+	assert.True(t, lastClick.Equal(*cursor.Time))
+
+	cursor, err = models.DecodeLinkCursor(models.NewLinkCursor(link, models.LinkSortClicks).Encode(), models.LinkSortClicks)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, cursor.Clicks)
+
+	// ссылка без переходов идёт как кликнутая в начале эпохи
+	link.LastClick = nil
+	cursor = models.NewLinkCursor(link, models.LinkSortLastClick)
+	assert.True(t, models.NeverClicked.Equal(*cursor.Time))
+
+	// курсор действует только для той сортировки, для которой выдан
+	_, err = models.DecodeLinkCursor(models.NewLinkCursor(link, models.LinkSortClicks).Encode(), models.LinkSortCreatedAt)
+	assert.Error(t, err)
+	_, err = models.DecodeLinkCursor("not a cursor", models.LinkSortCreatedAt)
+	assert.Error(t, err)
+}
+
+func TestLinkStatus(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		link models.ShortLink
+		want string
+	}{
+		{"plain", models.ShortLink{}, models.LinkStatusActive},
+		{"clicks left", models.ShortLink{ExpiresAt: &future, ActiveFrom: &past, MaxClicks: 2, UsedClicks: 1}, models.LinkStatusActive},
+		{"expired", models.ShortLink{ExpiresAt: &past}, models.LinkStatusExpired},
+		{"expires now", models.ShortLink{ExpiresAt: &now}, models.LinkStatusExpired},
+		{"expired and exhausted", models.ShortLink{ExpiresAt: &past, MaxClicks: 1, UsedClicks: 1}, models.LinkStatusExpired},
+		{"scheduled", models.ShortLink{ActiveFrom: &future}, models.LinkStatusScheduled},
+		{"scheduled and exhausted", models.ShortLink{ActiveFrom: &future, MaxClicks: 1, UsedClicks: 1}, models.LinkStatusScheduled},
+		{"starts now", models.ShortLink{ActiveFrom: &now}, models.LinkStatusActive},
+		{"exhausted", models.ShortLink{MaxClicks: 1, UsedClicks: 1}, models.LinkStatusExhausted},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.link.Status(now), tt.name)
+	}
+}
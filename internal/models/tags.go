@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTags - сколько меток можно задать одной ссылке
+	MaxTags = 20
+	// MaxTagLength - максимальная длина метки в символах
+	MaxTagLength = 32
+)
+
+// tagRegex - буквы любого алфавита, цифры, "-" и "_"
+var tagRegex = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}_-]*$`)
+
+// Tags - метки, по которым пользователь группирует и фильтрует свои ссылки
+type Tags []string
+
+// NormalizeTag приводит метку к нижнему регистру и проверяет её
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("tag cannot be empty")
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", fmt.Errorf("tag %q is too long, at most %d characters", tag, MaxTagLength)
+	}
+	if !tagRegex.MatchString(tag) {
+		return "", fmt.Errorf("tag %q can contain only letters, digits, \"-\" and \"_\"", tag)
+	}
+	return tag, nil
+}
+
+// Normalize приводит метки к нижнему регистру и убирает повторы, сохраняя порядок
+func (t Tags) Normalize() (Tags, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	normalized := make(Tags, 0, len(t))
+	seen := make(map[string]bool, len(t))
+	for _, tag := range t {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", MaxTags)
+	}
+	return normalized, nil
+}
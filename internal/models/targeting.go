@@ -0,0 +1,162 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bigxxby/dream-test-task/internal/utils"
+)
+
+// MaxGeoTargets - сколько стран можно задать одной ссылке
+const MaxGeoTargets = 250
+
+var countryCodeRegex = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// GeoTargets - адреса назначения по стране посетителя: код ISO 3166-1 alpha-2 -> URL.
+// Посетители из остальных стран идут на LongLink.
+type GeoTargets map[string]string
+
+// Normalize приводит коды стран к верхнему регистру и проверяет адреса
+func (g GeoTargets) Normalize() (GeoTargets, error) {
+	if len(g) == 0 {
+		return nil, nil
+	}
+	if len(g) > MaxGeoTargets {
+		return nil, fmt.Errorf("at most %d geo targets are allowed", MaxGeoTargets)
+	}
+	normalized := make(GeoTargets, len(g))
+	for country, target := range g {
+		code := strings.ToUpper(strings.TrimSpace(country))
+		if !countryCodeRegex.MatchString(code) {
+			return nil, fmt.Errorf("invalid country code %q, expected ISO 3166-1 alpha-2", country)
+		}
+		if _, exists := normalized[code]; exists {
+			return nil, fmt.Errorf("duplicate country code %q", code)
+		}
+		if !urlRegex.MatchString(target) {
+			return nil, errors.New("invalid URL for country " + code)
+		}
+		normalized[code] = target
+	}
+	return normalized, nil
+}
+
+// MaxTargetRules - сколько правил по устройству можно задать одной ссылке
+const MaxTargetRules = 20
+
+// GeoRulePrefix - префикс правила в статистике для кликов, направленных по GeoTargets
+const GeoRulePrefix = "geo:"
+
+var ruleDevices = map[string]bool{
+	utils.DeviceDesktop: true,
+	utils.DeviceMobile:  true,
+	utils.DeviceTablet:  true,
+	utils.DeviceBot:     true,
+}
+
+// TargetRule - адрес назначения для посетителей с подходящим устройством и языком.
+// Пустое условие не проверяется, непустые сравниваются без учёта регистра.
+type TargetRule struct {
+	Name     string `json:"name" example:"ios"`                 // попадает в статистику кликов, по умолчанию rule-N
+	OS       string `json:"os,omitempty" example:"iOS"`         // iOS, Android, Windows, macOS, Linux, ChromeOS...
+	Device   string `json:"device,omitempty" example:"mobile"`  // desktop, mobile, tablet или bot
+	Browser  string `json:"browser,omitempty" example:"Safari"` // Chrome, Safari, Firefox, Edge...
+	Language string `json:"language,omitempty" example:"en"`    // "en" подходит и для en-US
+	URL      string `json:"url" example:"https://apps.apple.com/app/id123"`
+}
+
+// TargetRules - правила по устройству в порядке приоритета: срабатывает первое подходящее
+type TargetRules []TargetRule
+
+// Visitor - признаки посетителя, по которым выбирается адрес назначения
+type Visitor struct {
+	Country  string
+	OS       string
+	Device   string
+	Browser  string
+	Language string // основной тег Accept-Language, например en-US
+	Variant  string // вариант A/B-теста, назначенный посетителю
+}
+
+// Normalize проверяет правила и присваивает имена безымянным
+func (r TargetRules) Normalize() (TargetRules, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	if len(r) > MaxTargetRules {
+		return nil, fmt.Errorf("at most %d target rules are allowed", MaxTargetRules)
+	}
+	normalized := make(TargetRules, 0, len(r))
+	names := make(map[string]bool, len(r))
+	for i, rule := range r {
+		rule.Name = strings.TrimSpace(rule.Name)
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if len(rule.Name) > 64 {
+			return nil, fmt.Errorf("rule name %q is too long, at most 64 characters", rule.Name)
+		}
+		if strings.HasPrefix(rule.Name, GeoRulePrefix) {
+			return nil, fmt.Errorf("rule name %q is reserved for geo_targets", rule.Name)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+
+		rule.OS = strings.TrimSpace(rule.OS)
+		rule.Device = strings.ToLower(strings.TrimSpace(rule.Device))
+		rule.Browser = strings.TrimSpace(rule.Browser)
+		rule.Language = strings.TrimSpace(rule.Language)
+		if rule.OS == "" && rule.Device == "" && rule.Browser == "" && rule.Language == "" {
+			return nil, fmt.Errorf("rule %q must have at least one of os, device, browser, language", rule.Name)
+		}
+		if rule.Device != "" && !ruleDevices[rule.Device] {
+			return nil, fmt.Errorf("rule %q: device must be one of desktop, mobile, tablet, bot", rule.Name)
+		}
+		if !urlRegex.MatchString(rule.URL) {
+			return nil, fmt.Errorf("rule %q: invalid URL", rule.Name)
+		}
+		normalized = append(normalized, rule)
+	}
+	return normalized, nil
+}
+
+// Matches проверяет, подходит ли правило посетителю
+func (r TargetRule) Matches(v Visitor) bool {
+	if r.OS != "" && !strings.EqualFold(r.OS, v.OS) {
+		return false
+	}
+	if r.Device != "" && !strings.EqualFold(r.Device, v.Device) {
+		return false
+	}
+	if r.Browser != "" && !strings.EqualFold(r.Browser, v.Browser) {
+		return false
+	}
+	if r.Language != "" && !languageMatches(r.Language, v.Language) {
+		return false
+	}
+	return true
+}
+
+// Match возвращает первое подходящее правило или nil
+func (r TargetRules) Match(v Visitor) *TargetRule {
+	for i := range r {
+		if r[i].Matches(v) {
+			return &r[i]
+		}
+	}
+	return nil
+}
+
+// languageMatches сравнивает языковые теги: правило "en" подходит для en и en-US,
+// правило "en-US" - только для en-US
+func languageMatches(rule, language string) bool {
+	if strings.EqualFold(rule, language) {
+		return true
+	}
+	prefix := rule + "-"
+	return len(language) > len(prefix) && strings.EqualFold(language[:len(prefix)], prefix)
+}
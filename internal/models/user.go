@@ -8,15 +8,25 @@ import (
 	"gorm.io/gorm"
 )
 
+// Роли пользователей. Роль меняется только в базе, от неё зависят ограничения, например срок жизни ссылок.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID       *uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
 	Username string     `json:"username" gorm:"unique;not null"`
 	Password string     `json:"-" gorm:"not null"`
+	Role     string     `json:"role" gorm:"size:32;not null;default:user"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	new := uuid.New()
 	u.ID = &new
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
 	return
 }
 
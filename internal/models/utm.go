@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UTMMaxLength - ограничение длины одного UTM-параметра
+const UTMMaxLength = 255
+
+// UTMParams - метки кампании, которые добавляются к адресу назначения при редиректе.
+// В таблицах хранятся колонками utm_source, utm_medium и т.д.
+type UTMParams struct {
+	Source   string `json:"utm_source,omitempty" gorm:"size:255"`
+	Medium   string `json:"utm_medium,omitempty" gorm:"size:255"`
+	Campaign string `json:"utm_campaign,omitempty" gorm:"size:255"`
+	Term     string `json:"utm_term,omitempty" gorm:"size:255"`
+	Content  string `json:"utm_content,omitempty" gorm:"size:255"`
+}
+
+// Values возвращает заполненные метки как параметры запроса
+func (p UTMParams) Values() url.Values {
+	values := url.Values{}
+	for key, value := range p.fields() {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values
+}
+
+func (p UTMParams) IsEmpty() bool {
+	return len(p.Values()) == 0
+}
+
+// Merge возвращает метки p, заменённые непустыми метками override
+func (p UTMParams) Merge(override UTMParams) UTMParams {
+	pick := func(base, value string) string {
+		if value != "" {
+			return value
+		}
+		return base
+	}
+	return UTMParams{
+		Source:   pick(p.Source, override.Source),
+		Medium:   pick(p.Medium, override.Medium),
+		Campaign: pick(p.Campaign, override.Campaign),
+		Term:     pick(p.Term, override.Term),
+		Content:  pick(p.Content, override.Content),
+	}
+}
+
+// Normalize убирает пробелы по краям и проверяет длину
+func (p *UTMParams) Normalize() error {
+	for _, field := range []*string{&p.Source, &p.Medium, &p.Campaign, &p.Term, &p.Content} {
+		*field = strings.TrimSpace(*field)
+		if len(*field) > UTMMaxLength {
+			return fmt.Errorf("utm parameters must be at most %d characters long", UTMMaxLength)
+		}
+	}
+	return nil
+}
+
+func (p UTMParams) fields() map[string]string {
+	return map[string]string{
+		"utm_source":   p.Source,
+		"utm_medium":   p.Medium,
+		"utm_campaign": p.Campaign,
+		"utm_term":     p.Term,
+		"utm_content":  p.Content,
+	}
+}
+
+// UTMTemplateNameMaxLength - ограничение длины имени шаблона
+const UTMTemplateNameMaxLength = 64
+
+// UTMTemplate - сохранённый пользователем набор меток для новых ссылок
+type UTMTemplate struct {
+	ID        *uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID    *uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_utm_template_name"`
+	Name      string     `json:"name" gorm:"size:64;not null;uniqueIndex:idx_utm_template_name"`
+	UTM       UTMParams  `json:"utm" gorm:"embedded;embeddedPrefix:utm_"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (u *UTMTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	new := uuid.New()
+	u.ID = &new
+	return
+}
+
+// Validate проверяет имя и метки шаблона
+func (u *UTMTemplate) Validate() error {
+	u.Name = strings.TrimSpace(u.Name)
+	if u.Name == "" || len(u.Name) > UTMTemplateNameMaxLength {
+		return fmt.Errorf("template name must be between 1 and %d characters long", UTMTemplateNameMaxLength)
+	}
+	if err := u.UTM.Normalize(); err != nil {
+		return err
+	}
+	if u.UTM.IsEmpty() {
+		return errors.New("template must set at least one utm parameter")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxVariants - сколько вариантов A/B-теста можно задать одной ссылке
+	MaxVariants = 10
+	// MaxVariantWeight - максимальный вес варианта
+	MaxVariantWeight = 1000
+)
+
+// Variant - один из адресов назначения A/B-теста
+type Variant struct {
+	Name   string `json:"name" example:"A"` // попадает в статистику кликов, по умолчанию A, B, C...
+	URL    string `json:"url" example:"https://example.com/landing-a"`
+	Weight int    `json:"weight" example:"50"` // доля трафика относительно суммы весов, по умолчанию 1
+}
+
+// Variants - адреса, между которыми делится трафик ссылки
+type Variants []Variant
+
+// Normalize проверяет варианты, присваивает имена безымянным и вес 1 вариантам без веса
+func (v Variants) Normalize() (Variants, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	if len(v) < 2 {
+		return nil, errors.New("at least 2 variants are required")
+	}
+	if len(v) > MaxVariants {
+		return nil, fmt.Errorf("at most %d variants are allowed", MaxVariants)
+	}
+	normalized := make(Variants, 0, len(v))
+	names := make(map[string]bool, len(v))
+	for i, variant := range v {
+		variant.Name = strings.TrimSpace(variant.Name)
+		if variant.Name == "" {
+			variant.Name = string(rune('A' + i))
+		}
+		if len(variant.Name) > 32 {
+			return nil, fmt.Errorf("variant name %q is too long, at most 32 characters", variant.Name)
+		}
+		if names[variant.Name] {
+			return nil, fmt.Errorf("duplicate variant name %q", variant.Name)
+		}
+		names[variant.Name] = true
+
+		if variant.Weight == 0 {
+			variant.Weight = 1
+		}
+		if variant.Weight < 0 || variant.Weight > MaxVariantWeight {
+			return nil, fmt.Errorf("variant %q: weight must be between 1 and %d", variant.Name, MaxVariantWeight)
+		}
+		if !urlRegex.MatchString(variant.URL) {
+			return nil, fmt.Errorf("variant %q: invalid URL", variant.Name)
+		}
+		normalized = append(normalized, variant)
+	}
+	return normalized, nil
+}
+
+// TotalWeight возвращает сумму весов
+func (v Variants) TotalWeight() int {
+	total := 0
+	for _, variant := range v {
+		total += variant.Weight
+	}
+	return total
+}
+
+// Pick выбирает вариант по числу roll из [0, TotalWeight())
+func (v Variants) Pick(roll int) *Variant {
+	for i := range v {
+		if roll < v[i].Weight {
+			return &v[i]
+		}
+		roll -= v[i].Weight
+	}
+	return nil
+}
+
+// Find возвращает вариант по имени или nil
+func (v Variants) Find(name string) *Variant {
+	for i := range v {
+		if v[i].Name == name {
+			return &v[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Ограничения параметров, чтобы один запрос не рисовал гигантскую картинку
+const (
+	MinSize       = 64
+	MaxSize       = 2048
+	DefaultSize   = 256
+	MaxMargin     = 16
+	DefaultMargin = 4 // тихая зона по стандарту - 4 модуля
+)
+
+// Уровни коррекции ошибок: какую долю кода можно повредить без потери данных
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,     // ~7%
+	"M": qrcode.Medium,  // ~15%
+	"Q": qrcode.High,    // ~25%
+	"H": qrcode.Highest, // ~30%
+}
+
+// Options - параметры отрисовки QR-кода
+type Options struct {
+	Size       int    // ширина и высота в пикселях (для SVG - атрибуты width и height)
+	Margin     int    // отступ в модулях
+	Level      string // L, M, Q или H
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+// DefaultOptions - чёрный код на белом фоне, 256px, уровень M
+func DefaultOptions() Options {
+	return Options{
+		Size:       DefaultSize,
+		Margin:     DefaultMargin,
+		Level:      "M",
+		Foreground: color.NRGBA{A: 0xff},
+		Background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// Code - закодированный QR-код, готовый к отрисовке
+type Code struct {
+	modules [][]bool // modules[y][x] - тёмный модуль, без тихой зоны
+	opts    Options
+}
+
+// Encode кодирует content с параметрами opts
+func Encode(content string, opts Options) (*Code, error) {
+	level, ok := levels[strings.ToUpper(opts.Level)]
+	if !ok {
+		return nil, errors.New("level must be one of L, M, Q, H")
+	}
+	if opts.Margin < 0 || opts.Margin > MaxMargin {
+		return nil, fmt.Errorf("margin must be between 0 and %d", MaxMargin)
+	}
+	if opts.Size < MinSize || opts.Size > MaxSize {
+		return nil, fmt.Errorf("size must be between %d and %d", MinSize, MaxSize)
+	}
+
+	q, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	q.DisableBorder = true
+	code := &Code{modules: q.Bitmap(), opts: opts}
+
+	if total := code.totalModules(); opts.Size < total {
+		return nil, fmt.Errorf("size must be at least %d for this code and margin", total)
+	}
+	return code, nil
+}
+
+// totalModules - ширина кода в модулях вместе с отступами
+func (c *Code) totalModules() int {
+	return len(c.modules) + 2*c.opts.Margin
+}
+
+// PNG рисует код целым числом пикселей на модуль и центрирует его на холсте Size x Size
+func (c *Code) PNG() ([]byte, error) {
+	total := c.totalModules()
+	scale := c.opts.Size / total
+	offset := (c.opts.Size-total*scale)/2 + c.opts.Margin*scale
+
+	img := image.NewPaletted(image.Rect(0, 0, c.opts.Size, c.opts.Size), color.Palette{c.opts.Background, c.opts.Foreground})
+	for y, row := range c.modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				start := img.PixOffset(offset+x*scale, offset+y*scale+dy)
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[start+dx] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG рисует код в координатах модулей; соседние тёмные модули строки объединяются в один отрезок
+func (c *Code) SVG() []byte {
+	total := c.totalModules()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		c.opts.Size, c.opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"%s/>`, total, total, hexColor(c.opts.Background), opacity(c.opts.Background))
+	fmt.Fprintf(&buf, `<path fill="%s"%s d="`, hexColor(c.opts.Foreground), opacity(c.opts.Foreground))
+	for y, row := range c.modules {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < len(row) && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x+c.opts.Margin, y+c.opts.Margin, run, run)
+			x += run
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+// ParseColor разбирает цвет вида RRGGBB, RRGGBBAA или RGB, с "#" или без
+func ParseColor(value string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected RRGGBB", value)
+	}
+	rgba, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected RRGGBB", value)
+	}
+	return color.NRGBA{R: uint8(rgba >> 24), G: uint8(rgba >> 16), B: uint8(rgba >> 8), A: uint8(rgba)}, nil
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func opacity(c color.NRGBA) string {
+	if c.A == 0xff {
+		return ""
+	}
+	return fmt.Sprintf(` fill-opacity="%.3g"`, float64(c.A)/0xff)
+}
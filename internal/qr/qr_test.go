@@ -0,0 +1,108 @@
+package qr_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/qr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPNG(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Size = 330 // 25 модулей версии 2 + 2*4 отступа = 33, по 10px на модуль
+	opts.Foreground, _ = qr.ParseColor("#1a2b3c")
+
+	code, err := qr.Encode("http://localhost/abc", opts)
+	require.NoError(t, err)
+	data, err := code.PNG()
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 330, img.Bounds().Dx())
+	assert.Equal(t, 330, img.Bounds().Dy())
+
+	fg := color.NRGBAModel.Convert(img.At(45, 45)).(color.NRGBA)
+	bg := color.NRGBAModel.Convert(img.At(5, 5)).(color.NRGBA)
+	// отступ - фон, левый верхний угол поискового узора - основной цвет
+	assert.Equal(t, color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}, fg)
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, bg)
+	assert.Equal(t, bg, color.NRGBAModel.Convert(img.At(39, 39)).(color.NRGBA))
+}
+
+func TestSVG(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Margin = 2
+	opts.Background, _ = qr.ParseColor("ffffff00")
+
+	code, err := qr.Encode("http://localhost/abc", opts)
+	require.NoError(t, err)
+	svg := string(code.SVG())
+
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256" viewBox="0 0 29 29"`))
+	assert.Contains(t, svg, `<rect width="29" height="29" fill="#ffffff" fill-opacity="0"/>`)
+	// первая строка поискового узора - 7 тёмных модулей подряд
+	assert.Contains(t, svg, `d="M2 2h7v1h-7z`)
+}
+
+func TestEncodeErrors(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Level = "X"
+	_, err := qr.Encode("http://localhost/abc", opts)
+	assert.Error(t, err)
+
+	opts = qr.DefaultOptions()
+	opts.Size = qr.MaxSize + 1
+	_, err = qr.Encode("http://localhost/abc", opts)
+	assert.Error(t, err)
+
+	// 64px не хватает на длинный адрес с уровнем H и широким отступом
+	opts = qr.DefaultOptions()
+	opts.Size = 64
+	opts.Level = "H"
+	opts.Margin = 16
+	_, err = qr.Encode("http://localhost/"+strings.Repeat("a", 100), opts)
+	assert.ErrorContains(t, err, "size must be at least")
+}
+
+func TestParseColor(t *testing.T) {
+	c, err := qr.ParseColor("#FF8000")
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0x80, A: 0xff}, c)
+
+	c, err = qr.ParseColor("0f08")
+	assert.Error(t, err)
+	c, err = qr.ParseColor("0f0")
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{G: 0xff, A: 0xff}, c)
+
+	// альфа не домножается на цвет: полупрозрачный красный остаётся красным
+	c, err = qr.ParseColor("ff000080")
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0x80}, c)
+
+	_, err = qr.ParseColor("red")
+	assert.Error(t, err)
+}
+
+func TestTranslucentColors(t *testing.T) {
+	opts := qr.DefaultOptions()
+	opts.Size = 330
+	opts.Foreground, _ = qr.ParseColor("ff000080")
+
+	code, err := qr.Encode("http://localhost/abc", opts)
+	require.NoError(t, err)
+	data, err := code.PNG()
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0x80}, color.NRGBAModel.Convert(img.At(45, 45)))
+
+	svg := string(code.SVG())
+	assert.Contains(t, svg, `<path fill="#ff0000" fill-opacity="0.502" d="`)
+}
@@ -1,11 +1,21 @@
 package router
 
 import (
+	"log"
+
 	_ "github.com/bigxxby/dream-test-task/docs" // Import the docs package for Swagger to pick up
 	"github.com/bigxxby/dream-test-task/internal/api/middleware"
 	authRepo "github.com/bigxxby/dream-test-task/internal/api/repo/auth"
 	authService "github.com/bigxxby/dream-test-task/internal/api/service/auth"
 	authController "github.com/bigxxby/dream-test-task/internal/api/transport/auth"
+	"github.com/bigxxby/dream-test-task/internal/cache"
+	"github.com/bigxxby/dream-test-task/internal/clicks"
+	"github.com/bigxxby/dream-test-task/internal/config"
+	"github.com/bigxxby/dream-test-task/internal/geoip"
+	"github.com/bigxxby/dream-test-task/internal/health"
+	"github.com/bigxxby/dream-test-task/internal/metadata"
+	"github.com/bigxxby/dream-test-task/internal/metrics"
+	"github.com/bigxxby/dream-test-task/internal/utils"
 
 	shortenerRepo "github.com/bigxxby/dream-test-task/internal/api/repo/shortener"
 	userRepo "github.com/bigxxby/dream-test-task/internal/api/repo/user"
@@ -17,8 +27,18 @@ import (
 	"gorm.io/gorm"
 )
 
-func NewRouter(db *gorm.DB) (*gin.Engine, error) {
+// NewRouter собирает зависимости и маршруты. linkCache == nil отключает кэш редиректов,
+// geoLocator == nil - определение страны посетителя, healthChecker == nil - метрики проверки адресов,
+// metadataQueue == nil - фоновую загрузку метаданных.
+func NewRouter(db *gorm.DB, cfg *config.Config, clickPipeline *clicks.Pipeline, linkCache cache.Cache, geoLocator geoip.Locator, healthChecker *health.Checker, metadataQueue *metadata.Queue) (*gin.Engine, error) {
 	router := gin.Default()
+	// X-Forwarded-For учитывается только от доверенных прокси, иначе IP посетителя можно подделать
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+	if len(cfg.TrustedProxies) == 0 {
+		log.Println("TRUSTED_PROXIES is empty: X-Forwarded-For and X-Real-IP are ignored, visitor IP is the connection address")
+	}
 
 	// Initialize repositories, services, and controllers
 	userRepo := userRepo.NewUserRepo(db)
@@ -26,9 +46,31 @@ func NewRouter(db *gorm.DB) (*gin.Engine, error) {
 	authService := authService.NewAuthService(authRepo, userRepo)
 	authController := authController.NewAuthController(authService)
 
-	shortenerRepo := shortenerRepo.NewShortenerRepo(db)
-	shortenerService := shortenerService.NewShortenerService(shortenerRepo)
-	shortenerController := shortenerController.NewShortenerController(shortenerService)
+	metricSources := []metrics.Source{clickPipeline}
+	if healthChecker != nil {
+		metricSources = append(metricSources, healthChecker)
+	}
+	var metadataScheduler metadata.Scheduler
+	if metadataQueue != nil {
+		metricSources = append(metricSources, metadataQueue)
+		metadataScheduler = metadataQueue
+	}
+
+	shortenerRepository := shortenerRepo.NewShortenerRepo(db)
+	idGenerator, err := utils.NewIDGenerator(cfg.ShortIDStrategy, cfg.ShortIDLength, cfg.ShortIDSalt, shortenerRepository)
+	if err != nil {
+		return nil, err
+	}
+	// Кэш разрешения коротких ссылок для редиректа
+	if linkCache != nil {
+		cachedRepo := shortenerRepo.NewCachedShortenerRepo(shortenerRepository, linkCache, cfg.CacheTTL, cfg.CacheNegativeTTL)
+		metricSources = append(metricSources, cachedRepo)
+		shortenerRepository = cachedRepo
+	}
+	linkAccess := shortenerService.NewLinkAccess([]byte(cfg.LinkAccessSecret), cfg.LinkAccessTTL, utils.NewRateLimiter(cfg.LinkPasswordAttempts, cfg.LinkPasswordWindow))
+	expiryPolicy := shortenerService.NewExpiryPolicy(cfg.LinkDefaultTTL, cfg.LinkMaxTTL)
+	shortenerService := shortenerService.NewShortenerService(shortenerRepository, userRepo, idGenerator, clickPipeline, linkAccess, expiryPolicy, geoLocator, newMetadataFetcher(cfg), metadataScheduler)
+	shortenerController := shortenerController.NewShortenerController(shortenerService, cfg.LinkAccessTTL)
 
 	// Create groups and routes
 	auth := router.Group("/auth")
@@ -41,14 +83,54 @@ func NewRouter(db *gorm.DB) (*gin.Engine, error) {
 	shortener := router.Group("/shortener")
 	{
 		shortener.GET("/", middleware.AuthMiddleware(), shortenerController.GetLinks)
-		shortener.GET("/:shortID", middleware.AuthMiddleware(), shortenerController.Redirect)
+		shortener.GET("/broken", middleware.AuthMiddleware(), shortenerController.GetBrokenLinks)
 		shortener.GET("/stats/:shortID", middleware.AuthMiddleware(), shortenerController.GetLink)
+		shortener.GET("/stats/:shortID/timeseries", middleware.AuthMiddleware(), shortenerController.GetLinkTimeSeries)
 		shortener.POST("/", middleware.AuthMiddleware(), shortenerController.CreateShortLink)
+		shortener.PATCH("/:shortID", middleware.AuthMiddleware(), shortenerController.UpdateLink)
 		shortener.DELETE("/:shortID", middleware.AuthMiddleware(), shortenerController.DeleteLink)
+		shortener.POST("/:shortID/extend", middleware.AuthMiddleware(), shortenerController.ExtendLink)
+		shortener.GET("/:shortID/qr", middleware.AuthMiddleware(), shortenerController.GetLinkQR)
+		shortener.POST("/:shortID/metadata", middleware.AuthMiddleware(), shortenerController.RefreshLinkMetadata)
+
+		shortener.GET("/shared", middleware.AuthMiddleware(), shortenerController.GetSharedLinks)
+		shortener.GET("/:shortID/shares", middleware.AuthMiddleware(), shortenerController.GetLinkShares)
+		shortener.POST("/:shortID/shares", middleware.AuthMiddleware(), shortenerController.ShareLink)
+		shortener.DELETE("/:shortID/shares/:username", middleware.AuthMiddleware(), shortenerController.UnshareLink)
+
+		shortener.GET("/:shortID/history", middleware.AuthMiddleware(), shortenerController.GetLinkHistory)
+		shortener.POST("/:shortID/history/:rev/restore", middleware.AuthMiddleware(), shortenerController.RestoreLinkRevision)
+
+		shortener.GET("/utm-templates", middleware.AuthMiddleware(), shortenerController.GetUTMTemplates)
+		shortener.POST("/utm-templates", middleware.AuthMiddleware(), shortenerController.CreateUTMTemplate)
+		shortener.PUT("/utm-templates/:name", middleware.AuthMiddleware(), shortenerController.UpdateUTMTemplate)
+		shortener.DELETE("/utm-templates/:name", middleware.AuthMiddleware(), shortenerController.DeleteUTMTemplate)
+	}
+
+	// Метрики в формате Prometheus, только с токеном
+	if cfg.MetricsToken != "" {
+		router.GET("/metrics", metrics.RequireToken(cfg.MetricsToken), metrics.Handler(metricSources...))
+	} else {
+		log.Println("METRICS_TOKEN is empty: /metrics is disabled")
 	}
 
 	// Serve Swagger UI
 	router.GET("/swagger/*any", swagger.WrapHandler(swaggerFiles.Handler))
 
+	// Публичный редирект по короткой ссылке, без авторизации
+	router.GET("/:shortID", shortenerController.Redirect)
+	router.POST("/:shortID", shortenerController.UnlockLink)
+
 	return router, nil
 }
+
+// newMetadataFetcher настраивает загрузку метаданных страниц назначения; nil отключает её
+func newMetadataFetcher(cfg *config.Config) metadata.Fetcher {
+	if !cfg.MetadataEnabled {
+		return nil
+	}
+	opts := metadata.DefaultOptions()
+	opts.Timeout = cfg.MetadataTimeout
+	opts.MaxBytes = int64(cfg.MetadataMaxBytes)
+	return metadata.NewClient(opts)
+}
@@ -0,0 +1,75 @@
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress - адрес назначения ведёт во внутреннюю сеть
+var ErrPrivateAddress = errors.New("destination resolves to a private network address")
+
+type Options struct {
+	Timeout      time.Duration // на весь запрос вместе с редиректами
+	MaxRedirects int
+	AllowPrivate bool // разрешить адреса внутренней сети; только для тестов
+}
+
+// NewClient создаёт клиент для запросов по адресам пользователей. Соединения с адресами
+// внутренней сети отклоняются после разрешения имени, поэтому их не обойти редиректом или DNS.
+func NewClient(opts Options) *http.Client {
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivate {
+		dialer.Control = rejectPrivate
+	}
+	transport := &http.Transport{
+		Proxy:                 nil, // прокси из окружения обошёл бы проверку адреса
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   opts.Timeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", opts.MaxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// rejectPrivate не даёт открыть соединение с адресом внутренней сети.
+// Вызывается для уже разрешённого адреса, в том числе после каждого редиректа.
+func rejectPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsPrivateIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// sharedAddressSpace - 100.64.0.0/10, адреса провайдерского NAT
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPrivateIP сообщает, что адрес не должен быть доступен по ссылке пользователя:
+// локальные, частные, link-local (в том числе метаданные облака 169.254.169.254) и служебные
+func IsPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
@@ -0,0 +1,52 @@
+package safehttp_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/safehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "192.168.0.10", "172.16.5.4", "169.254.169.254", "100.64.0.1", "::1", "fd00::1", "fe80::1", "0.0.0.0"} {
+		assert.True(t, safehttp.IsPrivateIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:4700::1111"} {
+		assert.False(t, safehttp.IsPrivateIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestClientRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/ftp":
+			http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := safehttp.NewClient(safehttp.Options{Timeout: time.Second, MaxRedirects: 2, AllowPrivate: true})
+	resp, err := client.Get(server.URL + "/ok")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	_, err = client.Get(server.URL + "/loop")
+	assert.ErrorContains(t, err, "stopped after 2 redirects")
+
+	_, err = client.Get(server.URL + "/ftp")
+	assert.ErrorContains(t, err, "unsupported scheme")
+
+	// без AllowPrivate локальный сервер недоступен
+	_, err = safehttp.NewClient(safehttp.Options{Timeout: time.Second}).Get(server.URL + "/ok")
+	assert.ErrorIs(t, err, safehttp.ErrPrivateAddress)
+}
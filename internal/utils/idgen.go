@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync/atomic"
+)
+
+// Стратегии генерации коротких идентификаторов
+const (
+	IDStrategyRandom   = "random"   // криптослучайная base62 строка с повтором при коллизии
+	IDStrategySequence = "sequence" // значение последовательности БД в base36
+	IDStrategyHashID   = "hashid"   // обфусцированный счётчик в духе hashids
+)
+
+const (
+	MaxShortIDLength = 16 // ограничение колонки short_id
+	// maxCounterIDLength - 36^12 ещё помещается в uint64
+	maxCounterIDLength = 12
+	// после стольких коллизий подряд случайные идентификаторы удлиняются
+	collisionsPerGrow = 3
+	// множитель для перемешивания счётчика, взаимно прост с 36
+	hashIDMultiplier = 1_000_000_007
+)
+
+// IDGenerator генерирует кандидатов в короткие идентификаторы.
+// Уникальность кандидата проверяет вызывающий код.
+type IDGenerator interface {
+	// NextID возвращает кандидата. attempt - номер попытки, начиная с 0,
+	// растёт после каждой коллизии.
+	NextID(attempt int) (string, error)
+}
+
+// SequenceSource выдаёт монотонно растущие значения, например из последовательности БД
+type SequenceSource interface {
+	NextSequence() (int64, error)
+}
+
+// NewIDGenerator создаёт генератор выбранной стратегии
+func NewIDGenerator(strategy string, length int, salt string, seq SequenceSource) (IDGenerator, error) {
+	if length < 1 || length > MaxShortIDLength {
+		return nil, fmt.Errorf("short id length must be between 1 and %d", MaxShortIDLength)
+	}
+
+	switch strategy {
+	case "", IDStrategyRandom:
+		return NewRandomIDGenerator(length), nil
+	case IDStrategySequence, IDStrategyHashID:
+		if seq == nil {
+			return nil, errors.New("sequence source is required for " + strategy + " strategy")
+		}
+		if length > maxCounterIDLength {
+			return nil, fmt.Errorf("short id length for %s strategy must not exceed %d", strategy, maxCounterIDLength)
+		}
+		if strategy == IDStrategySequence {
+			return &SequenceIDGenerator{minLength: length, seq: seq}, nil
+		}
+		return &HashIDGenerator{minLength: length, alphabet: shuffleAlphabet(base36Alphabet, salt), seq: seq}, nil
+	default:
+		return nil, fmt.Errorf("unknown short id strategy %q", strategy)
+	}
+}
+
+// RandomIDGenerator генерирует криптослучайные идентификаторы.
+// Когда коллизии становятся частыми, длина растёт для всех следующих идентификаторов.
+type RandomIDGenerator struct {
+	length atomic.Int32
+}
+
+func NewRandomIDGenerator(length int) *RandomIDGenerator {
+	g := &RandomIDGenerator{}
+	g.length.Store(int32(length))
+	return g
+}
+
+func (g *RandomIDGenerator) NextID(attempt int) (string, error) {
+	length := g.length.Load()
+	if attempt > 0 && attempt%collisionsPerGrow == 0 && length < MaxShortIDLength {
+		// пространство ключей заполняется - удлиняем идентификаторы
+		g.length.CompareAndSwap(length, length+1)
+		length = g.length.Load()
+	}
+	return RandStringBytes(int(length)), nil
+}
+
+// base36Alphabet - алфавит идентификаторов из счётчика. Занятость идентификаторов проверяется без учёта
+// регистра, поэтому в base62 значения, различающиеся только регистром последнего символа, считались бы
+// коллизией; в одном регистре каждое значение счётчика даёт действительно свободный идентификатор.
+const base36Alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// SequenceIDGenerator кодирует значения последовательности в base36.
+// Идентификаторы начинаются с минимальной длины и удлиняются по мере роста счётчика.
+type SequenceIDGenerator struct {
+	minLength int
+	seq       SequenceSource
+}
+
+func (g *SequenceIDGenerator) NextID(attempt int) (string, error) {
+	n, err := g.seq.NextSequence()
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		return "", errors.New("sequence value must not be negative")
+	}
+	// смещение, чтобы самый первый идентификатор уже имел минимальную длину
+	value := pow36(g.minLength-1) + uint64(n)
+	if value < uint64(n) || value >= pow36(maxCounterIDLength) {
+		return "", errors.New("short id keyspace exhausted")
+	}
+	return encodeBase36(value, base36Alphabet, 0), nil
+}
+
+// HashIDGenerator превращает значения последовательности в непредсказуемые идентификаторы.
+// Внутри блока одной длины счётчик перемешивается умножением по модулю 36^длина,
+// поэтому отображение взаимно однозначно и коллизий между значениями счётчика нет.
+// Алфавит перемешивается солью, так что без соли порядок выдачи не восстановить.
+type HashIDGenerator struct {
+	minLength int
+	alphabet  string
+	seq       SequenceSource
+}
+
+func (g *HashIDGenerator) NextID(attempt int) (string, error) {
+	n, err := g.seq.NextSequence()
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		return "", errors.New("sequence value must not be negative")
+	}
+
+	// выбираем блок длины, в который попадает значение
+	value := uint64(n)
+	length := g.minLength
+	for value >= pow36(length) {
+		if length >= maxCounterIDLength {
+			return "", errors.New("short id keyspace exhausted")
+		}
+		value -= pow36(length)
+		length++
+	}
+
+	hi, lo := bits.Mul64(value, hashIDMultiplier)
+	mixed := bits.Rem64(hi, lo, pow36(length))
+	return encodeBase36(mixed, g.alphabet, length), nil
+}
+
+func pow36(n int) uint64 {
+	result := uint64(1)
+	for i := 0; i < n; i++ {
+		result *= 36
+	}
+	return result
+}
+
+// encodeBase36 кодирует число, дополняя слева до width символов
+func encodeBase36(value uint64, alphabet string, width int) string {
+	var buf [16]byte
+	i := len(buf)
+	for value > 0 || i == len(buf) {
+		i--
+		buf[i] = alphabet[value%36]
+		value /= 36
+	}
+	for len(buf)-i < width {
+		i--
+		buf[i] = alphabet[0]
+	}
+	return string(buf[i:])
+}
+
+// shuffleAlphabet детерминированно перемешивает алфавит солью (как consistent shuffle в hashids)
+func shuffleAlphabet(alphabet, salt string) string {
+	result := []byte(alphabet)
+	if salt == "" {
+		return alphabet
+	}
+	for i, v, p := len(result)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		p += int(salt[v])
+		j := (int(salt[v]) + v + p) % i
+		result[i], result[j] = result[j], result[i]
+		v++
+	}
+	return string(result)
+}
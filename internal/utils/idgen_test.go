@@ -0,0 +1,84 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// counter - последовательность в памяти вместо БД
+type counter struct {
+	n int64
+}
+
+func (c *counter) NextSequence() (int64, error) {
+	c.n++
+	return c.n, nil
+}
+
+func TestRandomIDGeneratorGrowsOnCollisions(t *testing.T) {
+	gen, err := utils.NewIDGenerator(utils.IDStrategyRandom, 6, "", nil)
+	assert.NoError(t, err)
+
+	id, _ := gen.NextID(0)
+	assert.Len(t, id, 6)
+
+	// третья коллизия подряд удлиняет идентификатор, и длина сохраняется
+	id, _ = gen.NextID(3)
+	assert.Len(t, id, 7)
+	id, _ = gen.NextID(0)
+	assert.Len(t, id, 7)
+}
+
+func TestSequenceIDGenerator(t *testing.T) {
+	gen, err := utils.NewIDGenerator(utils.IDStrategySequence, 4, "", &counter{})
+	assert.NoError(t, err)
+
+	first, _ := gen.NextID(0)
+	second, _ := gen.NextID(0)
+	assert.Len(t, first, 4)
+	assert.NotEqual(t, first, second)
+
+	// соседние значения не различаются только регистром
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id, err := gen.NextID(0)
+		assert.NoError(t, err)
+		assert.False(t, seen[strings.ToLower(id)], "duplicate id %s", id)
+		seen[strings.ToLower(id)] = true
+	}
+}
+
+func TestHashIDGeneratorIsUniqueAndGrows(t *testing.T) {
+	// длина 1 - всего 36 значений, дальше идентификаторы удлиняются
+	gen, err := utils.NewIDGenerator(utils.IDStrategyHashID, 1, "salt", &counter{n: -1})
+	assert.NoError(t, err)
+
+	seen := map[string]bool{}
+	for i := 0; i < 36+36*36; i++ {
+		id, err := gen.NextID(0)
+		assert.NoError(t, err)
+		// идентификаторы сравниваются без учёта регистра
+		assert.Equal(t, strings.ToLower(id), id)
+		assert.False(t, seen[id], "duplicate id %s", id)
+		seen[id] = true
+		if i < 36 {
+			assert.Len(t, id, 1)
+		} else {
+			assert.Len(t, id, 2)
+		}
+	}
+}
+
+func TestNewIDGeneratorValidation(t *testing.T) {
+	_, err := utils.NewIDGenerator("unknown", 6, "", nil)
+	assert.Error(t, err)
+
+	_, err = utils.NewIDGenerator(utils.IDStrategyHashID, 6, "", nil)
+	assert.Error(t, err)
+
+	_, err = utils.NewIDGenerator(utils.IDStrategyRandom, 17, "", nil)
+	assert.Error(t, err)
+}
@@ -1,17 +1,27 @@
 package utils
 
-import "math/rand"
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+const base62Alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
 func GenerateShortLink() string {
-	// Example method to generate a random short link. You can replace it with a more sophisticated approach.
 	return RandStringBytes(6)
 }
 
+// RandStringBytes возвращает криптографически случайную base62 строку длины n
 func RandStringBytes(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	max := big.NewInt(int64(len(base62Alphabet)))
 	b := make([]byte, n)
 	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			// crypto/rand не должен возвращать ошибку на поддерживаемых платформах
+			panic(err)
+		}
+		b[i] = base62Alphabet[idx.Int64()]
 	}
 	return string(b)
 }
@@ -0,0 +1,24 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bigxxby/dream-test-task/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := utils.NewRateLimiter(2, 50*time.Millisecond)
+
+	assert.True(t, limiter.Allow("a"))
+	assert.True(t, limiter.Allow("a"))
+	assert.False(t, limiter.Allow("a"))
+
+	// у другого ключа свой счётчик
+	assert.True(t, limiter.Allow("b"))
+
+	// новое окно сбрасывает счётчик
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, limiter.Allow("a"))
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"github.com/bigxxby/dream-test-task/internal/config"
+	"github.com/mileusna/useragent"
+)
+
+// Классы устройств
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// UserAgentInfo - разобранный заголовок User-Agent
+type UserAgentInfo struct {
+	Browser string
+	OS      string
+	Device  string
+}
+
+// ParseUserAgent определяет браузер, ОС и класс устройства
+func ParseUserAgent(userAgent string) UserAgentInfo {
+	ua := useragent.Parse(userAgent)
+
+	info := UserAgentInfo{
+		Browser: ua.Name,
+		OS:      ua.OS,
+		Device:  DeviceUnknown,
+	}
+	switch {
+	case ua.Bot:
+		info.Device = DeviceBot
+	case ua.Tablet:
+		info.Device = DeviceTablet
+	case ua.Mobile:
+		info.Device = DeviceMobile
+	case ua.Desktop:
+		info.Device = DeviceDesktop
+	}
+	return info
+}
+
+// HashIP возвращает HMAC-SHA256 адреса с солью из конфигурации,
+// чтобы считать уникальных посетителей, не храня сами адреса
+func HashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, config.IPHashSalt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ReferrerHost оставляет от Referer только хост
+func ReferrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	parsed, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// PrimaryLanguage возвращает первый язык из Accept-Language, например "en-US"
+func PrimaryLanguage(acceptLanguage string) string {
+	first, _, _ := strings.Cut(acceptLanguage, ",")
+	tag, _, _ := strings.Cut(first, ";")
+	tag = strings.TrimSpace(tag)
+	if tag == "*" || len(tag) > 35 {
+		return ""
+	}
+	return tag
+}
@@ -0,0 +1,42 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/bigxxby/dream-test-task/internal/config"
+	"github.com/bigxxby/dream-test-task/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	iphone := utils.ParseUserAgent("Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1")
+	assert.Equal(t, "Safari", iphone.Browser)
+	assert.Equal(t, "iOS", iphone.OS)
+	assert.Equal(t, utils.DeviceMobile, iphone.Device)
+
+	desktop := utils.ParseUserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	assert.Equal(t, "Chrome", desktop.Browser)
+	assert.Equal(t, "Windows", desktop.OS)
+	assert.Equal(t, utils.DeviceDesktop, desktop.Device)
+
+	assert.Equal(t, utils.DeviceUnknown, utils.ParseUserAgent("").Device)
+}
+
+func TestHashIP(t *testing.T) {
+	config.IPHashSalt = []byte("salt")
+	hash := utils.HashIP("192.0.2.1")
+
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, utils.HashIP("192.0.2.1"))
+	assert.NotEqual(t, hash, utils.HashIP("192.0.2.2"))
+	assert.NotContains(t, hash, "192.0.2.1")
+}
+
+func TestVisitHeaders(t *testing.T) {
+	assert.Equal(t, "news.example.com", utils.ReferrerHost("https://News.Example.com/post?id=1"))
+	assert.Equal(t, "", utils.ReferrerHost(""))
+
+	assert.Equal(t, "en-US", utils.PrimaryLanguage("en-US,en;q=0.9,ru;q=0.8"))
+	assert.Equal(t, "ru", utils.PrimaryLanguage("ru;q=0.8"))
+	assert.Equal(t, "", utils.PrimaryLanguage("*"))
+}